@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"nexus-retention-policy/internal/config"
+	"nexus-retention-policy/internal/logger"
+	"nexus-retention-policy/internal/retention"
+)
+
+// repositoryCount is the number and size of the components a repository
+// would lose.
+type repositoryCount struct {
+	name       string
+	components int
+	bytes      int64
+	err        string
+}
+
+// countOnly plans a run and prints only the number and size of the
+// components each repository would lose, for capacity estimates. Nothing is
+// deleted, logged, emailed or written to state files, and the engine's
+// output is discarded.
+func countOnly(configPath string, opts retention.Options) error {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return &configError{fmt.Errorf("failed to load config: %w", err)}
+	}
+	if opts.Rule != "" && cfg.Rule(opts.Rule) == nil {
+		return &configError{fmt.Errorf("unknown rule '%s'", opts.Rule)}
+	}
+
+	opts.DryRun = true
+	opts.Verbosity = retention.VerbositySummary
+
+	configs := []*config.Config{cfg}
+	prefixes := []string{""}
+	if len(cfg.Instances) > 0 {
+		configs, prefixes = nil, nil
+		for i, inst := range cfg.Instances {
+			configs = append(configs, cfg.ForInstance(i))
+			prefixes = append(prefixes, inst.Name+"/")
+		}
+	}
+
+	var counts []repositoryCount
+	var runErr error
+	for i, c := range configs {
+		c.LastPlanFile, c.PerRepoReportsDir = "", ""
+		c.Email = config.EmailConfig{}
+		engine := retention.NewPolicyEngine(newRegistry(c), c, discardLog{}, opts)
+
+		result, err := silenced(engine.Execute)
+		if result == nil {
+			return err
+		}
+		if err != nil && runErr == nil {
+			runErr = err
+		}
+		for _, repo := range result.Repositories {
+			counts = append(counts, countRepository(c, prefixes[i], repo))
+		}
+	}
+
+	printCounts(counts)
+	return runErr
+}
+
+// countRepository sums the planned deletions of a repository. Deletions of
+// dry-run rules are left out, since executing wouldn't make them.
+func countRepository(cfg *config.Config, prefix string, repo retention.RepositoryResult) repositoryCount {
+	count := repositoryCount{name: prefix + repo.Name, err: repo.Error}
+	for _, image := range repo.Images {
+		if rule := cfg.Rule(image.Rule); rule != nil && rule.DryRun {
+			continue
+		}
+		count.components += image.Deleted
+		count.bytes += image.ReclaimedBytes
+	}
+	return count
+}
+
+// printCounts lists the counts per repository and their total.
+func printCounts(counts []repositoryCount) {
+	var total repositoryCount
+	fmt.Println("📊 Would delete:")
+	for _, c := range counts {
+		if c.err != "" {
+			fmt.Printf("   %s: ⚠️  %s\n", c.name, c.err)
+			continue
+		}
+		fmt.Printf("   %s: %d components, %s\n", c.name, c.components, retention.FormatBytes(c.bytes))
+		total.components += c.components
+		total.bytes += c.bytes
+	}
+	fmt.Printf("   Total: %d components, %s\n", total.components, retention.FormatBytes(total.bytes))
+}
+
+// silenced runs fn with standard output discarded.
+func silenced(fn func() (*retention.RunResult, error)) (*retention.RunResult, error) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return fn()
+	}
+	stdout := os.Stdout
+	os.Stdout = devNull
+	defer func() {
+		os.Stdout = stdout
+		devNull.Close()
+	}()
+	return fn()
+}
+
+// discardLog drops deletion records.
+type discardLog struct{}
+
+func (discardLog) LogDeletion(logger.DeletionRecord) error { return nil }
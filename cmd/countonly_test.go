@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"nexus-retention-policy/internal/config"
+	"nexus-retention-policy/internal/logger"
+	"nexus-retention-policy/internal/nexus"
+	"nexus-retention-policy/internal/retention"
+)
+
+func TestCountRepositoryMatchesPlan(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(nexus.Repository{Name: "docker-a", Format: "docker", Type: "hosted"},
+		append(testImage("app", 3), testImage("tool", 3)...)...)
+	fake.AddRepository(nexus.Repository{Name: "docker-b", Format: "docker", Type: "hosted"}, testImage("app", 2)...)
+
+	cfg, err := config.Parse([]byte(`
+nexus: {url: "http://nexus.test", username: admin, password: secret}
+rules:
+  - {name: apps, regex: "^app$", keep: 1}
+  - {name: trial, regex: "^tool$", keep: 1, dry_run: true}
+`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	engine := retention.NewPolicyEngine(fake, cfg, logger.NewMemoryLogger(), retention.Options{})
+	plan, result, err := engine.Plan()
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+
+	planned := make(map[string]int)
+	for _, d := range plan.Deletions {
+		planned[d.Repository]++
+	}
+	for _, repo := range result.Repositories {
+		count := countRepository(cfg, "", repo)
+		if count.components != planned[repo.Name] || count.bytes != int64(planned[repo.Name])*1024 {
+			t.Errorf("%s: counted %d components of %d bytes, want the plan's %d", repo.Name, count.components, count.bytes, planned[repo.Name])
+		}
+	}
+	if planned["docker-a"] != 2 || planned["docker-b"] != 1 {
+		t.Errorf("planned %v, want 2 deletions in docker-a and 1 in docker-b", planned)
+	}
+	if len(fake.Deleted) != 0 {
+		t.Errorf("deleted %v while planning", fake.Deleted)
+	}
+}
+
+func TestCountRepositoryPrefixesInstance(t *testing.T) {
+	cfg := &config.Config{}
+	count := countRepository(cfg, "eu/", retention.RepositoryResult{Name: "docker-hosted", Error: "forbidden"})
+	if count.name != "eu/docker-hosted" || count.err != "forbidden" {
+		t.Errorf("count = %+v, want eu/docker-hosted with its error", count)
+	}
+}
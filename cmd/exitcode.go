@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+
+	"nexus-retention-policy/internal/nexus"
+	"nexus-retention-policy/internal/retention"
+)
+
+// Exit codes reported to automation.
+const (
+	exitOK         = 0
+	exitConfig     = 1
+	exitConnection = 2
+	exitPartial    = 3
+	exitGuardrail  = 4
+)
+
+// configError marks an invalid or unreadable configuration.
+type configError struct {
+	err error
+}
+
+func (e *configError) Error() string { return e.err.Error() }
+func (e *configError) Unwrap() error { return e.err }
+
+// exitCode maps an error returned by a command to the process exit code.
+// Errors that fit no other category, such as usage errors, exit with 1.
+func exitCode(err error) int {
+	var (
+		cfgErr    *configError
+		guardrail *retention.GuardrailError
+		partial   *retention.PartialFailureError
+		breaker   *retention.CircuitOpenError
+		stage     *stageError
+		apiErr    *nexus.APIError
+		netErr    net.Error
+	)
+
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &cfgErr):
+		return exitConfig
+	case errors.As(err, &guardrail):
+		return exitGuardrail
+	case errors.As(err, &partial):
+		return exitPartial
+	case errors.As(err, &breaker), errors.As(err, &stage), errors.As(err, &apiErr), errors.As(err, &netErr):
+		return exitConnection
+	}
+	return exitConfig
+}
+
+// exit terminates the process with the exit code for err.
+func exit(err error) {
+	os.Exit(exitCode(err))
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"nexus-retention-policy/internal/nexus"
+	"nexus-retention-policy/internal/retention"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, exitOK},
+		{"config", &configError{errors.New("invalid keep")}, exitConfig},
+		{"usage", errors.New("unknown command"), exitConfig},
+		{"unreachable", &net.DNSError{Err: "no such host", Name: "nexus.test"}, exitConnection},
+		{"unauthorized", &nexus.APIError{StatusCode: 401}, exitConnection},
+		{"network check", &stageError{stage: "TLS", err: errors.New("bad certificate")}, exitConnection},
+		{"circuit open", &retention.CircuitOpenError{Reason: "5 consecutive failures"}, exitConnection},
+		{"partial failure", &retention.PartialFailureError{Failed: 2}, exitPartial},
+		{"guardrail", &retention.GuardrailError{Reason: "too many deletions"}, exitGuardrail},
+		{"wrapped", fmt.Errorf("failed to run: %w", &retention.GuardrailError{}), exitGuardrail},
+	}
+
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("%s: exitCode(%v) = %d, want %d", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"nexus-retention-policy/internal/config"
+	"nexus-retention-policy/internal/retention"
+)
+
+// instance is the policy engine of one registry. name is empty for a
+// configuration without instances.
+type instance struct {
+	name     string
+	url      string
+	engine   *retention.PolicyEngine
+	closeLog func()
+}
+
+// runner executes the policy against every configured instance, or the
+// single registry of the nexus section.
+type runner struct {
+	instances []instance
+	parallel  bool
+}
+
+// setupRunner loads the configuration and builds an engine per instance.
+func setupRunner(configPath string, opts retention.Options) (*runner, *config.Config, error) {
+	cfg, err := loadConfig(configPath, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := newRunner(cfg, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r, cfg, nil
+}
+
+// newRunner builds an engine per instance of a loaded configuration,
+// opening their deletion logs.
+func newRunner(cfg *config.Config, opts retention.Options) (*runner, error) {
+	r := &runner{parallel: cfg.InstancesParallel}
+	if len(cfg.Instances) == 0 {
+		engine, closeLog, err := newEngine(cfg, opts)
+		if err != nil {
+			return nil, err
+		}
+		r.instances = []instance{{url: cfg.Nexus.URL, engine: engine, closeLog: closeLog}}
+		return r, nil
+	}
+
+	if r.parallel && opts.Status != nil {
+		// Concurrent runs would overwrite each other's progress
+		fmt.Println("⚠️  Run progress is not tracked with instances_parallel")
+		opts.Status = nil
+	}
+	for i, inst := range cfg.Instances {
+		instCfg := cfg.ForInstance(i)
+		engine, closeLog, err := newEngine(instCfg, opts)
+		if err != nil {
+			r.close()
+			return nil, fmt.Errorf("instance %s: %w", inst.Name, err)
+		}
+		r.instances = append(r.instances, instance{name: inst.Name, url: instCfg.Nexus.URL, engine: engine, closeLog: closeLog})
+	}
+	return r, nil
+}
+
+// execute runs the policy against all instances, one after another or
+// concurrently, and prints a summary per instance. Errors of the instances
+// are joined, each prefixed with its instance.
+func (r *runner) execute() error {
+	if len(r.instances) == 1 && r.instances[0].name == "" {
+		_, err := r.instances[0].engine.Execute()
+		return err
+	}
+
+	results := make([]*retention.RunResult, len(r.instances))
+	errs := make([]error, len(r.instances))
+	runInstance := func(i int) {
+		inst := r.instances[i]
+		fmt.Printf("\n🖥️  Instance %s (%s)\n", inst.name, inst.url)
+		results[i], errs[i] = inst.engine.Execute()
+	}
+
+	if r.parallel {
+		var wg sync.WaitGroup
+		for i := range r.instances {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				runInstance(i)
+			}(i)
+		}
+		wg.Wait()
+	} else {
+		for i := range r.instances {
+			runInstance(i)
+		}
+	}
+
+	var failed []error
+	fmt.Println("\n🖥️  Instances:")
+	for i, inst := range r.instances {
+		if errs[i] != nil {
+			failed = append(failed, fmt.Errorf("instance %s: %w", inst.name, errs[i]))
+		}
+		switch result := results[i]; {
+		case result == nil:
+			fmt.Printf("   %s: ❌ %v\n", inst.name, errs[i])
+		case errs[i] != nil:
+			fmt.Printf("   %s: %d deleted, %d kept, ⚠️  %v\n", inst.name, result.Deleted, result.Kept, errs[i])
+		default:
+			fmt.Printf("   %s: %d deleted, %d kept\n", inst.name, result.Deleted, result.Kept)
+		}
+	}
+	return errors.Join(failed...)
+}
+
+// close closes the deletion logs of all instances.
+func (r *runner) close() {
+	for _, inst := range r.instances {
+		inst.closeLog()
+	}
+}
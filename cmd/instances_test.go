@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"nexus-retention-policy/internal/config"
+	"nexus-retention-policy/internal/logger"
+	"nexus-retention-policy/internal/nexus"
+	"nexus-retention-policy/internal/retention"
+)
+
+// unreachable is a registry whose listing fails.
+type unreachable struct{ *nexus.FakeClient }
+
+func (unreachable) GetRepositories() ([]nexus.Repository, error) {
+	return nil, errors.New("connection refused")
+}
+
+// testImage returns n tags of an image, the newest first, each of 1 KiB.
+func testImage(image string, n int) []nexus.Component {
+	var components []nexus.Component
+	for i := n; i >= 1; i-- {
+		tag := fmt.Sprintf("v%d", i)
+		components = append(components, nexus.Component{
+			ID: image + ":" + tag, Name: image, Version: tag,
+			Assets: []nexus.Asset{{LastModified: time.Date(2024, 1, 1, i, 0, 0, 0, time.UTC), FileSize: 1024}},
+		})
+	}
+	return components
+}
+
+// testRegistry returns a registry with n tags of app.
+func testRegistry(n int) *nexus.FakeClient {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(nexus.Repository{Name: "docker-hosted", Format: "docker", Type: "hosted"}, testImage("app", n)...)
+	return fake
+}
+
+// testRunner returns a runner applying a keep-one policy to an instance per
+// registry.
+func testRunner(t *testing.T, parallel bool, registries ...retention.Registry) *runner {
+	t.Helper()
+	settings := `
+nexus: {username: admin, password: secret}
+rules: [{name: all, regex: ".*", keep: 1}]
+instances:
+`
+	for i := range registries {
+		name := string(rune('a' + i))
+		settings += fmt.Sprintf("  - {name: %s, url: \"http://%s.test\"}\n", name, name)
+	}
+	cfg, err := config.Parse([]byte(settings))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+
+	r := &runner{parallel: parallel}
+	for i, registry := range registries {
+		instCfg := cfg.ForInstance(i)
+		engine := retention.NewPolicyEngine(registry, instCfg, logger.NewMemoryLogger(), retention.Options{})
+		r.instances = append(r.instances, instance{name: cfg.Instances[i].Name, url: instCfg.Nexus.URL, engine: engine, closeLog: func() {}})
+	}
+	return r
+}
+
+func TestRunnerExecutesEachInstance(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		a, b := testRegistry(3), testRegistry(2)
+		if err := testRunner(t, parallel, a, b).execute(); err != nil {
+			t.Fatalf("execute (parallel %t): %v", parallel, err)
+		}
+		if len(a.Deleted) != 2 || len(b.Deleted) != 1 {
+			t.Errorf("parallel %t: deleted %v and %v, want app:v1 and app:v2, and app:v1", parallel, a.Deleted, b.Deleted)
+		}
+	}
+}
+
+func TestRunnerContinuesPastFailedInstance(t *testing.T) {
+	b := testRegistry(2)
+	err := testRunner(t, false, unreachable{nexus.NewFakeClient()}, b).execute()
+
+	if err == nil || !strings.Contains(err.Error(), "instance a: ") {
+		t.Errorf("execute returned %v, want the error of instance a", err)
+	}
+	if len(b.Deleted) != 1 {
+		t.Errorf("deleted %v on instance b, want app:v1", b.Deleted)
+	}
+}
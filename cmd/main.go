@@ -3,93 +3,314 @@ package main
 import (
 	"flag"
 	"fmt"
+	"math/rand"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"nexus-retention-policy/internal/config"
+	"nexus-retention-policy/internal/events"
 	"nexus-retention-policy/internal/logger"
-	"nexus-retention-policy/internal/nexus"
+	"nexus-retention-policy/internal/registry"
 	"nexus-retention-policy/internal/retention"
-
-	"github.com/robfig/cron/v3"
+	"nexus-retention-policy/internal/tracing"
 )
 
 func main() {
-	configPath := flag.String("config", "config.yaml", "Path to configuration file")
+	if len(os.Args) > 1 {
+		var subcommand func([]string) error
+		switch os.Args[1] {
+		case "verify-log":
+			subcommand = verifyLog
+		case "validate":
+			subcommand = validateCommand
+		case "plan":
+			subcommand = planCommand
+		case "apply":
+			subcommand = applyCommand
+		case "review":
+			subcommand = reviewCommand
+		}
+
+		if subcommand != nil {
+			if err := subcommand(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exit(err)
+			}
+			return
+		}
+	}
+
+	configPath := flag.String("config", "config.yaml", "Path or http(s) URL of configuration file")
 	exec := flag.Bool("exec", false, "Execute deletions (default is dry-run mode)")
-	verbose := flag.Bool("verbose", false, "Verbose output (show all images including unmatched)")
+	verbosity := verbosityFlags(flag.CommandLine)
+	force := flag.Bool("force", false, "Execute deletions even outside the configured allowed hours")
+	maxRepos := flag.Int("max-repos", 0, "Only process the first N repositories sorted by name (0 = all)")
+	rule := flag.String("rule", "", "Only apply the rule with this name, skipping images matched by other rules")
+	listLimit := flag.Int("list-limit", 0, "Only list the newest N tags per image (0 = all)")
+	explain := flag.String("explain", "", "Explain the decisions for a single <repository>/<image> without deleting")
+	overrideReport := flag.String("protected-override-report", "", "Append protected tags that rules would have deleted to this CSV file")
+	checkPermissions := flag.Bool("check-permissions", false, "In dry-run mode, check that the account may delete in each repository")
+	sinceLastRun := flag.Bool("since-last-run", false, "Only evaluate images with components modified since the last successful run")
+	validateOnlyNetwork := flag.Bool("validate-only-network", false, "Check DNS, TCP, TLS and authenticated HTTP access to the registry, then exit")
+	countOnlyFlag := flag.Bool("count-only", false, "Only print the number and size of components each repository would lose, without deleting")
+	statusAddr := flag.String("status-addr", "", "Serve run progress as JSON on http://<addr>/status, e.g. \"localhost:8080\"")
 	flag.Parse()
 
-	if err := run(*configPath, *exec, *verbose); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	opts := retention.Options{
+		DryRun:                  !*exec,
+		Verbosity:               verbosity(),
+		Force:                   *force,
+		MaxRepos:                *maxRepos,
+		Rule:                    *rule,
+		ProtectedOverrideReport: *overrideReport,
+		CheckPermissions:        *checkPermissions,
+		SinceLastRun:            *sinceLastRun,
+		ListLimit:               *listLimit,
 	}
-}
 
-func run(configPath string, exec bool, verbose bool) error {
-	// Load configuration
-	cfg, err := config.Load(configPath)
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+	if *validateOnlyNetwork {
+		if err := validateNetwork(*configPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(err)
+		}
+		return
 	}
 
-	dryRun := !exec
+	if *countOnlyFlag {
+		if err := countOnly(*configPath, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(err)
+		}
+		return
+	}
 
-	fmt.Println("🚀 Nexus Retention Policy Tool")
-	fmt.Println("================================")
+	if *explain != "" {
+		opts.DryRun = true
+		if err := explainImage(*configPath, *explain, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(err)
+		}
+		return
+	}
 
-	// Initialize logger
-	log, err := logger.NewLogger(cfg.LogFile)
-	if err != nil {
-		return fmt.Errorf("failed to initialize logger: %w", err)
+	if *statusAddr != "" {
+		opts.Status = retention.NewStatusTracker()
+		serveStatus(*statusAddr, opts.Status)
 	}
-	defer log.Close()
 
-	// Initialize Nexus client
-	client := nexus.NewClient(cfg.Nexus.URL, cfg.Nexus.Username, cfg.Nexus.Password, cfg.Nexus.Timeout)
+	if err := run(*configPath, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(err)
+	}
+}
 
-	// Initialize policy engine
-	engine := retention.NewPolicyEngine(client, cfg, log, dryRun, verbose)
+func run(configPath string, opts retention.Options) error {
+	runner, cfg, err := setupRunner(configPath, opts)
+	if err != nil {
+		return err
+	}
 
 	// Check if scheduling is enabled
 	if cfg.Schedule == "" {
+		defer runner.close()
+
 		// One-time execution
 		fmt.Println("Mode: One-time execution")
-		return engine.Execute()
+		return runner.execute()
 	}
 
 	// Scheduled execution
+	s := newScheduler(configPath, opts, runner, cfg)
+
 	fmt.Printf("Mode: Scheduled execution (%s)\n", cfg.Schedule)
-	fmt.Println("Press Ctrl+C to stop")
+	fmt.Println("Press Ctrl+C to stop, send SIGHUP to reload the configuration")
+
+	s.cron.Start()
 
-	c := cron.New()
-	_, err = c.AddFunc(cfg.Schedule, func() {
-		fmt.Printf("\n⏰ Scheduled execution started at %s\n", formatTime())
-		if err := engine.Execute(); err != nil {
-			fmt.Fprintf(os.Stderr, "Execution error: %v\n", err)
+	// Wait for interrupt signal, reloading on SIGHUP
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range sigChan {
+		if sig != syscall.SIGHUP {
+			break
 		}
-		fmt.Printf("⏰ Scheduled execution completed at %s\n", formatTime())
-	})
 
+		fmt.Printf("\n🔄 Reloading configuration from %s\n", configPath)
+		if err := s.reload(); err != nil {
+			fmt.Fprintf(os.Stderr, "Reload failed, keeping current configuration: %v\n", err)
+			continue
+		}
+		fmt.Println("🔄 Configuration reloaded")
+	}
+
+	fmt.Println("\n\n👋 Shutting down gracefully...")
+	s.stop()
+
+	return nil
+}
+
+// setup loads the configuration and builds the policy engine. The returned
+// function closes the deletion log. Configurations with instances are
+// rejected, their engines are built by setupRunner.
+func setup(configPath string, opts retention.Options) (*retention.PolicyEngine, *config.Config, func(), error) {
+	cfg, err := loadConfig(configPath, opts)
 	if err != nil {
-		return fmt.Errorf("invalid cron schedule: %w", err)
+		return nil, nil, nil, err
+	}
+	if len(cfg.Instances) > 0 {
+		return nil, nil, nil, &configError{fmt.Errorf("this command doesn't support instances, use a configuration with a single nexus section")}
 	}
 
-	c.Start()
+	engine, closeLog, err := newEngine(cfg, opts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return engine, cfg, closeLog, nil
+}
 
-	// Wait for interrupt signal
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	<-sigChan
+// loadConfig loads the configuration and prints its warnings.
+func loadConfig(configPath string, opts retention.Options) (*config.Config, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, &configError{fmt.Errorf("failed to load config: %w", err)}
+	}
+	if opts.Rule != "" && cfg.Rule(opts.Rule) == nil {
+		return nil, &configError{fmt.Errorf("unknown rule '%s'", opts.Rule)}
+	}
 
-	fmt.Println("\n\n👋 Shutting down gracefully...")
-	c.Stop()
+	fmt.Println("🚀 Nexus Retention Policy Tool")
+	fmt.Println("================================")
+	for _, warning := range cfg.Warnings() {
+		fmt.Printf("⚠️  %s\n", warning)
+	}
+	return cfg, nil
+}
+
+// newEngine builds the policy engine of a configuration with its event
+// sinks. The returned function closes the deletion log.
+func newEngine(cfg *config.Config, opts retention.Options) (*retention.PolicyEngine, func(), error) {
+	// Initialize event sinks
+	var sinks []events.EventSink
+	for _, sinkCfg := range cfg.Sinks {
+		sink, err := newEventSink(cfg, sinkCfg)
+		if err != nil {
+			events.NewFanout(sinks...).Close()
+			return nil, nil, fmt.Errorf("failed to initialize %s sink: %w", sinkCfg.Type, err)
+		}
+		sinks = append(sinks, sink)
+	}
+	fanout := events.NewFanout(sinks...)
+	if cfg.RedactImageNames {
+		fanout.RedactImageNames()
+	}
+	closeLog := func() {
+		if err := fanout.Close(); err != nil {
+			fmt.Printf("⚠️  Failed to close event sinks: %v\n", err)
+		}
+	}
+
+	client := newRegistry(cfg)
 
+	// Initialize policy engine
+	opts.Tracer = tracing.New(cfg.Tracing.OTLPEndpoint, cfg.Tracing.ServiceName)
+	engine := retention.NewPolicyEngine(client, cfg, fanout, opts)
+
+	return engine, closeLog, nil
+}
+
+// newEventSink creates an event sink. Remote sinks are buffered, so they
+// never slow down or fail a run.
+func newEventSink(cfg *config.Config, sinkCfg config.SinkConfig) (events.EventSink, error) {
+	switch sinkCfg.Type {
+	case config.SinkCSV:
+		logOpts := logger.Options{
+			HashChain:  cfg.LogHashChain,
+			OmitHeader: !cfg.WriteLogHeader(),
+			MaxSize:    int64(cfg.LogMaxSize) * 1024 * 1024,
+			Compress:   cfg.LogCompress,
+			Location:   cfg.LogLocation(),
+		}
+		if cfg.SeparateDryRunLog {
+			logOpts.DryRunPath = cfg.DryRunLogFile()
+		}
+		log, err := logger.NewLogger(cfg.LogFile, logOpts)
+		if err != nil {
+			return nil, err
+		}
+		return events.NewCSVSink(log), nil
+	case config.SinkJSON:
+		return events.NewJSONFileSink(sinkCfg.Path)
+	case config.SinkStdout:
+		return events.NewJSONSink(os.Stdout), nil
+	case config.SinkWebhook:
+		return events.NewBufferedSink(events.NewHTTPSink(sinkCfg.URL), cfg.Events.BufferSize), nil
+	case config.SinkNATS:
+		sink, err := events.NewNATSSink(sinkCfg.URL, sinkCfg.Subject)
+		if err != nil {
+			return nil, err
+		}
+		return events.NewBufferedSink(sink, cfg.Events.BufferSize), nil
+	}
+	return nil, fmt.Errorf("unknown sink type '%s'", sinkCfg.Type)
+}
+
+// newRegistry creates the client for the configured backend.
+func newRegistry(cfg *config.Config) retention.Registry {
+	return registry.New(cfg, userAgent())
+}
+
+// verbosityFlags registers the -v and -vv flags and returns a function
+// reporting the selected level after parsing.
+func verbosityFlags(fs *flag.FlagSet) func() retention.Verbosity {
+	v := fs.Bool("v", false, "Print each matched image")
+	vv := fs.Bool("vv", false, "Print each tag's decision, unmatched images and full errors")
+	return func() retention.Verbosity {
+		switch {
+		case *vv:
+			return retention.VerbosityTag
+		case *v:
+			return retention.VerbosityImage
+		}
+		return retention.VerbositySummary
+	}
+}
+
+// explainImage prints the decision trace for a single image.
+func explainImage(configPath, ref string, opts retention.Options) error {
+	engine, _, closeLog, err := setup(configPath, opts)
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+
+	return engine.Explain(ref)
+}
+
+// verifyLog implements the verify-log subcommand.
+func verifyLog(args []string) error {
+	fs := flag.NewFlagSet("verify-log", flag.ExitOnError)
+	logPath := fs.String("log", "deletion_log.csv", "Path to the hash-chained deletion log")
+	fs.Parse(args)
+
+	count, err := logger.VerifyLog(*logPath)
+	if err != nil {
+		return fmt.Errorf("log verification failed after %d valid rows: %w", count, err)
+	}
+
+	fmt.Printf("✅ Log chain intact (%d rows verified)\n", count)
 	return nil
 }
 
 func formatTime() string {
 	return time.Now().Format("2006-01-02 15:04:05")
 }
+
+// jitterDelay returns a random delay between zero and maxSeconds.
+func jitterDelay(maxSeconds int) time.Duration {
+	if maxSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(maxSeconds) * int64(time.Second)))
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nexus-retention-policy/internal/retention"
+)
+
+func TestVerbosityFlags(t *testing.T) {
+	tests := []struct {
+		args []string
+		want retention.Verbosity
+	}{
+		{nil, retention.VerbositySummary},
+		{[]string{"-v"}, retention.VerbosityImage},
+		{[]string{"-vv"}, retention.VerbosityTag},
+		{[]string{"-v", "-vv"}, retention.VerbosityTag},
+	}
+
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		verbosity := verbosityFlags(fs)
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatalf("parse %v: %v", tt.args, err)
+		}
+		if got := verbosity(); got != tt.want {
+			t.Errorf("verbosity for %v = %d, want %d", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigUnknownRule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+nexus: {url: "http://nexus.test", username: admin, password: secret}
+rules: [{name: all, regex: ".*", keep: 1}]
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loadConfig(path, retention.Options{Rule: "all"}); err != nil {
+		t.Errorf("loadConfig with an existing rule: %v", err)
+	}
+	_, err := loadConfig(path, retention.Options{Rule: "missing"})
+	if err == nil || exitCode(err) != exitConfig {
+		t.Errorf("loadConfig with an unknown rule returned %v, want a config error", err)
+	}
+}
+
+func TestJitterDelay(t *testing.T) {
+	if d := jitterDelay(0); d != 0 {
+		t.Errorf("jitterDelay(0) = %v, want 0", d)
+	}
+	if d := jitterDelay(-5); d != 0 {
+		t.Errorf("jitterDelay(-5) = %v, want 0", d)
+	}
+	for i := 0; i < 1000; i++ {
+		if d := jitterDelay(3); d < 0 || d >= 3*time.Second {
+			t.Fatalf("jitterDelay(3) = %v, want within [0, 3s)", d)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"nexus-retention-policy/internal/config"
+	"nexus-retention-policy/internal/retention"
+)
+
+// stageError is the failure of a network check stage.
+type stageError struct {
+	stage string
+	err   error
+}
+
+func (e *stageError) Error() string { return fmt.Sprintf("%s check failed: %v", e.stage, e.err) }
+func (e *stageError) Unwrap() error { return e.err }
+
+// networkCheck checks reachability of the registry stage by stage, so a
+// failure points to DNS, the network path, TLS or the credentials. The
+// stages are fields so they can be replaced, e.g. to simulate failures.
+type networkCheck struct {
+	timeout  time.Duration
+	resolve  func(ctx context.Context, host string) ([]string, error)
+	dial     func(ctx context.Context, network, addr string) (net.Conn, error)
+	proxy    func(req *http.Request) (*url.URL, error)
+	registry retention.Registry
+}
+
+func newNetworkCheck(cfg *config.Config) *networkCheck {
+	timeout := time.Duration(cfg.Nexus.Timeout) * time.Second
+	dialer := &net.Dialer{Timeout: timeout}
+	return &networkCheck{
+		timeout:  timeout,
+		resolve:  net.DefaultResolver.LookupHost,
+		dial:     dialer.DialContext,
+		proxy:    http.ProxyFromEnvironment,
+		registry: newRegistry(cfg),
+	}
+}
+
+// run checks the stages in order and returns the error of the first failing
+// stage.
+func (c *networkCheck) run(rawURL string) error {
+	fmt.Printf("🌐 Checking %s\n", rawURL)
+
+	target, err := url.Parse(rawURL)
+	if err != nil || target.Host == "" {
+		return &configError{fmt.Errorf("invalid nexus.url '%s'", rawURL)}
+	}
+
+	// With a proxy, the network stages check the path to the proxy
+	host, port := target.Hostname(), target.Port()
+	if port == "" {
+		port = "80"
+		if target.Scheme == "https" {
+			port = "443"
+		}
+	}
+	proxy, err := c.proxy(&http.Request{URL: target})
+	if err != nil {
+		return fmt.Errorf("invalid proxy configuration: %w", err)
+	}
+	if proxy != nil {
+		fmt.Printf("  ℹ️  Using proxy %s\n", proxy.Host)
+		host, port = proxy.Hostname(), proxy.Port()
+		if port == "" {
+			port = "80"
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	if net.ParseIP(host) == nil {
+		addrs, err := c.resolve(ctx, host)
+		if err != nil {
+			fmt.Printf("  ❌ DNS: %v\n", err)
+			return &stageError{"DNS", err}
+		}
+		fmt.Printf("  ✅ DNS: %s resolves to %v\n", host, addrs)
+	}
+
+	addr := net.JoinHostPort(host, port)
+	start := time.Now()
+	conn, err := c.dial(ctx, "tcp", addr)
+	if err != nil {
+		fmt.Printf("  ❌ TCP: %v\n", err)
+		return &stageError{"TCP", err}
+	}
+	fmt.Printf("  ✅ TCP: connected to %s in %s\n", addr, time.Since(start).Round(time.Millisecond))
+
+	if target.Scheme == "https" && proxy == nil {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: target.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			tlsConn.Close()
+			fmt.Printf("  ❌ TLS: %v\n", err)
+			return &stageError{"TLS", err}
+		}
+		state := tlsConn.ConnectionState()
+		cert := state.PeerCertificates[0]
+		fmt.Printf("  ✅ TLS: %s, certificate for %s valid until %s\n", tls.VersionName(state.Version), cert.Subject.CommonName, cert.NotAfter.Format("2006-01-02"))
+		tlsConn.Close()
+	} else {
+		conn.Close()
+	}
+
+	repos, err := c.registry.GetRepositories()
+	if err != nil {
+		fmt.Printf("  ❌ HTTP: %v\n", err)
+		return &stageError{"HTTP", err}
+	}
+	fmt.Printf("  ✅ HTTP: authenticated, %d repositories visible\n", len(repos))
+	return nil
+}
+
+// validateNetwork checks that the configured registry, or every instance,
+// is reachable with the configured credentials, without processing any
+// repository.
+func validateNetwork(configPath string) error {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return &configError{fmt.Errorf("failed to load config: %w", err)}
+	}
+
+	if len(cfg.Instances) == 0 {
+		if err := newNetworkCheck(cfg).run(cfg.Nexus.URL); err != nil {
+			return err
+		}
+		fmt.Println("✅ Registry is reachable")
+		return nil
+	}
+
+	var errs []error
+	for i, inst := range cfg.Instances {
+		instCfg := cfg.ForInstance(i)
+		fmt.Printf("🖥️  Instance %s\n", inst.Name)
+		if err := newNetworkCheck(instCfg).run(instCfg.Nexus.URL); err != nil {
+			errs = append(errs, fmt.Errorf("instance %s: %w", inst.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	fmt.Println("✅ All instances are reachable")
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// testNetworkCheck returns a check whose stages all succeed.
+func testNetworkCheck() *networkCheck {
+	return &networkCheck{
+		timeout: 5 * time.Second,
+		resolve: func(ctx context.Context, host string) ([]string, error) {
+			return []string{"192.0.2.1"}, nil
+		},
+		dial: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			client, server := net.Pipe()
+			server.Close()
+			return client, nil
+		},
+		proxy: func(req *http.Request) (*url.URL, error) { return nil, nil },
+		registry: &nexus.MockClient{
+			GetRepositoriesFunc: func() ([]nexus.Repository, error) { return nil, nil },
+		},
+	}
+}
+
+func TestNetworkCheckStages(t *testing.T) {
+	tests := []struct {
+		name  string
+		url   string
+		setup func(c *networkCheck)
+		stage string
+	}{
+		{name: "reachable", url: "http://nexus.test"},
+		{
+			name: "DNS",
+			url:  "http://nexus.test",
+			setup: func(c *networkCheck) {
+				c.resolve = func(ctx context.Context, host string) ([]string, error) {
+					return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+				}
+			},
+			stage: "DNS",
+		},
+		{
+			name: "TCP",
+			url:  "http://nexus.test",
+			setup: func(c *networkCheck) {
+				c.dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+					return nil, errors.New("connection refused")
+				}
+			},
+			stage: "TCP",
+		},
+		{
+			// The pipe closes before a TLS handshake
+			name:  "TLS",
+			url:   "https://nexus.test",
+			stage: "TLS",
+		},
+		{
+			name: "HTTP",
+			url:  "http://nexus.test",
+			setup: func(c *networkCheck) {
+				c.registry = &nexus.MockClient{
+					GetRepositoriesFunc: func() ([]nexus.Repository, error) {
+						return nil, &nexus.APIError{StatusCode: 401}
+					},
+				}
+			},
+			stage: "HTTP",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testNetworkCheck()
+			if tt.setup != nil {
+				tt.setup(c)
+			}
+
+			err := c.run(tt.url)
+			var stage *stageError
+			switch {
+			case tt.stage == "" && err != nil:
+				t.Errorf("run failed: %v", err)
+			case tt.stage != "" && (!errors.As(err, &stage) || stage.stage != tt.stage):
+				t.Errorf("run returned %v, want a %s stage error", err, tt.stage)
+			case tt.stage != "" && exitCode(err) != exitConnection:
+				t.Errorf("exit code %d, want %d", exitCode(err), exitConnection)
+			}
+		})
+	}
+}
+
+func TestNetworkCheckProxy(t *testing.T) {
+	c := testNetworkCheck()
+	c.proxy = func(req *http.Request) (*url.URL, error) { return url.Parse("http://proxy.test:3128") }
+	var resolved, dialed string
+	c.resolve = func(ctx context.Context, host string) ([]string, error) {
+		resolved = host
+		return []string{"192.0.2.2"}, nil
+	}
+	dial := c.dial
+	c.dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = addr
+		return dial(ctx, network, addr)
+	}
+
+	// Through a proxy, TLS is negotiated with the proxy's help and not checked
+	if err := c.run("https://nexus.test"); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if resolved != "proxy.test" || dialed != "proxy.test:3128" {
+		t.Errorf("resolved %q and dialed %q, want the proxy", resolved, dialed)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"nexus-retention-policy/internal/retention"
+)
+
+// planCommand implements the plan subcommand, writing planned deletions to a
+// file for review.
+func planCommand(args []string) error {
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "Path or http(s) URL of configuration file")
+	output := fs.String("o", "plan.json", "Path of the plan file to write")
+	checkPermissions := fs.Bool("check-permissions", false, "Check that the account may delete in each repository")
+	stable := fs.Bool("stable", false, "Write a diff-friendly plan without execution ID and timestamps, for review in version control")
+	verbosity := verbosityFlags(fs)
+	fs.Parse(args)
+
+	engine, _, closeLog, err := setup(*configPath, retention.Options{DryRun: true, Verbosity: verbosity(), CheckPermissions: *checkPermissions})
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+
+	plan, _, err := engine.Plan()
+	if plan == nil {
+		return err
+	}
+
+	save := plan.Save
+	if *stable {
+		save = plan.SaveStable
+	}
+	if err := save(*output); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n📝 Plan with %d deletions written to %s\n", len(plan.Deletions), *output)
+	return err
+}
+
+// applyCommand implements the apply subcommand, deleting exactly the
+// components listed in a plan file.
+func applyCommand(args []string) error {
+	fs := flag.NewFlagSet("apply", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "Path or http(s) URL of configuration file")
+	force := fs.Bool("force", false, "Execute deletions even outside the configured allowed hours")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: apply [-config config.yaml] [-force] plan.json")
+	}
+
+	plan, err := retention.LoadPlan(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	engine, _, closeLog, err := setup(*configPath, retention.Options{Force: *force})
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+
+	return engine.Apply(plan)
+}
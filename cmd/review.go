@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"nexus-retention-policy/internal/retention"
+)
+
+const reviewHelp = `Commands:
+  l              list planned deletions
+  t <n>...       toggle deletions by number, ranges like 3-7 are allowed
+  i <n>...       toggle all deletions of images by number
+  a / n          select all / none
+  y              delete the selected components
+  q              quit without deleting`
+
+// reviewCommand implements the review subcommand, planning deletions and
+// letting the operator approve them interactively before they are applied.
+func reviewCommand(args []string) error {
+	fs := flag.NewFlagSet("review", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "Path or http(s) URL of configuration file")
+	force := fs.Bool("force", false, "Execute deletions even outside the configured allowed hours")
+	verbosity := verbosityFlags(fs)
+	fs.Parse(args)
+
+	// Plans are always built in dry-run mode, so the engine only deletes
+	// when applying the reviewed selection
+	engine, _, closeLog, err := setup(*configPath, retention.Options{Force: *force, Verbosity: verbosity()})
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+
+	plan, _, err := engine.Plan()
+	if plan == nil {
+		return err
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Plan is incomplete: %v\n", err)
+	}
+	if len(plan.Deletions) == 0 {
+		fmt.Println("\n✅ Nothing to delete")
+		return err
+	}
+
+	sel := retention.NewSelection(plan)
+	if !review(os.Stdin, os.Stdout, sel) {
+		fmt.Println("👋 No components deleted")
+		return err
+	}
+	if sel.Count() == 0 {
+		fmt.Println("No deletions selected")
+		return err
+	}
+
+	fmt.Println()
+	return engine.Apply(sel.Plan())
+}
+
+// review runs the interactive review loop and reports whether the operator
+// approved the selection.
+func review(in io.Reader, out io.Writer, sel *retention.Selection) bool {
+	printSelection(out, sel)
+	fmt.Fprintln(out, reviewHelp)
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(out, "\n%d of %d selected> ", sel.Count(), sel.Len())
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return false
+		}
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "l":
+			printSelection(out, sel)
+		case "t", "i":
+			toggle, entries := sel.Toggle, sel.Len()
+			if fields[0] == "i" {
+				toggle, entries = sel.ToggleImage, len(sel.Images())
+			}
+			for _, arg := range fields[1:] {
+				from, to, err := parseRange(arg)
+				if err != nil {
+					fmt.Fprintf(out, "Invalid number: %s\n", arg)
+					continue
+				}
+				// Clamp ranges, so a typo like 1-1000000 doesn't loop
+				// over entries that don't exist
+				if from < 1 || to > entries {
+					fmt.Fprintf(out, "No entries outside 1-%d\n", entries)
+				}
+				for n := max(from, 1); n <= min(to, entries); n++ {
+					if !toggle(n - 1) {
+						fmt.Fprintf(out, "No entry %d\n", n)
+					}
+				}
+			}
+		case "a":
+			sel.SetAll(true)
+		case "n":
+			sel.SetAll(false)
+		case "y":
+			return true
+		case "q":
+			return false
+		default:
+			fmt.Fprintln(out, reviewHelp)
+		}
+	}
+}
+
+// printSelection lists the planned deletions grouped by image. Images and
+// deletions are numbered from 1.
+func printSelection(out io.Writer, sel *retention.Selection) {
+	for n, image := range sel.Images() {
+		fmt.Fprintf(out, "\n📦 [%d] %s/%s\n", n+1, image.Repository, image.ImageName)
+		for _, i := range image.Indexes {
+			mark := " "
+			if sel.Selected(i) {
+				mark = "x"
+			}
+			d := sel.Deletion(i)
+			fmt.Fprintf(out, "  [%s] %4d  %s (rule: %s)\n", mark, i+1, displayTag(d.Tag), d.Rule)
+		}
+	}
+}
+
+// parseRange parses "n" or "n-m" into an inclusive range.
+func parseRange(arg string) (from, to int, err error) {
+	first, last, isRange := strings.Cut(arg, "-")
+	if from, err = strconv.Atoi(first); err != nil {
+		return 0, 0, err
+	}
+	if !isRange {
+		return from, from, nil
+	}
+	if to, err = strconv.Atoi(last); err != nil {
+		return 0, 0, err
+	}
+	return from, to, nil
+}
+
+// displayTag returns a planned tag for output.
+func displayTag(tag string) string {
+	if tag == "" {
+		return "<untagged>"
+	}
+	return tag
+}
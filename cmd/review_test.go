@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"nexus-retention-policy/internal/retention"
+)
+
+// testSelection returns a selection of three deletions of app and two of
+// api.
+func testSelection() *retention.Selection {
+	plan := &retention.Plan{}
+	for _, d := range []struct{ image, tag string }{
+		{"app", "v3"}, {"app", "v2"}, {"app", "v1"}, {"api", "v2"}, {"api", "v1"},
+	} {
+		plan.Deletions = append(plan.Deletions, retention.PlannedDeletion{
+			Repository:  "docker-hosted",
+			ImageName:   d.image,
+			Tag:         d.tag,
+			ComponentID: d.image + ":" + d.tag,
+			Rule:        "all",
+		})
+	}
+	return retention.NewSelection(plan)
+}
+
+func TestReviewToggle(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		selected []bool
+		output   string
+	}{
+		{
+			name:     "single",
+			input:    "t 2",
+			selected: []bool{true, false, true, true, true},
+		},
+		{
+			name:     "range",
+			input:    "t 2-4",
+			selected: []bool{true, false, false, false, true},
+		},
+		{
+			name:     "range past the end is clamped",
+			input:    "t 4-1000000000",
+			selected: []bool{true, true, true, false, false},
+			output:   "No entries outside 1-5",
+		},
+		{
+			name:     "range before the start is clamped",
+			input:    "t 0-1",
+			selected: []bool{false, true, true, true, true},
+			output:   "No entries outside 1-5",
+		},
+		{
+			name:     "out of range",
+			input:    "t 9",
+			selected: []bool{true, true, true, true, true},
+			output:   "No entries outside 1-5",
+		},
+		{
+			name:     "image range is clamped to images",
+			input:    "i 2-3",
+			selected: []bool{true, true, true, false, false},
+			output:   "No entries outside 1-2",
+		},
+		{
+			name:     "invalid",
+			input:    "t x",
+			selected: []bool{true, true, true, true, true},
+			output:   "Invalid number: x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel := testSelection()
+			var out bytes.Buffer
+			if !review(strings.NewReader(tt.input+"\ny\n"), &out, sel) {
+				t.Fatalf("review not approved")
+			}
+
+			for i, want := range tt.selected {
+				if sel.Selected(i) != want {
+					t.Errorf("deletion %d selected = %t, want %t", i+1, sel.Selected(i), want)
+				}
+			}
+			if tt.output != "" && !strings.Contains(out.String(), tt.output) {
+				t.Errorf("output doesn't contain %q:\n%s", tt.output, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"nexus-retention-policy/internal/config"
+	"nexus-retention-policy/internal/retention"
+
+	"github.com/robfig/cron/v3"
+)
+
+// scheduler runs the policy engines on the configured cron schedule and can
+// swap in a reloaded configuration between runs.
+type scheduler struct {
+	configPath string
+	opts       retention.Options
+	cron       *cron.Cron
+
+	// mu is held during runs, so a reload waits for the current run
+	mu     sync.Mutex
+	runner *runner
+	cfg    *config.Config
+	entry  cron.EntryID
+}
+
+func newScheduler(configPath string, opts retention.Options, runner *runner, cfg *config.Config) *scheduler {
+	s := &scheduler{
+		configPath: configPath,
+		opts:       opts,
+		cron:       cron.New(),
+		runner:     runner,
+		cfg:        cfg,
+	}
+
+	s.entry = s.cron.Schedule(cfg.CronSchedule(), cron.FuncJob(s.execute))
+	return s
+}
+
+// execute runs a scheduled execution with the current configuration.
+func (s *scheduler) execute() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if delay := jitterDelay(s.cfg.ScheduleJitter); delay > 0 {
+		fmt.Printf("\n⏳ Delaying scheduled execution by %s (jitter)\n", delay)
+		time.Sleep(delay)
+	}
+	fmt.Printf("\n⏰ Scheduled execution started at %s\n", formatTime())
+	if err := s.runner.execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "Execution error: %v\n", err)
+	}
+	fmt.Printf("⏰ Scheduled execution completed at %s\n", formatTime())
+}
+
+// reload loads and validates the configuration file again and swaps it in,
+// rescheduling if the schedule changed. On error the current configuration
+// is kept.
+func (s *scheduler) reload() error {
+	cfg, err := loadConfig(s.configPath, s.opts)
+	if err != nil {
+		return err
+	}
+	if cfg.Schedule == "" {
+		return fmt.Errorf("schedule must not be removed while running scheduled")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The deletion logs are reopened only after a running execution wrote its
+	// last record and closed them, so a hash chain continues from that record
+	s.runner.close()
+	next, err := newRunner(cfg, s.opts)
+	if err != nil {
+		current, reopenErr := newRunner(s.cfg, s.opts)
+		if reopenErr != nil {
+			// Without deletion logs nothing may be deleted, so scheduled
+			// executions run no instances until a reload succeeds
+			s.runner = &runner{}
+			return errors.Join(err, fmt.Errorf("failed to reopen the current configuration: %w", reopenErr))
+		}
+		s.runner = current
+		return err
+	}
+
+	if cfg.Schedule != s.cfg.Schedule || cfg.ScheduleWithSeconds != s.cfg.ScheduleWithSeconds {
+		s.cron.Remove(s.entry)
+		s.entry = s.cron.Schedule(cfg.CronSchedule(), cron.FuncJob(s.execute))
+		fmt.Printf("Rescheduled: %s\n", cfg.Schedule)
+	}
+
+	s.runner, s.cfg = next, cfg
+	return nil
+}
+
+// stop stops the cron scheduler, waits for a running execution and closes
+// the deletion logs.
+func (s *scheduler) stop() {
+	<-s.cron.Stop().Done()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runner.close()
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"nexus-retention-policy/internal/logger"
+	"nexus-retention-policy/internal/nexus"
+	"nexus-retention-policy/internal/retention"
+)
+
+// nexusServer serves the repositories and components API of a Nexus with a
+// single hosted Docker repository. The first deletion waits for release
+// after signalling deleting.
+type nexusServer struct {
+	mu         sync.Mutex
+	components map[string]nexus.Component
+	deleting   chan struct{}
+	release    chan struct{}
+	blocked    bool
+}
+
+func newNexusServer(t *testing.T) (*nexusServer, *httptest.Server) {
+	n := &nexusServer{
+		components: make(map[string]nexus.Component),
+		deleting:   make(chan struct{}),
+		release:    make(chan struct{}),
+	}
+	server := httptest.NewServer(n)
+	t.Cleanup(server.Close)
+	return n, server
+}
+
+// push adds a tag of app, pushed at the given time.
+func (n *nexusServer) push(tag string, pushed time.Time) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	id := "app-" + tag
+	n.components[id] = nexus.Component{
+		ID: id, Repository: "docker-hosted", Format: "docker", Name: "app", Version: tag,
+		Assets: []nexus.Asset{{ID: id + "-manifest", Path: "v2/app/manifests/" + tag, LastModified: pushed, FileSize: 100}},
+	}
+}
+
+func (n *nexusServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/service/rest/v1/repositories":
+		json.NewEncoder(w).Encode([]nexus.Repository{{Name: "docker-hosted", Format: "docker", Type: "hosted"}})
+	case r.URL.Path == "/service/rest/v1/components" && r.Method == http.MethodGet:
+		n.mu.Lock()
+		page := nexus.ComponentPage{}
+		for _, comp := range n.components {
+			page.Items = append(page.Items, comp)
+		}
+		n.mu.Unlock()
+		sort.Slice(page.Items, func(i, j int) bool { return page.Items[i].ID < page.Items[j].ID })
+		json.NewEncoder(w).Encode(page)
+	case strings.HasPrefix(r.URL.Path, "/service/rest/v1/components/") && r.Method == http.MethodDelete:
+		n.mu.Lock()
+		block := !n.blocked
+		n.blocked = true
+		n.mu.Unlock()
+		if block {
+			close(n.deleting)
+			<-n.release
+		}
+		n.mu.Lock()
+		delete(n.components, strings.TrimPrefix(r.URL.Path, "/service/rest/v1/components/"))
+		n.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestReloadDuringExecutionContinuesHashChain(t *testing.T) {
+	n, server := newNexusServer(t)
+	now := time.Now().Add(-time.Hour)
+	for i, tag := range []string{"v3", "v2", "v1"} {
+		n.push(tag, now.Add(-time.Duration(i)*time.Minute))
+	}
+
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "deletion_log.csv")
+	configPath := filepath.Join(dir, "config.yaml")
+	config := fmt.Sprintf(`
+nexus:
+  url: %q
+  username: admin
+  password: secret
+schedule: "0 2 * * *"
+log_file: %q
+log_hash_chain: true
+rules: [{name: all, regex: ".*", keep: 1}]
+`, server.URL, logFile)
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := retention.Options{}
+	runner, cfg, err := setupRunner(configPath, opts)
+	if err != nil {
+		t.Fatalf("setupRunner: %v", err)
+	}
+	s := newScheduler(configPath, opts, runner, cfg)
+
+	executed := make(chan struct{})
+	go func() {
+		s.execute()
+		close(executed)
+	}()
+
+	// Reload while the execution deletes, before it logged its deletions
+	<-n.deleting
+	reloaded := make(chan error)
+	go func() { reloaded <- s.reload() }()
+	time.Sleep(50 * time.Millisecond)
+	close(n.release)
+	<-executed
+	if err := <-reloaded; err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+
+	// The reloaded engine logs the next deletion
+	n.push("v4", now.Add(time.Minute))
+	s.execute()
+	s.stop()
+
+	rows, err := logger.VerifyLog(logFile)
+	if err != nil {
+		t.Fatalf("hash chain broken after reload: %v", err)
+	}
+	if rows != 3 {
+		t.Errorf("verified %d rows, want 3", rows)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+
+	"nexus-retention-policy/internal/retention"
+)
+
+// serveStatus serves the progress of runs as JSON on /status in the
+// background.
+func serveStatus(addr string, tracker *retention.StatusTracker) {
+	mux := http.NewServeMux()
+	mux.Handle("/status", statusHandler(tracker))
+
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			fmt.Fprintf(os.Stderr, "Status endpoint failed: %v\n", err)
+		}
+	}()
+	fmt.Printf("📊 Serving run status on http://%s/status\n", addr)
+}
+
+// statusHandler returns the current status of the tracker as JSON.
+func statusHandler(tracker *retention.StatusTracker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tracker.Status())
+	})
+}
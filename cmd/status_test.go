@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nexus-retention-policy/internal/retention"
+)
+
+func TestStatusHandler(t *testing.T) {
+	server := httptest.NewServer(statusHandler(retention.NewStatusTracker()))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var status retention.RunStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Fatalf("decode status: %v", err)
+	}
+	if resp.Header.Get("Content-Type") != "application/json" || status.Running {
+		t.Errorf("status %+v with content type %q", status, resp.Header.Get("Content-Type"))
+	}
+
+	resp, err = http.Post(server.URL, "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST answered %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,9 @@
+package main
+
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
+// userAgent identifies the tool's requests in registry access logs.
+func userAgent() string {
+	return "nexus-retention-policy/" + version
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"nexus-retention-policy/internal/config"
+)
+
+// validateCommand implements the validate subcommand, checking a
+// configuration without connecting to the registry. Lint findings are
+// reported as warnings and fail validation only with -strict.
+func validateCommand(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "Path or http(s) URL of configuration file")
+	strict := fs.Bool("strict", false, "Fail on warnings and lint findings")
+	fs.Parse(args)
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return &configError{fmt.Errorf("failed to load config: %w", err)}
+	}
+
+	warnings := 0
+	for _, warning := range cfg.Warnings() {
+		fmt.Printf("⚠️  %s\n", warning)
+		warnings++
+	}
+	for _, finding := range cfg.Lint() {
+		fmt.Printf("⚠️  %s\n", finding)
+		warnings++
+	}
+
+	if *strict && warnings > 0 {
+		return &configError{fmt.Errorf("configuration has %d warnings", warnings)}
+	}
+
+	fmt.Printf("✅ Configuration is valid (%d rules)\n", len(cfg.Rules))
+	return nil
+}
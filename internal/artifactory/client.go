@@ -0,0 +1,248 @@
+package artifactory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// Client lists and deletes Docker images in Artifactory, mapping them to the
+// Nexus repository and component types used by the policy engine.
+//
+// Each tag folder containing a manifest is a component. Its ID is the
+// repository and path of the folder, e.g. "docker-local/myapp/1.0".
+type Client struct {
+	baseURL    string
+	username   string
+	password   string
+	httpClient *http.Client
+	// maxComponents limits the manifests returned per repository
+	maxComponents int
+	// truncated holds the repositories whose last listing reached
+	// maxComponents
+	truncated map[string]bool
+
+	// repoCredentials overrides the credentials for individual repositories
+	repoCredentials map[string]credentials
+	mu              sync.Mutex
+}
+
+type credentials struct {
+	username string
+	password string
+}
+
+type repository struct {
+	Key         string `json:"key"`
+	Type        string `json:"type"`
+	PackageType string `json:"packageType"`
+}
+
+type item struct {
+	Repo     string    `json:"repo"`
+	Path     string    `json:"path"`
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	SHA256   string    `json:"sha256"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+	Updated  time.Time `json:"updated"`
+}
+
+type aqlResult struct {
+	Results []item `json:"results"`
+}
+
+// NewClient creates an Artifactory client. The base URL includes the
+// Artifactory context path, e.g. "https://example.com/artifactory".
+func NewClient(baseURL, username, password string, timeout int) *Client {
+	return NewClientWithHTTP(baseURL, username, password, &http.Client{
+		Timeout: time.Duration(timeout) * time.Second,
+	})
+}
+
+// NewClientWithHTTP creates a client using the given HTTP client, e.g. one
+// pointed at an httptest server or with a custom transport.
+func NewClientWithHTTP(baseURL, username, password string, httpClient *http.Client) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		username:   username,
+		password:   password,
+		httpClient: httpClient,
+	}
+}
+
+// SetMaxComponents limits GetComponents to the first max components of a
+// repository (0 = no limit).
+func (c *Client) SetMaxComponents(max int) {
+	c.maxComponents = max
+}
+
+// SetRepositoryCredentials uses the given credentials instead of the
+// client's for requests that access the repository.
+func (c *Client) SetRepositoryCredentials(repository, username, password string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.repoCredentials == nil {
+		c.repoCredentials = make(map[string]credentials)
+	}
+	c.repoCredentials[repository] = credentials{username: username, password: password}
+}
+
+func (c *Client) credentialsFor(repository string) (string, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if creds, ok := c.repoCredentials[repository]; ok {
+		return creds.username, creds.password
+	}
+	return c.username, c.password
+}
+
+func (c *Client) doRequest(method, path, repository string, body io.Reader) ([]byte, error) {
+	url := fmt.Sprintf("%s%s", c.baseURL, path)
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.SetBasicAuth(c.credentialsFor(repository))
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "text/plain")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &nexus.APIError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	return respBody, nil
+}
+
+// GetRepositories returns the Docker repositories. Local repositories are
+// reported as hosted, so only they are cleaned.
+func (c *Client) GetRepositories() ([]nexus.Repository, error) {
+	body, err := c.doRequest("GET", "/api/repositories", "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []repository
+	if err := json.Unmarshal(body, &repos); err != nil {
+		return nil, fmt.Errorf("failed to parse repositories: %w", err)
+	}
+
+	result := make([]nexus.Repository, 0, len(repos))
+	for _, repo := range repos {
+		repoType := strings.ToLower(repo.Type)
+		if repoType == "local" {
+			repoType = "hosted"
+		}
+		result = append(result, nexus.Repository{
+			Name:   repo.Key,
+			Format: strings.ToLower(repo.PackageType),
+			Type:   repoType,
+		})
+	}
+
+	return result, nil
+}
+
+// GetComponents finds the tag manifests of a repository with AQL.
+func (c *Client) GetComponents(repository string) ([]nexus.Component, error) {
+	repoJSON, _ := json.Marshal(repository)
+	query := fmt.Sprintf(`items.find({"repo":%s,"$or":[{"name":"manifest.json"},{"name":"list.manifest.json"}]})`+
+		`.include("repo","path","name","size","sha256","created","modified","updated")`, repoJSON)
+	if c.maxComponents > 0 {
+		query += fmt.Sprintf(".limit(%d)", c.maxComponents)
+	}
+
+	body, err := c.doRequest("POST", "/api/search/aql", repository, strings.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+
+	var result aqlResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse components: %w", err)
+	}
+	// The limit applies to the manifests found, before digest folders are
+	// left out, so fewer components than the limit may still be partial
+	c.setTruncated(repository, c.maxComponents > 0 && len(result.Results) >= c.maxComponents)
+
+	var components []nexus.Component
+	for _, it := range result.Results {
+		image, tag := path.Split(it.Path)
+		image = strings.TrimSuffix(image, "/")
+		// Platform manifests of multi-arch images live in digest folders
+		if image == "" || strings.HasPrefix(tag, "sha256:") {
+			continue
+		}
+
+		components = append(components, nexus.Component{
+			ID:           it.Repo + "/" + it.Path,
+			Repository:   it.Repo,
+			Format:       "docker",
+			Name:         image,
+			Version:      tag,
+			LastModified: it.Modified,
+			Assets: []nexus.Asset{{
+				Path:         it.Path + "/" + it.Name,
+				Repository:   it.Repo,
+				Format:       "docker",
+				LastModified: it.Modified,
+				FileSize:     it.Size,
+				Checksum:     map[string]string{"sha256": it.SHA256},
+				BlobCreated:  it.Created,
+				BlobUpdated:  it.Updated,
+			}},
+		})
+	}
+
+	return components, nil
+}
+
+func (c *Client) setTruncated(repository string, truncated bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.truncated == nil {
+		c.truncated = make(map[string]bool)
+	}
+	c.truncated[repository] = truncated
+}
+
+// Truncated reports whether the last listing of the repository reached the
+// limit set with SetMaxComponents.
+func (c *Client) Truncated(repository string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.truncated[repository]
+}
+
+// DeleteComponent deletes a tag folder with all of its files.
+func (c *Client) DeleteComponent(componentID string) error {
+	repository, _, _ := strings.Cut(componentID, "/")
+	_, err := c.doRequest("DELETE", "/"+componentID, repository, nil)
+	return err
+}
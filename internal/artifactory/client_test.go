@@ -0,0 +1,152 @@
+package artifactory
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// aqlServer answers AQL searches with the manifest paths, honouring the
+// query's limit.
+func aqlServer(t *testing.T, paths ...string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/search/aql" {
+			http.NotFound(w, r)
+			return
+		}
+		query, _ := io.ReadAll(r.Body)
+		limit := len(paths)
+		if _, after, ok := strings.Cut(string(query), ".limit("); ok {
+			fmt.Sscanf(after, "%d)", &limit)
+		}
+
+		var results []string
+		for _, p := range paths[:min(limit, len(paths))] {
+			results = append(results, fmt.Sprintf(`{"repo":"docker-local","path":%q,"name":"manifest.json","size":100}`, p))
+		}
+		fmt.Fprintf(w, `{"results":[%s]}`, strings.Join(results, ","))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetComponentsTruncated(t *testing.T) {
+	paths := []string{"app/1.0", "app/sha256:abc", "app/sha256:def", "app/2.0", "app/3.0"}
+
+	tests := []struct {
+		name       string
+		max        int
+		components int
+		truncated  bool
+	}{
+		{name: "no limit", components: 3},
+		{name: "limit above manifests", max: 10, components: 3},
+		// Digest folders use up the limit although they aren't components
+		{name: "limit reached by digest folders", max: 4, components: 2, truncated: true},
+		{name: "limit reached", max: 5, components: 3, truncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClientWithHTTP(aqlServer(t, paths...).URL, "admin", "secret", http.DefaultClient)
+			c.SetMaxComponents(tt.max)
+
+			components, err := c.GetComponents("docker-local")
+			if err != nil {
+				t.Fatalf("GetComponents: %v", err)
+			}
+			if len(components) != tt.components {
+				t.Errorf("listed %d components, want %d", len(components), tt.components)
+			}
+			if got := c.Truncated("docker-local"); got != tt.truncated {
+				t.Errorf("Truncated() = %t, want %t", got, tt.truncated)
+			}
+		})
+	}
+}
+
+func TestGetRepositories(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"key": "docker-local", "type": "LOCAL", "packageType": "Docker"},
+			{"key": "docker-remote", "type": "REMOTE", "packageType": "Docker"},
+			{"key": "libs-release", "type": "LOCAL", "packageType": "Maven"}
+		]`))
+	}))
+	defer server.Close()
+
+	repos, err := NewClientWithHTTP(server.URL, "admin", "secret", http.DefaultClient).GetRepositories()
+	if err != nil {
+		t.Fatalf("GetRepositories: %v", err)
+	}
+
+	want := []nexus.Repository{
+		{Name: "docker-local", Format: "docker", Type: "hosted"},
+		{Name: "docker-remote", Format: "docker", Type: "remote"},
+		{Name: "libs-release", Format: "maven", Type: "hosted"},
+	}
+	if !reflect.DeepEqual(repos, want) {
+		t.Errorf("repositories %+v, want %+v", repos, want)
+	}
+	if !repos[0].IsDockerHosted() || repos[1].IsDockerHosted() {
+		t.Error("only local Docker repositories are cleanable")
+	}
+}
+
+func TestGetComponents(t *testing.T) {
+	server := aqlServer(t, "team/app/1.0", "team/app/sha256:abc", "app/2.0")
+	components, err := NewClientWithHTTP(server.URL, "admin", "secret", http.DefaultClient).GetComponents("docker-local")
+	if err != nil {
+		t.Fatalf("GetComponents: %v", err)
+	}
+
+	if len(components) != 2 {
+		t.Fatalf("listed %d components, want 2 without the digest folder", len(components))
+	}
+	comp := components[0]
+	if comp.ID != "docker-local/team/app/1.0" || comp.Name != "team/app" || comp.Version != "1.0" || comp.Repository != "docker-local" {
+		t.Errorf("unexpected component %+v", comp)
+	}
+	if len(comp.Assets) != 1 || comp.Assets[0].Path != "team/app/1.0/manifest.json" || comp.Assets[0].FileSize != 100 {
+		t.Errorf("unexpected assets %+v", comp.Assets)
+	}
+}
+
+func TestDeleteComponent(t *testing.T) {
+	var method, path, user string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		user, _, _ = r.BasicAuth()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	c := NewClientWithHTTP(server.URL, "admin", "secret", http.DefaultClient)
+	c.SetRepositoryCredentials("docker-local", "deployer", "pass")
+	if err := c.DeleteComponent("docker-local/team/app/1.0"); err != nil {
+		t.Fatalf("DeleteComponent: %v", err)
+	}
+	if method != "DELETE" || path != "/docker-local/team/app/1.0" || user != "deployer" {
+		t.Errorf("sent %s %s as %s, want DELETE /docker-local/team/app/1.0 as deployer", method, path, user)
+	}
+}
+
+func TestAPIErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	err := NewClientWithHTTP(server.URL, "admin", "secret", http.DefaultClient).DeleteComponent("docker-local/app/1.0")
+	var apiErr *nexus.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("DeleteComponent error %v, want a 404 API error", err)
+	}
+}
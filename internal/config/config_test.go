@@ -0,0 +1,487 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testNexus is the registry section of test configurations.
+const testNexus = `
+nexus:
+  url: "http://nexus.test"
+  username: "admin"
+  password: "secret"
+`
+
+func TestValidateKeepCounts(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+		// err is a substring of the expected error, empty if valid
+		err string
+	}{
+		{name: "keep", rule: `keep: 1`},
+		{name: "keep 0", rule: `keep: 0`, err: "keep must be at least 1"},
+		{name: "keep 0 allowed", rule: `keep: 0, allow_delete_all: true`},
+		{name: "negative keep", rule: `keep: -1`, err: "must not be negative"},
+		{name: "maven", rule: `keep: 1, keep_snapshots: 2, keep_releases: 5`},
+		{name: "keep_snapshots 0", rule: `keep: 1, keep_snapshots: 0`, err: "keep_snapshots and keep_releases must be at least 1"},
+		{name: "keep_releases 0", rule: `keep: 1, keep_releases: 0`, err: "keep_snapshots and keep_releases must be at least 1"},
+		{name: "maven 0 allowed", rule: `keep: 1, keep_snapshots: 0, keep_releases: 0, allow_delete_all: true`},
+		{name: "negative keep_snapshots", rule: `keep: 1, keep_snapshots: -1`, err: "must not be negative"},
+		{name: "group_keep", rule: `keep: 1, group_by_regex: "-(prod|dev)$", group_keep: {prod: 3}`},
+		{name: "group_keep 0", rule: `keep: 1, group_by_regex: "^(\\w+)-", group_keep: {prod: 0}`, err: "group_keep 'prod' must be at least 1"},
+		{name: "group_keep 0 allowed", rule: `keep: 1, group_by_regex: "^(\\w+)-", group_keep: {dev: 0}, allow_delete_all: true`},
+		{name: "negative group_keep", rule: `keep: 1, group_by_regex: "^(\\w+)-", group_keep: {prod: -1}`, err: "group_keep 'prod' must not be negative"},
+		{name: "group_keep without groups", rule: `keep: 1, group_keep: {prod: 3}`, err: "group_keep requires group_by_regex"},
+		{name: "group_keep by downloads", rule: `keep: 1, group_by_regex: "^(\\w+)-", group_keep: {prod: 3}, keep_by_downloads: {min_keep: 1, max_keep: 4, max_downloads: 100}`, err: "can't be combined with keep_by_downloads"},
+		{name: "group_keep maven", rule: `keep: 1, group_by_regex: "^(\\w+)-", group_keep: {prod: 3}, keep_releases: 2`, err: "can't be combined with keep_snapshots"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(testNexus + `rules: [{name: r, regex: ".*", ` + tt.rule + `}]`))
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.err != "" && err == nil:
+				t.Errorf("accepted, want error %q", tt.err)
+			case tt.err != "" && !strings.Contains(err.Error(), tt.err):
+				t.Errorf("error %q, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestValidateBackupManifest(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		err    bool
+	}{
+		{name: "backup manifest", config: `backup_manifest: backup.jsonl`},
+		{name: "redacted names", config: `redact_image_names: true`},
+		{name: "both", config: "backup_manifest: backup.jsonl\nredact_image_names: true", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(testNexus + "rules: [{name: r, regex: \".*\", keep: 1}]\n" + tt.config))
+			if (err != nil) != tt.err {
+				t.Errorf("Parse() error = %v, want error %t", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestValidateDeleteOrder(t *testing.T) {
+	if cfg := mustParse(t, ""); cfg.DeleteOrder != DeleteOrderNewestFirst {
+		t.Errorf("default delete_order = %q, want %q", cfg.DeleteOrder, DeleteOrderNewestFirst)
+	}
+	_, err := Parse([]byte(testNexus + "rules: [{name: r, regex: \".*\", keep: 1}]\ndelete_order: random"))
+	if err == nil {
+		t.Error("accepted an unknown delete_order")
+	}
+}
+
+func TestFloatingTag(t *testing.T) {
+	cfg := mustParse(t, "floating_tag_mode: protect")
+	if !cfg.FloatingTag("latest", FloatingTagProtect) || cfg.FloatingTag("stable", FloatingTagProtect) {
+		t.Errorf("floating tags = %v, want only latest by default", cfg.FloatingTags)
+	}
+	if cfg.FloatingTag("latest", FloatingTagIgnore) {
+		t.Error("latest handled by a mode other than the configured one")
+	}
+	if cfg := mustParse(t, ""); cfg.FloatingTagMode != FloatingTagNormal {
+		t.Errorf("default floating_tag_mode = %q, want %q", cfg.FloatingTagMode, FloatingTagNormal)
+	}
+	_, err := Parse([]byte(testNexus + "rules: [{name: r, regex: \".*\", keep: 1}]\nfloating_tag_mode: skip"))
+	if err == nil {
+		t.Error("accepted an unknown floating_tag_mode")
+	}
+}
+
+// mustParse parses a configuration with a catch-all rule and the given
+// settings.
+func mustParse(t *testing.T, settings string) *Config {
+	t.Helper()
+	cfg, err := Parse([]byte(testNexus + "rules: [{name: r, regex: \".*\", keep: 1}]\n" + settings))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	return cfg
+}
+
+func TestIncludesRepository(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		included []string
+		excluded []string
+	}{
+		{
+			name:     "all by default",
+			included: []string{"docker-hosted", "maven-releases"},
+		},
+		{
+			name:     "include",
+			settings: `include_repositories: ["^docker-"]`,
+			included: []string{"docker-hosted", "docker-prod"},
+			excluded: []string{"maven-releases"},
+		},
+		{
+			name:     "exclude",
+			settings: `exclude_repositories: ["-prod$"]`,
+			included: []string{"docker-hosted"},
+			excluded: []string{"docker-prod"},
+		},
+		{
+			name:     "exclude wins",
+			settings: "include_repositories: [\"^docker-\"]\nexclude_repositories: [\"^docker-prod$\"]",
+			included: []string{"docker-hosted"},
+			excluded: []string{"docker-prod", "maven-releases"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := mustParse(t, tt.settings)
+			for _, name := range tt.included {
+				if !cfg.IncludesRepository(name) {
+					t.Errorf("%s excluded", name)
+				}
+			}
+			for _, name := range tt.excluded {
+				if cfg.IncludesRepository(name) {
+					t.Errorf("%s included", name)
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidRepositoryRegex(t *testing.T) {
+	_, err := Parse([]byte(testNexus + "rules: [{name: r, regex: \".*\", keep: 1}]\nexclude_repositories: [\"(\"]"))
+	if err == nil {
+		t.Error("accepted an invalid exclude_repositories regex")
+	}
+}
+
+// parseRule parses a configuration with a single rule and returns it.
+func parseRule(t *testing.T, rule string) *Rule {
+	t.Helper()
+	cfg, err := Parse([]byte(testNexus + "rules: [{name: r, keep: 1, " + rule + "}]"))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	return &cfg.Rules[0]
+}
+
+func TestRuleMatchesCaseInsensitive(t *testing.T) {
+	tests := []struct {
+		rule  string
+		image string
+		want  bool
+	}{
+		{`regex: "^prod-"`, "prod-api", true},
+		{`regex: "^prod-"`, "Prod-API", false},
+		{`regex: "^prod-", case_insensitive: true`, "Prod-API", true},
+		{`regex: "^prod-", case_insensitive: true`, "dev-api", false},
+		{`regex: ".*", group_regex: "^com\\.example\\.", case_insensitive: true`, "Com.Example.core:lib", true},
+		{`regex: ".*", group_regex: "^com\\.example\\."`, "Com.Example.core:lib", false},
+	}
+
+	for _, tt := range tests {
+		if got := parseRule(t, tt.rule).Matches(tt.image); got != tt.want {
+			t.Errorf("rule {%s} Matches(%q) = %t, want %t", tt.rule, tt.image, got, tt.want)
+		}
+	}
+}
+
+func TestWriteLogHeader(t *testing.T) {
+	for settings, want := range map[string]bool{
+		"":                        true,
+		"log_write_header: true":  true,
+		"log_write_header: false": false,
+	} {
+		if got := mustParse(t, settings).WriteLogHeader(); got != want {
+			t.Errorf("WriteLogHeader() with %q = %t, want %t", settings, got, want)
+		}
+	}
+}
+
+func TestGetKeepCountOverrides(t *testing.T) {
+	cfg, err := Parse([]byte(testNexus + `
+rules: [{name: prod, regex: "^prod-", keep: 10}, {name: all, regex: ".*", keep: 3}]
+keep_overrides: {prod-legacy: 2, tools: 7}
+`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+
+	tests := []struct {
+		image string
+		keep  int
+		rule  string
+	}{
+		{"prod-legacy", 2, "keep override"},
+		{"prod-api", 10, "prod"},
+		{"tools", 7, "keep override"},
+		// Overrides apply to exact names only
+		{"tools-ci", 3, "all"},
+	}
+
+	for _, tt := range tests {
+		keep, rule, ok := cfg.GetKeepCount("docker-hosted", tt.image)
+		if !ok || keep != tt.keep || rule != tt.rule {
+			t.Errorf("GetKeepCount(%q) = %d, %q, %t, want %d, %q", tt.image, keep, rule, ok, tt.keep, tt.rule)
+		}
+	}
+}
+
+func TestKeepOverridesValidation(t *testing.T) {
+	_, err := Parse([]byte(testNexus + "rules: [{name: r, regex: \".*\", keep: 1}]\nkeep_overrides: {app: 0}"))
+	if err == nil || !strings.Contains(err.Error(), "keep_overrides 'app'") {
+		t.Errorf("Parse() error = %v, want keep_overrides error", err)
+	}
+}
+
+func TestMinimumAge(t *testing.T) {
+	tests := []struct {
+		minAge string
+		want   time.Duration
+		err    bool
+	}{
+		{minAge: "", want: 0},
+		{minAge: "72h", want: 72 * time.Hour},
+		{minAge: "7d", want: 7 * 24 * time.Hour},
+		{minAge: "1.5d", err: true},
+		{minAge: "-1h", err: true},
+		{minAge: "soon", err: true},
+	}
+
+	for _, tt := range tests {
+		cfg, err := Parse([]byte(testNexus + "rules: [{name: r, regex: \".*\", keep: 1}]\nmin_age: \"" + tt.minAge + "\""))
+		if tt.err {
+			if err == nil {
+				t.Errorf("min_age %q accepted", tt.minAge)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("min_age %q: %v", tt.minAge, err)
+		} else if got := cfg.MinimumAge(); got != tt.want {
+			t.Errorf("MinimumAge() for %q = %s, want %s", tt.minAge, got, tt.want)
+		}
+	}
+}
+
+func TestCanonicalImage(t *testing.T) {
+	cfg := mustParse(t, "image_aliases: {svc: service, my-service: service}")
+
+	for name, want := range map[string]string{"svc": "service", "my-service": "service", "service": "service", "other": "other"} {
+		if got := cfg.CanonicalImage(name); got != want {
+			t.Errorf("CanonicalImage(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	if _, err := Parse([]byte(testNexus + "rules: [{name: r, regex: \".*\", keep: 1}]\nimage_aliases: {svc: service, service: app}")); err == nil {
+		t.Error("Parse accepted an alias of an alias")
+	}
+}
+
+func TestDownloadScalingKeep(t *testing.T) {
+	s := &DownloadScaling{MinKeep: 2, MaxKeep: 10, MaxDownloads: 1000}
+
+	for downloads, want := range map[int64]int{0: 2, 125: 3, 500: 6, 1000: 10, 50000: 10} {
+		if got := s.Keep(downloads); got != want {
+			t.Errorf("Keep(%d) = %d, want %d", downloads, got, want)
+		}
+	}
+}
+
+func TestLogLocation(t *testing.T) {
+	tests := []struct {
+		settings string
+		want     string
+	}{
+		{"timezone: Europe/Berlin", "Europe/Berlin"},
+		{"timezone: Europe/Berlin\nlog_timezone: UTC", "UTC"},
+		{"log_timezone: America/New_York", "America/New_York"},
+	}
+
+	for _, tt := range tests {
+		if got := mustParse(t, tt.settings).LogLocation().String(); got != tt.want {
+			t.Errorf("LogLocation() with %q = %s, want %s", tt.settings, got, tt.want)
+		}
+	}
+
+	if _, err := Parse([]byte(testNexus + "rules: [{name: r, regex: \".*\", keep: 1}]\nlog_timezone: Mars/Olympus")); err == nil {
+		t.Error("Parse accepted an unknown log_timezone")
+	}
+}
+
+func TestGroupKey(t *testing.T) {
+	tests := []struct {
+		regex string
+		tag   string
+		want  string
+	}{
+		{"-(dev|prod)$", "1.2-dev", "dev"},
+		{"-(dev|prod)$", "1.2-prod", "prod"},
+		{"-(dev|prod)$", "1.2", ""},
+		{"^[a-z]+", "feature-1", "feature"},
+		{"", "1.2-dev", ""},
+	}
+
+	for _, tt := range tests {
+		rule := parseRule(t, `regex: ".*", group_by_regex: "`+tt.regex+`"`)
+		if got := rule.GroupKey(tt.tag); got != tt.want {
+			t.Errorf("GroupKey(%q) with %q = %q, want %q", tt.tag, tt.regex, got, tt.want)
+		}
+	}
+}
+
+func TestRuleMatchesGroup(t *testing.T) {
+	rule := parseRule(t, `regex: "lib$", group_regex: "^com\\.example"`)
+
+	for name, want := range map[string]bool{
+		"com.example:lib":       true,
+		"com.example.tools:lib": true,
+		"org.other:lib":         false,
+		"com.example:app":       false,
+		// Docker images have no group
+		"lib": false,
+	} {
+		if got := rule.Matches(name); got != want {
+			t.Errorf("Matches(%q) = %t, want %t", name, got, want)
+		}
+	}
+}
+
+func TestAPIKeyValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		err    bool
+		header string
+	}{
+		{name: "api key", config: "nexus: {url: http://nexus.test, api_key: secret}", header: "X-Nexus-ApiKey"},
+		{name: "custom header", config: "nexus: {url: http://nexus.test, api_key: secret, api_key_header: X-Api-Token}", header: "X-Api-Token"},
+		{name: "header without key", config: "nexus: {url: http://nexus.test, username: admin, password: secret, api_key_header: X-Api-Token}", err: true},
+		{name: "key and password", config: "nexus: {url: http://nexus.test, username: admin, password: secret, api_key: secret}", err: true},
+		{name: "invalid header", config: "nexus: {url: http://nexus.test, api_key: secret, api_key_header: 'X-Api: Token'}", err: true},
+		{name: "other backend", config: "backend: harbor\nnexus: {url: http://harbor.test, api_key: secret}", err: true},
+		{name: "no credentials", config: "nexus: {url: http://nexus.test}", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Parse([]byte(tt.config + "\nrules: [{name: r, regex: \".*\", keep: 1}]"))
+			if (err != nil) != tt.err {
+				t.Fatalf("Parse() error = %v, want error %t", err, tt.err)
+			}
+			if err == nil && cfg.Nexus.APIKeyHeader != tt.header {
+				t.Errorf("api_key_header %q, want %q", cfg.Nexus.APIKeyHeader, tt.header)
+			}
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	const rules = "rules: [{name: a, regex: \"^a\", keep: 1}, {name: b, regex: \".*\", keep: 1}]\n"
+	tests := []struct {
+		name     string
+		config   string
+		dryRun   bool
+		warnings []string
+		err      string
+	}{
+		{name: "v1 without version", config: rules},
+		{
+			name:     "v1 dry run",
+			config:   rules + "dry_run: true\n",
+			dryRun:   true,
+			warnings: []string{"top-level dry_run is deprecated, set dry_run on each rule instead"},
+		},
+		{
+			name:     "v1 dry run off",
+			config:   "version: 1\n" + rules + "dry_run: false\n",
+			warnings: []string{"top-level dry_run is deprecated, set dry_run on each rule instead"},
+		},
+		{name: "v2", config: "version: 2\n" + rules},
+		{name: "v2 top-level dry run", config: "version: 2\n" + rules + "dry_run: true\n", err: "not supported in version 2"},
+		{name: "future version", config: "version: 3\n" + rules, err: "config version 3 is newer than supported version 2"},
+		{name: "invalid version", config: "version: -1\n" + rules, err: "invalid config version -1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(testNexus+tt.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := Load(path)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("Load error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			if cfg.Version != CurrentVersion {
+				t.Errorf("version = %d, want %d", cfg.Version, CurrentVersion)
+			}
+			for _, rule := range cfg.Rules {
+				if rule.DryRun != tt.dryRun {
+					t.Errorf("rule %s dry_run = %t, want %t", rule.Name, rule.DryRun, tt.dryRun)
+				}
+			}
+			if !reflect.DeepEqual(cfg.Warnings(), tt.warnings) {
+				t.Errorf("warnings = %q, want %q", cfg.Warnings(), tt.warnings)
+			}
+		})
+	}
+}
+
+func TestLoadFromURL(t *testing.T) {
+	t.Setenv(configTokenEnv, "s3cret")
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if r.URL.Path != "/retention.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(testNexus + "rules: [{name: remote, regex: \".*\", keep: 3}]\n"))
+	}))
+	defer server.Close()
+
+	cfg, err := Load(server.URL + "/retention.yaml")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(cfg.Rules) != 1 || cfg.Rules[0].Name != "remote" || cfg.Rules[0].Keep != 3 {
+		t.Errorf("rules = %+v, want the remote rule", cfg.Rules)
+	}
+	if auth != "Bearer s3cret" {
+		t.Errorf("Authorization = %q, want the configured token", auth)
+	}
+
+	_, err = Load(server.URL + "/missing.yaml")
+	if err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("Load of a missing config returned %v, want status 404", err)
+	}
+	if _, err := Load("ftp://example.com/retention.yaml"); err == nil {
+		t.Error("loaded a config over an unsupported scheme")
+	}
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Pattern types of rules.
+const (
+	PatternRegex = "regex"
+	PatternGlob  = "glob"
+)
+
+// globToRegex translates a glob matching the whole string into a regex.
+// "*" matches any characters, "?" a single character, "[...]" and "[!...]"
+// a character class, and "\" escapes the next character.
+func globToRegex(glob string) (string, error) {
+	var b strings.Builder
+	b.WriteString("^")
+
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '\\':
+			if i+1 == len(glob) {
+				return "", fmt.Errorf("trailing escape in glob '%s'", glob)
+			}
+			i++
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated character class in glob '%s'", glob)
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	b.WriteString("$")
+	return b.String(), nil
+}
+
+// patterns returns the rule's regex and always_keep_regex as regexes,
+// translating them from globs with pattern_type glob.
+func (r *Rule) patterns() (regex, alwaysKeep string, err error) {
+	if r.PatternType != PatternGlob {
+		return r.Regex, r.AlwaysKeepRegex, nil
+	}
+
+	if regex, err = globToRegex(r.Regex); err != nil {
+		return "", "", err
+	}
+	if r.AlwaysKeepRegex != "" {
+		if alwaysKeep, err = globToRegex(r.AlwaysKeepRegex); err != nil {
+			return "", "", err
+		}
+	}
+	return regex, alwaysKeep, nil
+}
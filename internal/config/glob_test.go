@@ -0,0 +1,44 @@
+package config
+
+import "testing"
+
+func TestGlobMatchesLikeRegex(t *testing.T) {
+	tests := []struct {
+		glob, regex string
+	}{
+		{"service-*", "^service-.*$"},
+		{"v?.?.?", `^v.\..\..$`},
+		{"app-[0-9]", "^app-[0-9]$"},
+		{"app-[!0-9]", "^app-[^0-9]$"},
+		{`lit\*`, `^lit\*$`},
+	}
+	names := []string{"service-api", "service", "my-service-api", "v1.2.3", "v1.22.3", "v1x2x3", "app-1", "app-x", "app-12", "lit*", "lit"}
+
+	for _, tt := range tests {
+		glob := parseRule(t, `regex: '`+tt.glob+`', pattern_type: glob`)
+		regex := parseRule(t, `regex: '`+tt.regex+`'`)
+		for _, name := range names {
+			if got, want := glob.Matches(name), regex.Matches(name); got != want {
+				t.Errorf("glob %q matches %q = %t, regex %q = %t", tt.glob, name, got, tt.regex, want)
+			}
+		}
+	}
+}
+
+func TestGlobAlwaysKeep(t *testing.T) {
+	rule := parseRule(t, `regex: "app-*", always_keep_regex: "release-*", pattern_type: glob`)
+	if !rule.AlwaysKeeps("release-1") || rule.AlwaysKeeps("dev-release-1") {
+		t.Error("always_keep_regex not translated from a glob")
+	}
+}
+
+func TestInvalidGlob(t *testing.T) {
+	for _, glob := range []string{`app\`, "app-[0-9"} {
+		if _, err := Parse([]byte(testNexus + `rules: [{name: r, keep: 1, pattern_type: glob, regex: '` + glob + `'}]`)); err == nil {
+			t.Errorf("Parse accepted the glob %q", glob)
+		}
+	}
+	if _, err := Parse([]byte(testNexus + `rules: [{name: r, keep: 1, pattern_type: wildcard, regex: "app"}]`)); err == nil {
+		t.Error("Parse accepted an unknown pattern_type")
+	}
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Instance is a Nexus server the policy is applied to. Unset settings are
+// taken from the nexus section; credentials are taken as a whole, so an
+// instance setting a username, password or API key inherits none of them.
+type Instance struct {
+	// Name identifies the instance in output and in its file names
+	Name        string `yaml:"name"`
+	NexusConfig `yaml:",inline"`
+}
+
+// instanceNamePattern restricts names to characters safe in file names.
+var instanceNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// validateInstances checks the instances and merges their settings with
+// the nexus section.
+func (c *Config) validateInstances() error {
+	seen := make(map[string]bool)
+	c.instanceNexus = make([]NexusConfig, len(c.Instances))
+	for i, inst := range c.Instances {
+		if !instanceNamePattern.MatchString(inst.Name) {
+			return fmt.Errorf("instance %d: name must be letters, digits, '-' or '_'", i+1)
+		}
+		if seen[inst.Name] {
+			return fmt.Errorf("instance '%s' is defined twice", inst.Name)
+		}
+		seen[inst.Name] = true
+		if inst.URL == "" {
+			return fmt.Errorf("instance '%s': url is required", inst.Name)
+		}
+
+		merged := c.mergeNexus(inst.NexusConfig)
+		if err := c.validateAuth(&merged, fmt.Sprintf("instance '%s': ", inst.Name)); err != nil {
+			return err
+		}
+		c.instanceNexus[i] = merged
+	}
+	return nil
+}
+
+// mergeNexus returns the nexus section overridden by the set settings of an
+// instance.
+func (c *Config) mergeNexus(inst NexusConfig) NexusConfig {
+	merged := c.Nexus
+	merged.URL = inst.URL
+	if inst.Username != "" || inst.Password != "" || inst.APIKey != "" {
+		merged.Username, merged.Password = inst.Username, inst.Password
+		merged.APIKey, merged.APIKeyHeader = inst.APIKey, inst.APIKeyHeader
+	}
+	if inst.Timeout != 0 {
+		merged.Timeout = inst.Timeout
+	}
+	if len(inst.Headers) > 0 {
+		merged.Headers = make(map[string]string, len(c.Nexus.Headers)+len(inst.Headers))
+		for name, value := range c.Nexus.Headers {
+			merged.Headers[name] = value
+		}
+		for name, value := range inst.Headers {
+			merged.Headers[name] = value
+		}
+	}
+	return merged
+}
+
+// ForInstance returns the configuration applied to the i-th instance: its
+// merged registry settings, with the files written by runs suffixed by the
+// instance name so instances don't overwrite each other's state, e.g.
+// "deletion_log.eu.csv".
+func (c *Config) ForInstance(i int) *Config {
+	name := c.Instances[i].Name
+	cfg := *c
+	cfg.Nexus = c.instanceNexus[i]
+	cfg.Instances, cfg.instanceNexus = nil, nil
+
+	cfg.LogFile = instanceFile(c.LogFile, name)
+	cfg.StateFile = instanceFile(c.StateFile, name)
+	if c.QuarantineFile != "" {
+		cfg.QuarantineFile = instanceFile(c.QuarantineFile, name)
+	}
+	if c.LastPlanFile != "" {
+		cfg.LastPlanFile = instanceFile(c.LastPlanFile, name)
+	}
+	if c.BackupManifest != "" {
+		cfg.BackupManifest = instanceFile(c.BackupManifest, name)
+	}
+	if c.PerRepoReportsDir != "" {
+		cfg.PerRepoReportsDir = filepath.Join(c.PerRepoReportsDir, name)
+	}
+	cfg.Sinks = make([]SinkConfig, len(c.Sinks))
+	for j, sink := range c.Sinks {
+		if sink.Path != "" {
+			sink.Path = instanceFile(sink.Path, name)
+		}
+		cfg.Sinks[j] = sink
+	}
+	return &cfg
+}
+
+// instanceFile inserts the instance name before the extension of path.
+func instanceFile(path, name string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + name + ext
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateInstances(t *testing.T) {
+	tests := []struct {
+		name      string
+		instances string
+		// err is a substring of the expected error, empty if valid
+		err string
+	}{
+		{name: "valid", instances: `[{name: eu, url: "http://eu.test"}, {name: us_1, url: "http://us.test"}]`},
+		{name: "invalid name", instances: `[{name: "eu/1", url: "http://eu.test"}]`, err: "name must be"},
+		{name: "duplicate", instances: `[{name: eu, url: "http://a.test"}, {name: eu, url: "http://b.test"}]`, err: "defined twice"},
+		{name: "missing url", instances: `[{name: eu}]`, err: "url is required"},
+		{name: "partial credentials", instances: `[{name: eu, url: "http://eu.test", username: ops}]`, err: "instance 'eu': "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(testNexus + "rules: [{name: r, regex: \".*\", keep: 1}]\ninstances: " + tt.instances))
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("rejected valid instances: %v", err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("error = %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestForInstance(t *testing.T) {
+	cfg, err := Parse([]byte(`
+nexus:
+  url: "http://nexus.test"
+  username: admin
+  password: secret
+  timeout: 30
+  headers: {X-Team: ops, X-Env: prod}
+instances:
+  - name: eu
+    url: "http://eu.test"
+    headers: {X-Env: eu}
+  - name: us
+    url: "http://us.test"
+    username: us-admin
+    password: us-secret
+    timeout: 5
+sinks: [{type: json, path: events/run.json}]
+per_repo_reports_dir: reports
+rules: [{name: r, regex: ".*", keep: 1}]
+`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+
+	eu, us := cfg.ForInstance(0), cfg.ForInstance(1)
+	if eu.Nexus.URL != "http://eu.test" || eu.Nexus.Username != "admin" || eu.Nexus.Timeout != 30 {
+		t.Errorf("eu settings = %+v, want the nexus section with its own url", eu.Nexus)
+	}
+	if eu.Nexus.Headers["X-Team"] != "ops" || eu.Nexus.Headers["X-Env"] != "eu" {
+		t.Errorf("eu headers = %v, want the nexus headers overridden by its own", eu.Nexus.Headers)
+	}
+	if cfg.Nexus.Headers["X-Env"] != "prod" {
+		t.Errorf("merging changed the nexus headers to %v", cfg.Nexus.Headers)
+	}
+	if us.Nexus.Username != "us-admin" || us.Nexus.Password != "us-secret" || us.Nexus.Timeout != 5 {
+		t.Errorf("us settings = %+v, want its own credentials and timeout", us.Nexus)
+	}
+
+	if eu.LogFile != "deletion_log.eu.csv" || us.StateFile != "retention_state.us.json" {
+		t.Errorf("files = %s and %s, want them suffixed by the instance", eu.LogFile, us.StateFile)
+	}
+	if eu.Sinks[0].Path != "events/run.eu.json" || cfg.Sinks[0].Path != "events/run.json" {
+		t.Errorf("sink paths = %s and %s, want only the instance's suffixed", eu.Sinks[0].Path, cfg.Sinks[0].Path)
+	}
+	if us.PerRepoReportsDir != "reports/us" {
+		t.Errorf("reports dir = %s, want reports/us", us.PerRepoReportsDir)
+	}
+	if len(eu.Instances) != 0 {
+		t.Errorf("instance configuration has instances %v", eu.Instances)
+	}
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"strings"
+)
+
+// Lint reports rules that never match because an earlier rule matches every
+// image they match, in every repository they apply to. Rules are matched
+// first come first served, so a broad early rule shadows specific later
+// ones. Only common pattern shapes are analyzed: a literal prefix, suffix or
+// substring, an exact name and match-all patterns.
+func (c *Config) Lint() []string {
+	var findings []string
+	for j := range c.Rules {
+		for i := 0; i < j; i++ {
+			if c.Rules[i].shadows(&c.Rules[j]) {
+				findings = append(findings, fmt.Sprintf("rule '%s' is shadowed by earlier rule '%s': every image matching '%s' also matches '%s'",
+					c.Rules[j].Name, c.Rules[i].Name, c.Rules[j].Regex, c.Rules[i].Regex))
+				break
+			}
+		}
+	}
+	return findings
+}
+
+// shadows reports whether the rule matches every image matched by a later
+// rule in all repositories the later rule applies to.
+func (r *Rule) shadows(later *Rule) bool {
+	if r.compiledRegex == nil || later.compiledRegex == nil {
+		return false
+	}
+	// A group_regex narrows the rule beyond its name pattern
+	if r.GroupRegex != "" && (r.GroupRegex != later.GroupRegex || later.CaseInsensitive && !r.CaseInsensitive) {
+		return false
+	}
+
+	if len(r.Repositories) > 0 {
+		if len(later.Repositories) == 0 {
+			return false
+		}
+		for _, repo := range later.Repositories {
+			if !r.AppliesTo(repo) {
+				return false
+			}
+		}
+	}
+
+	broad, ok := analyzePattern(r.compiledRegex.String())
+	if !ok || broad.partial {
+		return false
+	}
+	if broad.literal == "" && broad.kind != patternExact {
+		return true
+	}
+
+	narrow, ok := analyzePattern(later.compiledRegex.String())
+	if !ok || (narrow.fold && !broad.fold) {
+		return false
+	}
+
+	literal, narrowLiteral := broad.literal, narrow.literal
+	if broad.fold {
+		literal, narrowLiteral = strings.ToLower(literal), strings.ToLower(narrowLiteral)
+	}
+
+	switch broad.kind {
+	case patternContains:
+		return strings.Contains(narrowLiteral, literal)
+	case patternPrefix:
+		return (narrow.kind == patternPrefix || narrow.kind == patternExact) && strings.HasPrefix(narrowLiteral, literal)
+	case patternSuffix:
+		return (narrow.kind == patternSuffix || narrow.kind == patternExact) && strings.HasSuffix(narrowLiteral, literal)
+	case patternExact:
+		return narrow.kind == patternExact && literal == narrowLiteral
+	}
+	return false
+}
+
+// patternKind is the shape of names a pattern matches around its literal.
+type patternKind int
+
+const (
+	patternContains patternKind = iota
+	patternPrefix
+	patternSuffix
+	patternExact
+)
+
+// pattern summarizes a regex as the literal every match contains, starts
+// with, ends with or equals. A partial pattern matches only some of the
+// names of its shape.
+type pattern struct {
+	kind    patternKind
+	literal string
+	fold    bool
+	partial bool
+}
+
+// analyzePattern summarizes a regex of the form [^]literal[.*][$]. Regexes
+// of other forms are summarized as partial by their leading literal when
+// they are anchored to the start, so they can still be found shadowed by a
+// prefix.
+func analyzePattern(expr string) (pattern, bool) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return pattern{}, false
+	}
+	re = re.Simplify()
+
+	var parts []*syntax.Regexp
+	switch re.Op {
+	case syntax.OpEmptyMatch:
+	case syntax.OpConcat:
+		parts = re.Sub
+	default:
+		parts = []*syntax.Regexp{re}
+	}
+
+	var p pattern
+	start := len(parts) > 0 && isBeginAnchor(parts[0])
+	if start {
+		parts = parts[1:]
+	}
+	if len(parts) > 0 && parts[0].Op == syntax.OpLiteral {
+		p.literal = string(parts[0].Rune)
+		p.fold = parts[0].Flags&syntax.FoldCase != 0
+		parts = parts[1:]
+	}
+	end := len(parts) > 0 && isEndAnchor(parts[len(parts)-1])
+	if end {
+		parts = parts[:len(parts)-1]
+	}
+
+	// Anything following the literal must be ".*"
+	rest := len(parts) > 0
+	if rest && !(len(parts) == 1 && isMatchAll(parts[0])) {
+		if start {
+			// Every match still starts with the literal
+			return pattern{kind: patternPrefix, literal: p.literal, fold: p.fold, partial: true}, p.literal != ""
+		}
+		return pattern{}, false
+	}
+	if p.literal == "" && len(parts) == 0 && start && end {
+		// Only the empty name matches
+		return pattern{}, false
+	}
+
+	switch {
+	case start && end && !rest:
+		p.kind = patternExact
+	case start:
+		p.kind = patternPrefix
+	case end && !rest:
+		p.kind = patternSuffix
+	default:
+		p.kind = patternContains
+	}
+	return p, true
+}
+
+func isBeginAnchor(re *syntax.Regexp) bool {
+	return re.Op == syntax.OpBeginText || re.Op == syntax.OpBeginLine
+}
+
+func isEndAnchor(re *syntax.Regexp) bool {
+	return re.Op == syntax.OpEndText || re.Op == syntax.OpEndLine
+}
+
+func isMatchAll(re *syntax.Regexp) bool {
+	if re.Op != syntax.OpStar {
+		return false
+	}
+	sub := re.Sub[0]
+	return sub.Op == syntax.OpAnyChar || sub.Op == syntax.OpAnyCharNotNL
+}
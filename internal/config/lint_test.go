@@ -0,0 +1,47 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		earlier  string
+		later    string
+		shadowed bool
+	}{
+		{"match all", `regex: ".*"`, `regex: "^app$"`, true},
+		{"prefix", `regex: "^service-"`, `regex: "^service-api$"`, true},
+		{"prefix of prefix", `regex: "^service"`, `regex: "^service-api-.*"`, true},
+		{"substring", `regex: "api"`, `regex: "^team/api-v2$"`, true},
+		{"suffix", `regex: "-snapshot$"`, `regex: "^app-snapshot$"`, true},
+		{"exact", `regex: "^app$"`, `regex: "^app$"`, true},
+		{"case-insensitive", `regex: "^APP", case_insensitive: true`, `regex: "^app-api$"`, true},
+		{"independent prefixes", `regex: "^service-"`, `regex: "^worker-"`, false},
+		{"narrower earlier", `regex: "^service-api$"`, `regex: "^service-"`, false},
+		{"different suffix", `regex: "-snapshot$"`, `regex: "^app-release$"`, false},
+		{"partial pattern", `regex: "^app-[0-9]+"`, `regex: "^app-1$"`, false},
+		{"earlier repositories", `regex: ".*", repositories: [docker-a]`, `regex: "^app$"`, false},
+		{"same repositories", `regex: ".*", repositories: [docker-a]`, `regex: "^app$", repositories: [docker-a]`, true},
+		{"group_regex", `regex: ".*", group_regex: "^com\\.example"`, `regex: "^app$"`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Parse([]byte(testNexus + "rules:\n  - {name: earlier, keep: 1, " + tt.earlier + "}\n  - {name: later, keep: 1, " + tt.later + "}\n"))
+			if err != nil {
+				t.Fatalf("parse config: %v", err)
+			}
+
+			findings := cfg.Lint()
+			if shadowed := len(findings) > 0; shadowed != tt.shadowed {
+				t.Fatalf("findings %v, want shadowed %t", findings, tt.shadowed)
+			}
+			if tt.shadowed && !strings.Contains(findings[0], "rule 'later' is shadowed by earlier rule 'earlier'") {
+				t.Errorf("finding %q", findings[0])
+			}
+		})
+	}
+}
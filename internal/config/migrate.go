@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the config schema version written by this release.
+// Files without a version field are version 1.
+const CurrentVersion = 2
+
+// legacyFields are config keys of older versions that have been replaced.
+type legacyFields struct {
+	Version int `yaml:"version"`
+	// DryRun (v1) made all rules dry-run; replaced by per-rule dry_run
+	DryRun *bool `yaml:"dry_run"`
+}
+
+// migrate upgrades a config parsed from an older schema version in place,
+// recording a warning for each replaced field.
+func (c *Config) migrate(data []byte) error {
+	var legacy legacyFields
+	if err := yaml.Unmarshal(data, &legacy); err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	if c.Version == 0 {
+		c.Version = 1
+	}
+	if c.Version > CurrentVersion {
+		return fmt.Errorf("config version %d is newer than supported version %d", c.Version, CurrentVersion)
+	}
+	if c.Version < 1 {
+		return fmt.Errorf("invalid config version %d", c.Version)
+	}
+
+	if c.Version == 1 {
+		if legacy.DryRun != nil {
+			if *legacy.DryRun {
+				for i := range c.Rules {
+					c.Rules[i].DryRun = true
+				}
+			}
+			c.warnings = append(c.warnings, "top-level dry_run is deprecated, set dry_run on each rule instead")
+		}
+		c.Version = 2
+	} else if legacy.DryRun != nil {
+		return fmt.Errorf("top-level dry_run is not supported in version %d, set dry_run on each rule", c.Version)
+	}
+
+	return nil
+}
+
+// Warnings returns the deprecation warnings recorded while loading.
+func (c *Config) Warnings() []string {
+	return c.warnings
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/robfig/cron/v3"
+)
+
+// standardFields are the fields of a standard 5-field cron expression.
+const standardFields = cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor
+
+// scheduleParser returns the parser for the schedule. With
+// schedule_with_seconds, expressions may start with a seconds field.
+func (c *Config) scheduleParser() cron.Parser {
+	if c.ScheduleWithSeconds {
+		return cron.NewParser(cron.SecondOptional | standardFields)
+	}
+	return cron.NewParser(standardFields)
+}
+
+// compileSchedule parses the schedule, if any.
+func (c *Config) compileSchedule() error {
+	if c.Schedule == "" {
+		return nil
+	}
+
+	schedule, err := c.scheduleParser().Parse(c.Schedule)
+	if err != nil {
+		if !c.ScheduleWithSeconds && len(strings.Fields(c.Schedule)) == 6 {
+			return fmt.Errorf("invalid schedule '%s': expressions with a seconds field require schedule_with_seconds: true", c.Schedule)
+		}
+		return fmt.Errorf("invalid schedule '%s': %w", c.Schedule, err)
+	}
+	c.cronSchedule = schedule
+	return nil
+}
+
+// CronSchedule returns the parsed schedule, or nil for one-time execution.
+func (c *Config) CronSchedule() cron.Schedule {
+	return c.cronSchedule
+}
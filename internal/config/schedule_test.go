@@ -0,0 +1,66 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		settings string
+		next     time.Time
+		err      string
+	}{
+		{
+			name:     "5 fields",
+			settings: `schedule: "0 2 * * *"`,
+			next:     time.Date(2024, 1, 16, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "6 fields without seconds",
+			settings: `schedule: "30 0 2 * * *"`,
+			err:      "require schedule_with_seconds: true",
+		},
+		{
+			name:     "5 fields with seconds",
+			settings: "schedule: \"0 2 * * *\"\nschedule_with_seconds: true",
+			next:     time.Date(2024, 1, 16, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "6 fields with seconds",
+			settings: "schedule: \"30 0 2 * * *\"\nschedule_with_seconds: true",
+			next:     time.Date(2024, 1, 16, 2, 0, 30, 0, time.UTC),
+		},
+		{
+			name:     "descriptor",
+			settings: `schedule: "@hourly"`,
+			next:     time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "invalid",
+			settings: `schedule: "every day"`,
+			err:      "invalid schedule",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Parse([]byte(testNexus + "rules: [{name: r, regex: \".*\", keep: 1}]\n" + tt.settings))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Parse() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse config: %v", err)
+			}
+			if next := cfg.CronSchedule().Next(start); !next.Equal(tt.next) {
+				t.Errorf("next run %s, want %s", next, tt.next)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semver is a semantic version. Build metadata is ignored.
+type semver struct {
+	major, minor, patch int
+	prerelease          []string
+}
+
+// parseSemver parses a version like "1.2.3", "v1.2.3-rc.1" or "1.2+build".
+// A missing patch number defaults to 0. Versions need at least a major and
+// minor number, so bare numbers like build IDs or dates ("1234",
+// "20240115") aren't taken for major versions.
+func parseSemver(s string) (semver, bool) {
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+
+	var v semver
+	core, prerelease, hasPrerelease := strings.Cut(s, "-")
+	if hasPrerelease {
+		if prerelease == "" {
+			return semver{}, false
+		}
+		v.prerelease = strings.Split(prerelease, ".")
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return semver{}, false
+	}
+	numbers := []*int{&v.major, &v.minor, &v.patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || part != strconv.Itoa(n) {
+			return semver{}, false
+		}
+		*numbers[i] = n
+	}
+	return v, true
+}
+
+// compare returns -1, 0 or 1 as v is lower than, equal to or higher than w,
+// following semver precedence: a prerelease is lower than its release.
+func (v semver) compare(w semver) int {
+	for _, pair := range [][2]int{{v.major, w.major}, {v.minor, w.minor}, {v.patch, w.patch}} {
+		if pair[0] != pair[1] {
+			return compareInts(pair[0], pair[1])
+		}
+	}
+
+	switch {
+	case len(v.prerelease) == 0 && len(w.prerelease) == 0:
+		return 0
+	case len(v.prerelease) == 0:
+		return 1
+	case len(w.prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.prerelease) && i < len(w.prerelease); i++ {
+		if c := comparePrerelease(v.prerelease[i], w.prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(v.prerelease), len(w.prerelease))
+}
+
+// comparePrerelease compares prerelease identifiers: numeric identifiers
+// numerically and lower than alphanumeric ones, which compare as strings.
+func comparePrerelease(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInts(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compileVersionBound parses a version_floor or version_ceiling, returning
+// nil for an empty bound.
+func compileVersionBound(field, bound string) (*semver, error) {
+	if bound == "" {
+		return nil, nil
+	}
+	v, ok := parseSemver(bound)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s '%s': not a semantic version", field, bound)
+	}
+	return &v, nil
+}
@@ -0,0 +1,83 @@
+package config
+
+import "testing"
+
+func TestParseSemver(t *testing.T) {
+	tests := []struct {
+		tag string
+		ok  bool
+	}{
+		{"1.2.3", true},
+		{"v1.2.3", true},
+		{"1.2", true},
+		{"1.2.3-rc.1", true},
+		{"1.2+build.5", true},
+		{"1234", false},
+		{"20240115", false},
+		{"v3", false},
+		{"1.2.3.4", false},
+		{"1.02.3", false},
+		{"1.2.3-", false},
+		{"latest", false},
+	}
+
+	for _, tt := range tests {
+		if _, ok := parseSemver(tt.tag); ok != tt.ok {
+			t.Errorf("parseSemver(%q) ok = %t, want %t", tt.tag, ok, tt.ok)
+		}
+	}
+}
+
+func TestSemverCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2", "1.2.0", 0},
+		{"1.2.3", "1.10.0", -1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0-rc.2", "1.0.0-rc.10", -1},
+		{"1.0.0-alpha", "1.0.0-1", 1},
+		{"1.0.0-rc", "1.0.0-rc.1", -1},
+	}
+
+	for _, tt := range tests {
+		a, _ := parseSemver(tt.a)
+		b, _ := parseSemver(tt.b)
+		if got := a.compare(b); got != tt.want {
+			t.Errorf("compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestVersionProtection(t *testing.T) {
+	cfg, err := Parse([]byte(testNexus + `rules: [{name: r, regex: ".*", keep: 1, version_floor: "1.0", version_ceiling: "2.0.0"}]`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	rule := &cfg.Rules[0]
+
+	tests := []struct {
+		tag       string
+		protected bool
+	}{
+		{"0.9.1", true},
+		{"1.0.0-rc.1", true},
+		{"1.0.0", false},
+		{"v1.5.2", false},
+		{"2.0.0", true},
+		// Build numbers and dates aren't versions, so they aren't
+		// above the ceiling
+		{"1234", false},
+		{"20240115", false},
+		{"latest", false},
+	}
+
+	for _, tt := range tests {
+		if got := rule.VersionProtection(tt.tag) != ""; got != tt.protected {
+			t.Errorf("VersionProtection(%q) protected = %t, want %t", tt.tag, got, tt.protected)
+		}
+	}
+}
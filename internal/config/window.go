@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// timeWindow is a daily window in minutes since midnight. A window whose end
+// is before its start wraps around midnight (e.g. 22:00-04:00).
+type timeWindow struct {
+	start int
+	end   int
+}
+
+func parseTimeWindow(s string) (*timeWindow, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("expected HH:MM-HH:MM, got '%s'", s)
+	}
+
+	start, err := parseClock(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseClock(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	if start == end {
+		return nil, fmt.Errorf("window '%s' is empty", s)
+	}
+
+	return &timeWindow{start: start, end: end}, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time '%s': expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (w *timeWindow) contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if w.start < w.end {
+		return minute >= w.start && minute < w.end
+	}
+	return minute >= w.start || minute < w.end
+}
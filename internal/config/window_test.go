@@ -0,0 +1,63 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeWindow(t *testing.T) {
+	at := func(clock string) time.Time {
+		t, _ := time.Parse("15:04", clock)
+		return t
+	}
+	tests := []struct {
+		window string
+		clock  string
+		want   bool
+	}{
+		{"01:00-05:00", "03:30", true},
+		{"01:00-05:00", "01:00", true},
+		{"01:00-05:00", "05:00", false},
+		{"01:00-05:00", "12:00", false},
+		{"22:00-04:00", "23:15", true},
+		{"22:00-04:00", "00:00", true},
+		{"22:00-04:00", "03:59", true},
+		{"22:00-04:00", "04:00", false},
+		{"22:00-04:00", "21:59", false},
+	}
+
+	for _, tt := range tests {
+		w, err := parseTimeWindow(tt.window)
+		if err != nil {
+			t.Fatalf("parseTimeWindow(%q): %v", tt.window, err)
+		}
+		if got := w.contains(at(tt.clock)); got != tt.want {
+			t.Errorf("%s contains %s = %t, want %t", tt.window, tt.clock, got, tt.want)
+		}
+	}
+}
+
+func TestInvalidTimeWindow(t *testing.T) {
+	for _, window := range []string{"", "01:00", "01:00-05:00-07:00", "1am-5am", "25:00-05:00", "01:00-01:00"} {
+		if _, err := parseTimeWindow(window); err == nil {
+			t.Errorf("parseTimeWindow(%q) accepted an invalid window", window)
+		}
+	}
+	if _, err := Parse([]byte(testNexus + "rules: [{name: r, regex: \".*\", keep: 1}]\nallowed_hours: \"01:00\"")); err == nil {
+		t.Error("accepted an invalid allowed_hours")
+	}
+}
+
+func TestInAllowedHours(t *testing.T) {
+	cfg := mustParse(t, "allowed_hours: \"22:00-04:00\"\ntimezone: America/New_York")
+	// 03:00 UTC is 22:00 in New York
+	if !cfg.InAllowedHours(time.Date(2024, 1, 15, 3, 0, 0, 0, time.UTC)) {
+		t.Error("22:00 in the configured timezone is outside the window")
+	}
+	if cfg.InAllowedHours(time.Date(2024, 1, 15, 23, 0, 0, 0, time.UTC)) {
+		t.Error("18:00 in the configured timezone is inside the window")
+	}
+	if cfg := mustParse(t, ""); !cfg.InAllowedHours(time.Now()) {
+		t.Error("deletions outside allowed hours without a window")
+	}
+}
@@ -0,0 +1,172 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"nexus-retention-policy/internal/logger"
+)
+
+// defaultBufferSize is the number of events queued for a buffered sink.
+const defaultBufferSize = 1000
+
+// Event types.
+const (
+	TypeDeletion = "deletion"
+	TypeRun      = "run"
+)
+
+// Event is a deletion or the start or end of a run. Exactly one of Deletion
+// and Run is set, according to Type.
+type Event struct {
+	Type     string                 `json:"type"`
+	Deletion *logger.DeletionRecord `json:"deletion,omitempty"`
+	Run      *logger.RunRecord      `json:"run,omitempty"`
+}
+
+// EventSink receives the events of runs, e.g. a log file or a message bus.
+type EventSink interface {
+	Publish(event Event) error
+	Close() error
+}
+
+// Fanout sends every deletion and run event to all of its sinks.
+type Fanout struct {
+	sinks []EventSink
+	// redact replaces image names of deletions with their hash
+	redact bool
+}
+
+func NewFanout(sinks ...EventSink) *Fanout {
+	return &Fanout{sinks: sinks}
+}
+
+// RedactImageNames makes the fanout publish deletions with hashed image
+// names. Component IDs are kept for traceability.
+func (f *Fanout) RedactImageNames() {
+	f.redact = true
+}
+
+// PublishError reports the sinks an event couldn't be published to.
+type PublishError struct {
+	event  Event
+	failed []EventSink
+	err    error
+}
+
+func (e *PublishError) Error() string {
+	return e.err.Error()
+}
+
+func (e *PublishError) Unwrap() error {
+	return e.err
+}
+
+// Retry publishes the event again to the sinks that failed, so sinks that
+// received it don't get it twice. It returns a PublishError for the sinks
+// still failing.
+func (e *PublishError) Retry() error {
+	return publish(e.failed, e.event)
+}
+
+func (f *Fanout) publish(event Event) error {
+	return publish(f.sinks, event)
+}
+
+// publish sends an event to every sink, returning a PublishError for the
+// sinks that failed.
+func publish(sinks []EventSink, event Event) error {
+	var failed []EventSink
+	var errs []error
+	for _, sink := range sinks {
+		if err := sink.Publish(event); err != nil {
+			failed = append(failed, sink)
+			errs = append(errs, err)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &PublishError{event: event, failed: failed, err: errors.Join(errs...)}
+}
+
+func (f *Fanout) LogDeletion(record logger.DeletionRecord) error {
+	if f.redact {
+		record.ImageName = logger.RedactImageName(record.ImageName)
+	}
+	return f.publish(Event{Type: TypeDeletion, Deletion: &record})
+}
+
+func (f *Fanout) LogRun(record logger.RunRecord) error {
+	return f.publish(Event{Type: TypeRun, Run: &record})
+}
+
+// Close closes all sinks.
+func (f *Fanout) Close() error {
+	var errs []error
+	for _, sink := range f.sinks {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// BufferedSink publishes to a sink in the background, so a slow or
+// unavailable sink never fails or delays a run. Failures and a full buffer
+// are reported as warnings.
+type BufferedSink struct {
+	sink   EventSink
+	events chan Event
+	done   chan struct{}
+
+	mu      sync.Mutex
+	dropped int
+}
+
+// NewBufferedSink starts publishing to sink. bufferSize is the number of
+// events queued before events are dropped (0 = default).
+func NewBufferedSink(sink EventSink, bufferSize int) *BufferedSink {
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+	b := &BufferedSink{
+		sink:   sink,
+		events: make(chan Event, bufferSize),
+		done:   make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+func (b *BufferedSink) run() {
+	defer close(b.done)
+	for event := range b.events {
+		if err := b.sink.Publish(event); err != nil {
+			fmt.Printf("⚠️  Failed to publish %s event: %v\n", event.Type, err)
+		}
+	}
+}
+
+func (b *BufferedSink) Publish(event Event) error {
+	select {
+	case b.events <- event:
+	default:
+		b.mu.Lock()
+		b.dropped++
+		b.mu.Unlock()
+	}
+	return nil
+}
+
+// Close publishes the buffered events and closes the sink.
+func (b *BufferedSink) Close() error {
+	close(b.events)
+	<-b.done
+
+	if b.dropped > 0 {
+		fmt.Printf("⚠️  Dropped %d events, the event buffer was full\n", b.dropped)
+	}
+	return b.sink.Close()
+}
@@ -0,0 +1,126 @@
+package events
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"nexus-retention-policy/internal/logger"
+)
+
+// fakeSink captures published events, failing with err if set.
+type fakeSink struct {
+	mu     sync.Mutex
+	events []Event
+	err    error
+	closed bool
+	// block, if set, delays publishing until it's closed
+	block chan struct{}
+}
+
+func (s *fakeSink) Publish(event Event) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return s.err
+}
+
+func (s *fakeSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func deletion(tag string) Event {
+	return Event{Type: TypeDeletion, Deletion: &logger.DeletionRecord{ImageName: "app", Tag: tag, ComponentID: "app:" + tag}}
+}
+
+func TestFanout(t *testing.T) {
+	failing := &fakeSink{err: errors.New("unavailable")}
+	sinks := []*fakeSink{{}, failing, {}}
+	fanout := NewFanout(sinks[0], sinks[1], sinks[2])
+
+	// Every sink receives the events despite the failing one
+	if err := fanout.LogRun(logger.RunRecord{ExecutionID: "run-1", Event: logger.RunStarted}); err == nil {
+		t.Error("LogRun didn't report the failing sink")
+	}
+	fanout.LogDeletion(*deletion("v1").Deletion)
+	if err := fanout.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	for i, sink := range sinks {
+		if len(sink.events) != 2 || sink.events[0].Run.ExecutionID != "run-1" || sink.events[1].Deletion.Tag != "v1" || !sink.closed {
+			t.Errorf("sink %d received %v, closed %t", i, sink.events, sink.closed)
+		}
+	}
+}
+
+func TestFanoutRedactsImageNames(t *testing.T) {
+	sink := &fakeSink{}
+	fanout := NewFanout(sink)
+	fanout.RedactImageNames()
+	fanout.LogDeletion(logger.DeletionRecord{ImageName: "team/app", ComponentID: "team/app:v1"})
+
+	got := sink.events[0].Deletion
+	if got.ImageName != logger.RedactImageName("team/app") || got.ComponentID != "team/app:v1" {
+		t.Errorf("published %+v", got)
+	}
+}
+
+func TestPublishErrorRetriesFailedSinks(t *testing.T) {
+	working, failing := &fakeSink{}, &fakeSink{err: errors.New("unavailable")}
+	err := NewFanout(working, failing).LogDeletion(*deletion("v1").Deletion)
+
+	var publishErr *PublishError
+	if !errors.As(err, &publishErr) {
+		t.Fatalf("LogDeletion returned %v, want a PublishError", err)
+	}
+	if publishErr.Retry() == nil {
+		t.Error("Retry succeeded with the sink still failing")
+	}
+	failing.err = nil
+	if err := publishErr.Retry(); err != nil {
+		t.Errorf("Retry: %v", err)
+	}
+	if len(working.events) != 1 || len(failing.events) != 3 {
+		t.Errorf("sinks received %d and %d events, want 1 and 3", len(working.events), len(failing.events))
+	}
+}
+
+func TestBufferedSink(t *testing.T) {
+	sink := &fakeSink{err: errors.New("unavailable")}
+	buffered := NewBufferedSink(sink, 0)
+
+	// Failures of the sink don't fail publishing
+	for _, tag := range []string{"v1", "v2"} {
+		if err := buffered.Publish(deletion(tag)); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	if err := buffered.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if len(sink.events) != 2 || sink.events[1].Deletion.Tag != "v2" || !sink.closed {
+		t.Errorf("sink received %v, closed %t", sink.events, sink.closed)
+	}
+}
+
+func TestBufferedSinkDropsWhenFull(t *testing.T) {
+	sink := &fakeSink{block: make(chan struct{})}
+	buffered := NewBufferedSink(sink, 1)
+
+	// One event is being published, one is buffered and the rest dropped
+	for _, tag := range []string{"v1", "v2", "v3", "v4"} {
+		buffered.Publish(deletion(tag))
+	}
+	close(sink.block)
+	buffered.Close()
+
+	if buffered.dropped == 0 || len(sink.events)+buffered.dropped != 4 {
+		t.Errorf("published %d events and dropped %d, want 4 in total", len(sink.events), buffered.dropped)
+	}
+}
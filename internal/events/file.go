@@ -0,0 +1,71 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"nexus-retention-policy/internal/logger"
+)
+
+// CSVSink writes deletion events to the CSV deletion log. Run events aren't
+// logged.
+type CSVSink struct {
+	log *logger.Logger
+}
+
+func NewCSVSink(log *logger.Logger) *CSVSink {
+	return &CSVSink{log: log}
+}
+
+func (s *CSVSink) Publish(event Event) error {
+	if event.Deletion == nil {
+		return nil
+	}
+	return s.log.LogDeletion(*event.Deletion)
+}
+
+func (s *CSVSink) Close() error {
+	return s.log.Close()
+}
+
+// JSONSink writes each event as a line of JSON, e.g. to a file or stdout.
+type JSONSink struct {
+	mu      sync.Mutex
+	w       io.Writer
+	encoder *json.Encoder
+}
+
+// NewJSONFileSink appends events to a JSON lines file.
+func NewJSONFileSink(path string) (*JSONSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event file: %w", err)
+	}
+	return NewJSONSink(file), nil
+}
+
+// NewJSONSink writes events to w, which is closed with the sink if it is an
+// io.Closer other than stdout.
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{w: w, encoder: json.NewEncoder(w)}
+}
+
+func (s *JSONSink) Publish(event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.encoder.Encode(event); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	return nil
+}
+
+func (s *JSONSink) Close() error {
+	if closer, ok := s.w.(io.Closer); ok && s.w != os.Stdout {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"nexus-retention-policy/internal/logger"
+)
+
+func TestJSONSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewJSONSink(&buf)
+	sink.Publish(Event{Type: TypeRun, Run: &logger.RunRecord{ExecutionID: "run-1", Event: logger.RunStarted}})
+	sink.Publish(deletion("v1"))
+
+	var types []string
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("decode %s: %v", scanner.Text(), err)
+		}
+		types = append(types, event.Type)
+	}
+	if strings.Join(types, ",") != "run,deletion" {
+		t.Errorf("wrote events %v, want run,deletion", types)
+	}
+}
+
+func TestCSVSinkLogsDeletionsOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deletion_log.csv")
+	log, err := logger.NewLogger(path, logger.Options{OmitHeader: true})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	sink := NewCSVSink(log)
+	sink.Publish(Event{Type: TypeRun, Run: &logger.RunRecord{ExecutionID: "run-1"}})
+	sink.Publish(deletion("v1"))
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 1 || !strings.Contains(lines[0], "app:v1") {
+		t.Errorf("logged %q, want the deletion only", data)
+	}
+}
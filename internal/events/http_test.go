@@ -0,0 +1,35 @@
+package events
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPSink(t *testing.T) {
+	var received []Event
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("decode event: %v", err)
+		}
+		received = append(received, event)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := NewHTTPSink(server.URL)
+	if err := sink.Publish(deletion("v1")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if len(received) != 1 || received[0].Type != TypeDeletion || received[0].Deletion.ComponentID != "app:v1" {
+		t.Errorf("received %+v", received)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := sink.Publish(deletion("v2")); err == nil {
+		t.Error("Publish succeeded despite an error status")
+	}
+}
@@ -0,0 +1,155 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsTimeout bounds connecting to and writing to the NATS server.
+const natsTimeout = 10 * time.Second
+
+// NATSSink publishes each event as JSON to a NATS subject, using the core
+// NATS text protocol. The connection is opened on the first publish and
+// reopened after an error.
+type NATSSink struct {
+	addr    string
+	subject string
+	user    *url.Userinfo
+
+	mu   sync.Mutex
+	conn net.Conn
+	// pongs receives the server's replies to our PINGs on conn
+	pongs chan struct{}
+}
+
+// NewNATSSink creates a sink for a server URL like "nats://host:4222",
+// optionally with user:password credentials.
+func NewNATSSink(serverURL, subject string) (*NATSSink, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil || u.Scheme != "nats" || u.Host == "" {
+		return nil, fmt.Errorf("invalid NATS URL '%s'", serverURL)
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	return &NATSSink{addr: addr, subject: subject, user: u.User}, nil
+}
+
+func (s *NATSSink) Publish(event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+
+	s.conn.SetWriteDeadline(time.Now().Add(natsTimeout))
+	if _, err := fmt.Fprintf(s.conn, "PUB %s %d\r\n%s\r\n", s.subject, len(payload), payload); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+	return nil
+}
+
+// connect opens the connection, reads the server's INFO and sends CONNECT.
+func (s *NATSSink) connect() error {
+	conn, err := net.DialTimeout("tcp", s.addr, natsTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	conn.SetDeadline(time.Now().Add(natsTimeout))
+	reader := bufio.NewReader(conn)
+	info, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(info, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("unexpected NATS greeting: %q", strings.TrimSpace(info))
+	}
+
+	options := map[string]interface{}{"verbose": false, "pedantic": false, "name": "nexus-retention-policy"}
+	if s.user != nil {
+		options["user"] = s.user.Username()
+		if password, ok := s.user.Password(); ok {
+			options["pass"] = password
+		}
+	}
+	connect, _ := json.Marshal(options)
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\n", connect); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	conn.SetDeadline(time.Time{})
+
+	s.conn = conn
+	s.pongs = make(chan struct{}, 1)
+	go s.answerPings(conn, reader, s.pongs)
+	return nil
+}
+
+// answerPings replies to the server's keepalive PINGs, forwards PONGs and
+// reports protocol errors, until the connection is closed.
+func (s *NATSSink) answerPings(conn net.Conn, reader *bufio.Reader, pongs chan<- struct{}) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		switch line = strings.TrimSpace(line); {
+		case line == "PING":
+			s.mu.Lock()
+			fmt.Fprint(conn, "PONG\r\n")
+			s.mu.Unlock()
+		case line == "PONG":
+			select {
+			case pongs <- struct{}{}:
+			default:
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			fmt.Printf("⚠️  NATS error: %s\n", strings.TrimPrefix(line, "-ERR "))
+		}
+	}
+}
+
+// Close waits until the server has processed the published events, using a
+// PING round trip, and closes the connection.
+func (s *NATSSink) Close() error {
+	s.mu.Lock()
+	conn, pongs := s.conn, s.pongs
+	s.conn = nil
+	if conn == nil {
+		s.mu.Unlock()
+		return nil
+	}
+	conn.SetWriteDeadline(time.Now().Add(natsTimeout))
+	_, err := fmt.Fprint(conn, "PING\r\n")
+	s.mu.Unlock()
+
+	defer conn.Close()
+	if err != nil {
+		return fmt.Errorf("failed to flush NATS connection: %w", err)
+	}
+
+	select {
+	case <-pongs:
+		return nil
+	case <-time.After(natsTimeout):
+		return fmt.Errorf("NATS server didn't confirm the published events")
+	}
+}
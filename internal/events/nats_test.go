@@ -0,0 +1,88 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+)
+
+// natsServer accepts one connection, speaks enough of the NATS protocol
+// for a publisher and sends the received protocol lines on lines.
+func natsServer(t *testing.T) (addr string, lines <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	out := make(chan string, 10)
+	go func() {
+		defer close(out)
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			if line == "PING" {
+				conn.Write([]byte("PONG\r\n"))
+				continue
+			}
+			if strings.HasPrefix(line, "PUB ") {
+				payload, _ := reader.ReadString('\n')
+				line += " " + strings.TrimSpace(payload)
+			}
+			out <- line
+		}
+	}()
+	return listener.Addr().String(), out
+}
+
+func TestNATSSink(t *testing.T) {
+	addr, lines := natsServer(t)
+	sink, err := NewNATSSink("nats://deployer:secret@"+addr, "retention.events")
+	if err != nil {
+		t.Fatalf("NewNATSSink: %v", err)
+	}
+
+	if err := sink.Publish(deletion("v1")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	connect := <-lines
+	var options map[string]any
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(connect, "CONNECT ")), &options); err != nil || options["user"] != "deployer" || options["pass"] != "secret" {
+		t.Errorf("connected with %q", connect)
+	}
+
+	pub := strings.SplitN(<-lines, " ", 4)
+	if len(pub) != 4 || pub[1] != "retention.events" {
+		t.Fatalf("published %q", pub)
+	}
+	var event Event
+	if err := json.Unmarshal([]byte(pub[3]), &event); err != nil || event.Deletion.ComponentID != "app:v1" {
+		t.Errorf("published event %s", pub[3])
+	}
+}
+
+func TestNewNATSSinkInvalidURL(t *testing.T) {
+	for _, u := range []string{"http://nats.test", "nats://", "::"} {
+		if _, err := NewNATSSink(u, "events"); err == nil {
+			t.Errorf("NewNATSSink accepted %q", u)
+		}
+	}
+}
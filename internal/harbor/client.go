@@ -0,0 +1,308 @@
+package harbor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// defaultPageSize is the number of items requested per page.
+const defaultPageSize = 100
+
+// Client lists and deletes artifacts in Harbor, mapping them to the Nexus
+// repository and component types used by the policy engine.
+//
+// Harbor projects are repositories and each tag of an artifact is a
+// component, identified like a Docker reference ("project/app:1.0").
+// Untagged artifacts are identified by digest ("project/app@sha256:...").
+type Client struct {
+	baseURL    string
+	username   string
+	password   string
+	httpClient *http.Client
+	pageSize   int
+	// maxComponents stops listing a project after this many components
+	maxComponents int
+
+	// repoCredentials overrides the credentials for individual projects
+	repoCredentials map[string]credentials
+	mu              sync.Mutex
+}
+
+type credentials struct {
+	username string
+	password string
+}
+
+type project struct {
+	Name string `json:"name"`
+}
+
+type repository struct {
+	Name string `json:"name"`
+}
+
+type artifact struct {
+	Digest   string    `json:"digest"`
+	Size     int64     `json:"size"`
+	PushTime time.Time `json:"push_time"`
+	Tags     []tag     `json:"tags"`
+}
+
+type tag struct {
+	Name     string    `json:"name"`
+	PushTime time.Time `json:"push_time"`
+}
+
+// NewClient creates a Harbor client for the given base URL, e.g.
+// "https://harbor.example.com".
+func NewClient(baseURL, username, password string, timeout int) *Client {
+	return NewClientWithHTTP(baseURL, username, password, &http.Client{
+		Timeout: time.Duration(timeout) * time.Second,
+	})
+}
+
+// NewClientWithHTTP creates a client using the given HTTP client, e.g. one
+// pointed at an httptest server or with a custom transport.
+func NewClientWithHTTP(baseURL, username, password string, httpClient *http.Client) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		username:   username,
+		password:   password,
+		httpClient: httpClient,
+		pageSize:   defaultPageSize,
+	}
+}
+
+// SetPageSize sets the number of items requested per page.
+func (c *Client) SetPageSize(size int) {
+	c.pageSize = size
+}
+
+// SetMaxComponents limits GetComponents to the first max components of a
+// project (0 = no limit).
+func (c *Client) SetMaxComponents(max int) {
+	c.maxComponents = max
+}
+
+// SetRepositoryCredentials uses the given credentials instead of the
+// client's for requests that access the project.
+func (c *Client) SetRepositoryCredentials(project, username, password string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.repoCredentials == nil {
+		c.repoCredentials = make(map[string]credentials)
+	}
+	c.repoCredentials[project] = credentials{username: username, password: password}
+}
+
+func (c *Client) credentialsFor(project string) (string, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if creds, ok := c.repoCredentials[project]; ok {
+		return creds.username, creds.password
+	}
+	return c.username, c.password
+}
+
+func (c *Client) doRequest(method, path, project string) ([]byte, error) {
+	url := fmt.Sprintf("%s/api/v2.0%s", c.baseURL, path)
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.SetBasicAuth(c.credentialsFor(project))
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &nexus.APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return body, nil
+}
+
+// getPages requests all pages of a list endpoint, decoding each page with
+// decode. It stops at the first page with fewer than pageSize items, or
+// when decode returns done.
+func (c *Client) getPages(path, project string, decode func([]byte) (n int, done bool, err error)) error {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+
+	for page := 1; ; page++ {
+		body, err := c.doRequest("GET", fmt.Sprintf("%s%spage=%d&page_size=%d", path, sep, page, c.pageSize), project)
+		if err != nil {
+			return err
+		}
+
+		n, done, err := decode(body)
+		if err != nil {
+			return err
+		}
+		if done || n < c.pageSize {
+			return nil
+		}
+	}
+}
+
+// GetRepositories returns the Harbor projects as hosted Docker repositories.
+func (c *Client) GetRepositories() ([]nexus.Repository, error) {
+	var repos []nexus.Repository
+	err := c.getPages("/projects", "", func(body []byte) (int, bool, error) {
+		var projects []project
+		if err := json.Unmarshal(body, &projects); err != nil {
+			return 0, false, fmt.Errorf("failed to parse projects: %w", err)
+		}
+		for _, p := range projects {
+			repos = append(repos, nexus.Repository{Name: p.Name, Format: "docker", Type: "hosted"})
+		}
+		return len(projects), false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return repos, nil
+}
+
+// GetComponents returns a component per tag, and per untagged artifact, of
+// all repositories in a project.
+func (c *Client) GetComponents(projectName string) ([]nexus.Component, error) {
+	var repoNames []string
+	err := c.getPages(fmt.Sprintf("/projects/%s/repositories", url.PathEscape(projectName)), projectName, func(body []byte) (int, bool, error) {
+		var repos []repository
+		if err := json.Unmarshal(body, &repos); err != nil {
+			return 0, false, fmt.Errorf("failed to parse repositories: %w", err)
+		}
+		for _, repo := range repos {
+			repoNames = append(repoNames, repo.Name)
+		}
+		return len(repos), false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var components []nexus.Component
+	for _, repoName := range repoNames {
+		// Repository names include the project
+		image := strings.TrimPrefix(repoName, projectName+"/")
+
+		err := c.getPages(artifactsPath(projectName, image)+"?with_tag=true", projectName, func(body []byte) (int, bool, error) {
+			var artifacts []artifact
+			if err := json.Unmarshal(body, &artifacts); err != nil {
+				return 0, false, fmt.Errorf("failed to parse artifacts: %w", err)
+			}
+			for _, a := range artifacts {
+				components = append(components, toComponents(projectName, image, a)...)
+			}
+			return len(artifacts), c.capped(components), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if c.capped(components) {
+			components = components[:c.maxComponents]
+			break
+		}
+	}
+
+	return components, nil
+}
+
+// capped reports whether the listed components reached the maximum.
+func (c *Client) capped(components []nexus.Component) bool {
+	return c.maxComponents > 0 && len(components) >= c.maxComponents
+}
+
+// toComponents maps an artifact to a component per tag, or a single untagged
+// component.
+func toComponents(projectName, image string, a artifact) []nexus.Component {
+	asset := nexus.Asset{
+		Path:         image + "@" + a.Digest,
+		Repository:   projectName,
+		Format:       "docker",
+		LastModified: a.PushTime,
+		FileSize:     a.Size,
+		Checksum:     map[string]string{"sha256": strings.TrimPrefix(a.Digest, "sha256:")},
+	}
+	component := nexus.Component{
+		Repository: projectName,
+		Format:     "docker",
+		Name:       image,
+		Assets:     []nexus.Asset{asset},
+	}
+
+	if len(a.Tags) == 0 {
+		component.ID = projectName + "/" + image + "@" + a.Digest
+		component.LastModified = a.PushTime
+		return []nexus.Component{component}
+	}
+
+	components := make([]nexus.Component, 0, len(a.Tags))
+	for _, t := range a.Tags {
+		c := component
+		c.ID = projectName + "/" + image + ":" + t.Name
+		c.Version = t.Name
+		c.LastModified = t.PushTime
+		if c.LastModified.IsZero() {
+			c.LastModified = a.PushTime
+		}
+		components = append(components, c)
+	}
+	return components
+}
+
+// DeleteComponent deletes a tag, or an untagged artifact by digest.
+// Deleting a tag keeps the artifact; once untagged it can be removed with
+// delete_untagged.
+func (c *Client) DeleteComponent(componentID string) error {
+	projectName, ref, ok := strings.Cut(componentID, "/")
+	if !ok {
+		return fmt.Errorf("invalid component ID '%s'", componentID)
+	}
+
+	var path string
+	if image, digest, ok := strings.Cut(ref, "@"); ok {
+		path = fmt.Sprintf("%s/%s", artifactsPath(projectName, image), url.PathEscape(digest))
+	} else {
+		i := strings.LastIndex(ref, ":")
+		if i < 0 {
+			return fmt.Errorf("invalid component ID '%s'", componentID)
+		}
+		image, tagName := ref[:i], url.PathEscape(ref[i+1:])
+		path = fmt.Sprintf("%s/%s/tags/%s", artifactsPath(projectName, image), tagName, tagName)
+	}
+
+	_, err := c.doRequest("DELETE", path, projectName)
+	return err
+}
+
+// artifactsPath returns the artifacts endpoint of a repository. Slashes in
+// repository names must be double encoded.
+func artifactsPath(projectName, image string) string {
+	return fmt.Sprintf("/projects/%s/repositories/%s/artifacts",
+		url.PathEscape(projectName), url.PathEscape(url.PathEscape(image)))
+}
@@ -0,0 +1,156 @@
+package harbor
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// harborServer is a Harbor stub with the project "library" holding the
+// repositories "library/app" and "library/team/api". It records the
+// escaped path of every request.
+type harborServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []string
+}
+
+func newHarborServer(t *testing.T) *harborServer {
+	s := &harborServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.EscapedPath()+"?"+r.URL.RawQuery)
+		s.mu.Unlock()
+
+		page := r.URL.Query().Get("page")
+		switch path := r.URL.EscapedPath(); {
+		case r.Method == "DELETE":
+			w.WriteHeader(http.StatusOK)
+		case path == "/api/v2.0/projects":
+			// Two pages of two and one projects
+			if page == "1" {
+				w.Write([]byte(`[{"name": "library"}, {"name": "ci"}]`))
+			} else {
+				w.Write([]byte(`[{"name": "staging"}]`))
+			}
+		case page != "1":
+			// Listings fit on the first page
+			w.Write([]byte(`[]`))
+		case path == "/api/v2.0/projects/library/repositories":
+			w.Write([]byte(`[{"name": "library/app"}, {"name": "library/team/api"}]`))
+		case path == "/api/v2.0/projects/library/repositories/app/artifacts":
+			w.Write([]byte(`[
+				{"digest": "sha256:aaa", "size": 100, "push_time": "2024-01-15T10:00:00Z",
+				 "tags": [{"name": "1.0", "push_time": "2024-01-16T10:00:00Z"}, {"name": "latest"}]},
+				{"digest": "sha256:bbb", "size": 50, "push_time": "2024-01-14T10:00:00Z", "tags": []}
+			]`))
+		case path == "/api/v2.0/projects/library/repositories/team%252Fapi/artifacts":
+			w.Write([]byte(`[{"digest": "sha256:ccc", "size": 10, "push_time": "2024-01-13T10:00:00Z", "tags": [{"name": "2.0"}]}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *harborServer) client() *Client {
+	c := NewClientWithHTTP(s.URL, "admin", "secret", http.DefaultClient)
+	c.SetPageSize(2)
+	return c
+}
+
+func TestGetRepositoriesPages(t *testing.T) {
+	server := newHarborServer(t)
+	repos, err := server.client().GetRepositories()
+	if err != nil {
+		t.Fatalf("GetRepositories: %v", err)
+	}
+
+	var names []string
+	for _, repo := range repos {
+		if !repo.IsDockerHosted() {
+			t.Errorf("project %s isn't a hosted Docker repository", repo.Name)
+		}
+		names = append(names, repo.Name)
+	}
+	if strings.Join(names, ",") != "library,ci,staging" {
+		t.Errorf("projects %v, want library, ci and staging", names)
+	}
+}
+
+func TestGetComponents(t *testing.T) {
+	server := newHarborServer(t)
+	components, err := server.client().GetComponents("library")
+	if err != nil {
+		t.Fatalf("GetComponents: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, comp := range components {
+		got[comp.ID] = fmt.Sprintf("%s %s %s", comp.Name, comp.Version, comp.LastModified.Format(time.DateOnly))
+	}
+	want := map[string]string{
+		"library/app:1.0": "app 1.0 2024-01-16",
+		// Tags without a push time use the artifact's
+		"library/app:latest":     "app latest 2024-01-15",
+		"library/app@sha256:bbb": "app  2024-01-14",
+		"library/team/api:2.0":   "team/api 2.0 2024-01-13",
+	}
+	if len(got) != len(want) {
+		t.Errorf("components %v, want %v", got, want)
+	}
+	for id, w := range want {
+		if got[id] != w {
+			t.Errorf("component %s = %q, want %q", id, got[id], w)
+		}
+	}
+}
+
+func TestGetComponentsMaxComponents(t *testing.T) {
+	server := newHarborServer(t)
+	c := server.client()
+	c.SetMaxComponents(2)
+
+	components, err := c.GetComponents("library")
+	if err != nil {
+		t.Fatalf("GetComponents: %v", err)
+	}
+	if len(components) != 2 {
+		t.Errorf("listed %d components, want 2", len(components))
+	}
+	for _, request := range server.requests {
+		if strings.Contains(request, "team%252Fapi") {
+			t.Errorf("listed further repositories after the limit: %s", request)
+		}
+	}
+}
+
+func TestDeleteComponent(t *testing.T) {
+	tests := []struct {
+		id   string
+		path string
+	}{
+		{"library/app:1.0", "/api/v2.0/projects/library/repositories/app/artifacts/1.0/tags/1.0"},
+		{"library/team/api:2.0", "/api/v2.0/projects/library/repositories/team%252Fapi/artifacts/2.0/tags/2.0"},
+		{"library/app@sha256:bbb", "/api/v2.0/projects/library/repositories/app/artifacts/sha256:bbb"},
+	}
+
+	for _, tt := range tests {
+		server := newHarborServer(t)
+		if err := server.client().DeleteComponent(tt.id); err != nil {
+			t.Fatalf("DeleteComponent(%s): %v", tt.id, err)
+		}
+		if want := "DELETE " + tt.path + "?"; len(server.requests) != 1 || server.requests[0] != want {
+			t.Errorf("DeleteComponent(%s) sent %v, want %s", tt.id, server.requests, want)
+		}
+	}
+
+	if err := NewClient("http://harbor.test", "admin", "secret", 1).DeleteComponent("app"); err == nil {
+		t.Error("deleted a component ID without a project")
+	}
+}
@@ -1,64 +1,155 @@
 package logger
 
 import (
+	"crypto/sha256"
 	"encoding/csv"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"sync"
 	"time"
 )
 
+// hashColumn is the header of the checksum chain column
+const hashColumn = "Hash"
+
 type Logger struct {
+	path   string
+	opts   Options
 	file   *os.File
 	writer *csv.Writer
 	mu     sync.Mutex
+
+	// lastHash is the hash of the last row when hash chaining is enabled
+	lastHash string
+	// dryRun receives dry-run records when DryRunPath is set
+	dryRun *Logger
 }
 
 type DeletionRecord struct {
-	Timestamp   time.Time
-	Repository  string
-	ImageName   string
-	Tag         string
-	ComponentID string
-	Rule        string
-	DryRun      bool
+	ExecutionID string    `json:"execution_id"`
+	Timestamp   time.Time `json:"timestamp"`
+	Repository  string    `json:"repository"`
+	ImageName   string    `json:"image_name"`
+	Tag         string    `json:"tag"`
+	ComponentID string    `json:"component_id"`
+	Rule        string    `json:"rule"`
+	DryRun      bool      `json:"dry_run"`
+}
+
+// RunRecord describes the start or the end of a run.
+type RunRecord struct {
+	ExecutionID string    `json:"execution_id"`
+	Timestamp   time.Time `json:"timestamp"`
+	// Event is RunStarted or RunFinished
+	Event  string `json:"event"`
+	DryRun bool   `json:"dry_run"`
+	// Deleted, Kept and Failed are only set when the run finished
+	Deleted int `json:"deleted"`
+	Kept    int `json:"kept"`
+	Failed  int `json:"failed"`
+}
+
+// Run events.
+const (
+	RunStarted  = "started"
+	RunFinished = "finished"
+)
+
+// Options controls the format of the deletion log.
+type Options struct {
+	// HashChain appends a hash of each row chained to the previous row's hash
+	HashChain bool
+	// OmitHeader skips writing the CSV header to new files
+	OmitHeader bool
+	// MaxSize rotates the log once it reaches this many bytes (0 = never)
+	MaxSize int64
+	// Compress gzips rotated log files
+	Compress bool
+	// DryRunPath moves dry-run records to a separate log with the same
+	// format, written with DryRun=false so it can be diffed against the log
+	// of an execution
+	DryRunPath string
+	// Location is the timezone timestamps are written in (nil = as recorded)
+	Location *time.Location
 }
 
-func NewLogger(filepath string) (*Logger, error) {
+func NewLogger(filepath string, opts Options) (*Logger, error) {
+	l := &Logger{
+		path: filepath,
+		opts: opts,
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+
+	if opts.DryRunPath != "" {
+		dryRunOpts := opts
+		dryRunOpts.DryRunPath = ""
+		dryRun, err := NewLogger(opts.DryRunPath, dryRunOpts)
+		if err != nil {
+			l.Close()
+			return nil, err
+		}
+		l.dryRun = dryRun
+	}
+	return l, nil
+}
+
+// open opens the log file for appending, writing the header if it is new.
+func (l *Logger) open() error {
 	fileExists := false
-	if _, err := os.Stat(filepath); err == nil {
+	if _, err := os.Stat(l.path); err == nil {
 		fileExists = true
 	}
 
-	file, err := os.OpenFile(filepath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	l.lastHash = ""
+	if fileExists && l.opts.HashChain {
+		var err error
+		l.lastHash, err = readLastHash(l.path)
+		if err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open log file: %w", err)
+		return fmt.Errorf("failed to open log file: %w", err)
 	}
 
 	writer := csv.NewWriter(file)
 
 	// Write header if file is new
-	if !fileExists {
-		header := []string{"Timestamp", "Repository", "Image Name", "Tag", "Component ID", "Rule", "Dry Run"}
+	if !fileExists && !l.opts.OmitHeader {
+		header := []string{"Execution ID", "Timestamp", "Repository", "Image Name", "Tag", "Component ID", "Rule", "Dry Run"}
+		if l.opts.HashChain {
+			header = append(header, hashColumn)
+		}
 		if err := writer.Write(header); err != nil {
 			file.Close()
-			return nil, fmt.Errorf("failed to write CSV header: %w", err)
+			return fmt.Errorf("failed to write CSV header: %w", err)
 		}
 		writer.Flush()
 	}
 
-	return &Logger{
-		file:   file,
-		writer: writer,
-	}, nil
+	l.file = file
+	l.writer = writer
+	return nil
 }
 
 func (l *Logger) LogDeletion(record DeletionRecord) error {
+	if record.DryRun && l.dryRun != nil {
+		record.DryRun = false
+		return l.dryRun.LogDeletion(record)
+	}
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
 	row := []string{
-		record.Timestamp.Format(time.RFC3339),
+		record.ExecutionID,
+		l.formatTime(record.Timestamp),
 		record.Repository,
 		record.ImageName,
 		record.Tag,
@@ -67,18 +158,130 @@ func (l *Logger) LogDeletion(record DeletionRecord) error {
 		fmt.Sprintf("%t", record.DryRun),
 	}
 
+	if l.opts.HashChain {
+		l.lastHash = chainHash(l.lastHash, row)
+		row = append(row, l.lastHash)
+	}
+
 	if err := l.writer.Write(row); err != nil {
 		return fmt.Errorf("failed to write log entry: %w", err)
 	}
 
 	l.writer.Flush()
-	return l.writer.Error()
+	if err := l.writer.Error(); err != nil {
+		return err
+	}
+
+	if l.opts.MaxSize > 0 {
+		return l.rotateIfNeeded()
+	}
+	return nil
+}
+
+// formatTime formats a timestamp as RFC 3339 in the configured timezone.
+func (l *Logger) formatTime(t time.Time) string {
+	if l.opts.Location != nil {
+		t = t.In(l.opts.Location)
+	}
+	return t.Format(time.RFC3339)
 }
 
 func (l *Logger) Close() error {
+	if l.dryRun != nil {
+		l.dryRun.Close()
+	}
+
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
 	l.writer.Flush()
 	return l.file.Close()
 }
+
+// chainHash hashes a row together with the hash of the previous row.
+func chainHash(prevHash string, row []string) string {
+	h := sha256.New()
+	h.Write([]byte(prevHash))
+	for _, field := range row {
+		h.Write([]byte{0})
+		h.Write([]byte(field))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// readLastHash returns the hash of the last row in an existing chained log.
+func readLastHash(filepath string) (string, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	if header[len(header)-1] != hashColumn {
+		return "", fmt.Errorf("log file %s has no %s column, use a new log file for hash chaining", filepath, hashColumn)
+	}
+
+	lastHash := ""
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read log entry: %w", err)
+		}
+		lastHash = row[len(row)-1]
+	}
+
+	return lastHash, nil
+}
+
+// VerifyLog validates the checksum chain of a log file and returns the number
+// of verified rows. It fails on the first row whose hash doesn't match.
+func VerifyLog(filepath string) (int, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	if header[len(header)-1] != hashColumn {
+		return 0, fmt.Errorf("log file has no %s column", hashColumn)
+	}
+
+	prevHash := ""
+	count := 0
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, fmt.Errorf("failed to read log entry: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		fields, hash := row[:len(row)-1], row[len(row)-1]
+		if expected := chainHash(prevHash, fields); hash != expected {
+			return count, fmt.Errorf("hash mismatch on line %d: log has been modified", line)
+		}
+
+		prevHash = hash
+		count++
+	}
+
+	return count, nil
+}
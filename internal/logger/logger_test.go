@@ -0,0 +1,197 @@
+package logger
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testRecord returns a deletion record of the tag.
+func testRecord(tag string) DeletionRecord {
+	return DeletionRecord{
+		ExecutionID: "run-1",
+		Timestamp:   time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC),
+		Repository:  "docker-hosted",
+		ImageName:   "app",
+		Tag:         tag,
+		ComponentID: "app:" + tag,
+		Rule:        "all",
+	}
+}
+
+// readRows returns the rows of a CSV log.
+func readRows(t *testing.T, path string) [][]string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	defer file.Close()
+
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	return rows
+}
+
+// writeLog logs the tags to a new logger on path and closes it.
+func writeLog(t *testing.T, path string, opts Options, tags ...string) {
+	t.Helper()
+	l, err := NewLogger(path, opts)
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	for _, tag := range tags {
+		if err := l.LogDeletion(testRecord(tag)); err != nil {
+			t.Fatalf("LogDeletion: %v", err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestLoggerHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   Options
+		header bool
+	}{
+		{name: "header", header: true},
+		{name: "omitted", opts: Options{OmitHeader: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "deletion_log.csv")
+			// Reopening an existing log doesn't repeat the header
+			writeLog(t, path, tt.opts, "v1")
+			writeLog(t, path, tt.opts, "v2")
+
+			rows := readRows(t, path)
+			want := 2
+			if tt.header {
+				want++
+				if rows[0][0] != "Execution ID" {
+					t.Errorf("first row %v, want the header", rows[0])
+				}
+			}
+			if len(rows) != want {
+				t.Fatalf("log has %d rows, want %d: %v", len(rows), want, rows)
+			}
+			if last := rows[len(rows)-1]; last[4] != "v2" || last[1] != "2024-01-15T10:30:00Z" || last[7] != "false" {
+				t.Errorf("unexpected row %v", last)
+			}
+		})
+	}
+}
+
+func TestSeparateDryRunLog(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "deletion_log.csv")
+	dryRunPath := filepath.Join(dir, "deletion_log.dryrun.csv")
+
+	l, err := NewLogger(path, Options{DryRunPath: dryRunPath})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	predicted := testRecord("v1")
+	predicted.DryRun = true
+	for _, record := range []DeletionRecord{predicted, testRecord("v1")} {
+		if err := l.LogDeletion(record); err != nil {
+			t.Fatalf("LogDeletion: %v", err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// The predicted row equals the actual one, DryRun included
+	rows, dryRunRows := readRows(t, path), readRows(t, dryRunPath)
+	if len(rows) != 2 || len(dryRunRows) != 2 {
+		t.Fatalf("logged %d and %d rows, want a header and one row each", len(rows), len(dryRunRows))
+	}
+	if !reflect.DeepEqual(rows, dryRunRows) {
+		t.Errorf("dry-run log %v differs from log %v", dryRunRows, rows)
+	}
+}
+
+func TestLoggerLocation(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "deletion_log.csv")
+	writeLog(t, path, Options{Location: loc}, "v1")
+
+	if got := readRows(t, path)[1][1]; got != "2024-01-15T05:30:00-05:00" {
+		t.Errorf("timestamp %s, want 2024-01-15T05:30:00-05:00", got)
+	}
+}
+
+func TestVerifyLog(t *testing.T) {
+	tests := []struct {
+		name string
+		// edit modifies the rows of the log before verification
+		edit  func(rows [][]string)
+		count int
+		err   string
+	}{
+		{name: "intact", count: 4},
+		{name: "modified tag", edit: func(rows [][]string) { rows[2][4] = "v9" }, count: 1, err: "line 3"},
+		{name: "modified hash", edit: func(rows [][]string) { rows[3][8] = rows[2][8] }, count: 2, err: "line 4"},
+		{name: "removed row", edit: func(rows [][]string) { copy(rows[2:], rows[3:]); rows[4] = nil }, count: 1, err: "line 3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "deletion_log.csv")
+			// Reopening the log continues its chain
+			writeLog(t, path, Options{HashChain: true}, "v1", "v2")
+			writeLog(t, path, Options{HashChain: true}, "v3", "v4")
+
+			if tt.edit != nil {
+				rows := readRows(t, path)
+				tt.edit(rows)
+				file, err := os.Create(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				w := csv.NewWriter(file)
+				for _, row := range rows {
+					if row != nil {
+						w.Write(row)
+					}
+				}
+				w.Flush()
+				file.Close()
+			}
+
+			count, err := VerifyLog(path)
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("VerifyLog: %v", err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("VerifyLog error = %v, want one naming %s", err, tt.err)
+			}
+			if count != tt.count {
+				t.Errorf("verified %d rows, want %d", count, tt.count)
+			}
+		})
+	}
+}
+
+func TestVerifyLogWithoutHashes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deletion_log.csv")
+	writeLog(t, path, Options{}, "v1")
+
+	if _, err := VerifyLog(path); err == nil {
+		t.Error("verified a log without hashes")
+	}
+}
@@ -0,0 +1,29 @@
+package logger
+
+import "sync"
+
+// MemoryLogger keeps deletion records in memory instead of writing a file.
+type MemoryLogger struct {
+	mu      sync.Mutex
+	records []DeletionRecord
+}
+
+func NewMemoryLogger() *MemoryLogger {
+	return &MemoryLogger{}
+}
+
+func (m *MemoryLogger) LogDeletion(record DeletionRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.records = append(m.records, record)
+	return nil
+}
+
+// Records returns a copy of the logged records.
+func (m *MemoryLogger) Records() []DeletionRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]DeletionRecord(nil), m.records...)
+}
@@ -0,0 +1,14 @@
+package logger
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// RedactImageName replaces an image name with a short hash of it. The same
+// name always yields the same hash, so deletions of an image can still be
+// correlated.
+func RedactImageName(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
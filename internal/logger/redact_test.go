@@ -0,0 +1,19 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactImageName(t *testing.T) {
+	redacted := RedactImageName("team/secret-project")
+	if redacted != RedactImageName("team/secret-project") {
+		t.Error("same name redacted to different hashes")
+	}
+	if redacted == RedactImageName("team/other-project") {
+		t.Error("different names redacted to the same hash")
+	}
+	if !strings.HasPrefix(redacted, "sha256:") || len(redacted) != len("sha256:")+16 || strings.Contains(redacted, "secret") {
+		t.Errorf("redacted name %q", redacted)
+	}
+}
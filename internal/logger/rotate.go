@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// compressLog compresses rotated logs; tests replace it to simulate
+// failures.
+var compressLog = compressFile
+
+// rotateIfNeeded moves the log aside once it reaches the maximum size and
+// starts a new file. A hash chain restarts in the new file. The log is
+// reopened whatever fails, so a failed rotation never stops later writes.
+func (l *Logger) rotateIfNeeded() (err error) {
+	info, err := l.file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	if info.Size() < l.opts.MaxSize {
+		return nil
+	}
+
+	closeErr := l.file.Close()
+	defer func() {
+		if openErr := l.open(); err == nil {
+			err = openErr
+		}
+	}()
+	if closeErr != nil {
+		return fmt.Errorf("failed to close log file: %w", closeErr)
+	}
+
+	backup := rotatedName(l.path, time.Now(), 0)
+	for i := 1; fileExists(backup) || fileExists(backup+".gz"); i++ {
+		backup = rotatedName(l.path, time.Now(), i)
+	}
+	if err := os.Rename(l.path, backup); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	// A rotated log that can't be compressed is kept as is
+	if l.opts.Compress {
+		if err := compressLog(backup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rotatedName returns the backup name for a log, e.g.
+// deletion_log-20240115T103000.csv. A non-zero seq disambiguates rotations
+// within the same second.
+func rotatedName(path string, t time.Time, seq int) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	stamp := t.Format("20060102T150405")
+	if seq > 0 {
+		stamp = fmt.Sprintf("%s-%d", stamp, seq)
+	}
+	return fmt.Sprintf("%s-%s%s", base, stamp, ext)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// compressFile gzips path to path.gz and removes the original.
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open rotated log: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path + ".gz")
+	if err != nil {
+		return fmt.Errorf("failed to create compressed log: %w", err)
+	}
+
+	gz := gzip.NewWriter(dst)
+	gz.Name = filepath.Base(path)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return fmt.Errorf("failed to compress rotated log: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return fmt.Errorf("failed to compress rotated log: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return fmt.Errorf("failed to write compressed log: %w", err)
+	}
+
+	src.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatedName(t *testing.T) {
+	at := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	if got, want := rotatedName("logs/deletion_log.csv", at, 0), "logs/deletion_log-20240115T103000.csv"; got != want {
+		t.Errorf("rotatedName = %s, want %s", got, want)
+	}
+	if got, want := rotatedName("deletion_log.csv", at, 2), "deletion_log-20240115T103000-2.csv"; got != want {
+		t.Errorf("rotatedName = %s, want %s", got, want)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	tests := []struct {
+		name     string
+		compress bool
+		suffix   string
+	}{
+		{name: "plain", suffix: ".csv"},
+		{name: "compressed", compress: true, suffix: ".csv.gz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "deletion_log.csv")
+			// Every row reaches the maximum size
+			writeLog(t, path, Options{MaxSize: 1, Compress: tt.compress}, "v1", "v2", "v3")
+
+			rotated, err := filepath.Glob(filepath.Join(dir, "deletion_log-*"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rotated) != 3 {
+				t.Fatalf("rotated %v, want 3 files", rotated)
+			}
+
+			var tags []string
+			for _, name := range rotated {
+				if !strings.HasSuffix(name, tt.suffix) {
+					t.Errorf("rotated log %s, want suffix %s", name, tt.suffix)
+				}
+				rows := readRotated(t, name)
+				if len(rows) != 2 || rows[0][0] != "Execution ID" {
+					t.Fatalf("rotated log %s has rows %v, want a header and a deletion", name, rows)
+				}
+				tags = append(tags, rows[1][4])
+			}
+			sort.Strings(tags)
+			if strings.Join(tags, ",") != "v1,v2,v3" {
+				t.Errorf("rotated logs hold %v, want v1, v2 and v3", tags)
+			}
+
+			// The current log was started anew after the last rotation
+			if rows := readRows(t, path); len(rows) != 1 {
+				t.Errorf("current log has rows %v, want only the header", rows)
+			}
+		})
+	}
+}
+
+// readRotated returns the rows of a rotated log, decompressing gzipped logs.
+func readRotated(t *testing.T, path string) [][]string {
+	t.Helper()
+	if !strings.HasSuffix(path, ".gz") {
+		return readRows(t, path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open rotated log: %v", err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("decompress rotated log: %v", err)
+	}
+	rows, err := csv.NewReader(gz).ReadAll()
+	if err != nil {
+		t.Fatalf("read rotated log: %v", err)
+	}
+	return rows
+}
+
+func TestRotationSurvivesCompressionFailure(t *testing.T) {
+	failures := 1
+	compressLog = func(path string) error {
+		if failures > 0 {
+			failures--
+			return errors.New("disk full")
+		}
+		return compressFile(path)
+	}
+	defer func() { compressLog = compressFile }()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "deletion_log.csv")
+	l, err := NewLogger(path, Options{MaxSize: 1, Compress: true})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	defer l.Close()
+
+	if err := l.LogDeletion(testRecord("v1")); err == nil {
+		t.Error("LogDeletion didn't report the failed compression")
+	}
+	// The log was reopened, so later deletions are still logged
+	for _, tag := range []string{"v2", "v3"} {
+		if err := l.LogDeletion(testRecord(tag)); err != nil {
+			t.Fatalf("LogDeletion after a failed compression: %v", err)
+		}
+	}
+
+	plain, _ := filepath.Glob(filepath.Join(dir, "deletion_log-*.csv"))
+	compressed, _ := filepath.Glob(filepath.Join(dir, "deletion_log-*.csv.gz"))
+	if len(plain) != 1 || len(compressed) != 2 {
+		t.Fatalf("rotated %v and %v, want the uncompressed log of v1 and two compressed logs", plain, compressed)
+	}
+	if rows := readRows(t, plain[0]); len(rows) != 2 || rows[1][4] != "v1" {
+		t.Errorf("uncompressed log has rows %v, want v1", rows)
+	}
+}
@@ -2,10 +2,12 @@ package nexus
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -14,31 +16,114 @@ type Client struct {
 	username   string
 	password   string
 	httpClient *http.Client
+	// apiKey is sent in the apiKeyHeader header instead of basic auth
+	apiKey       string
+	apiKeyHeader string
+	// maxComponents stops listing a repository after this many components
+	maxComponents int
+
+	// repoCredentials overrides the credentials for individual repositories
+	repoCredentials map[string]credentials
+	// componentRepos maps listed component IDs to their repository, so
+	// deletions use that repository's credentials
+	componentRepos map[string]string
+	mu             sync.Mutex
+}
+
+type credentials struct {
+	username string
+	password string
 }
 
 type Repository struct {
 	Name   string `json:"name"`
 	Format string `json:"format"`
 	Type   string `json:"type"`
+	// Online is only reported by some Nexus versions; nil means online
+	Online *bool `json:"online"`
+}
+
+// IsOnline reports whether the repository is online. Nexus tracks online
+// status per repository, so it applies to all of its components.
+func (r Repository) IsOnline() bool {
+	return r.Online == nil || *r.Online
+}
+
+// IsDockerHosted reports whether the repository can be cleaned by this tool.
+// Proxy and group repositories don't own their components.
+func (r Repository) IsDockerHosted() bool {
+	return r.Format == "docker" && r.Type == "hosted"
+}
+
+// IsMavenHosted reports whether the repository is a hosted Maven repository.
+func (r Repository) IsMavenHosted() bool {
+	return r.Format == "maven2" && r.Type == "hosted"
 }
 
 type Component struct {
-	ID         string    `json:"id"`
-	Repository string    `json:"repository"`
-	Format     string    `json:"format"`
-	Group      string    `json:"group"`
-	Name       string    `json:"name"`
-	Version    string    `json:"version"`
-	Assets     []Asset   `json:"assets"`
+	ID         string  `json:"id"`
+	Repository string  `json:"repository"`
+	Format     string  `json:"format"`
+	Group      string  `json:"group"`
+	Name       string  `json:"name"`
+	Version    string  `json:"version"`
+	Assets     []Asset `json:"assets"`
+	// LastModified is only present in some Nexus responses
+	LastModified time.Time `json:"lastModified"`
 }
 
 type Asset struct {
-	DownloadURL  string    `json:"downloadUrl"`
-	Path         string    `json:"path"`
-	ID           string    `json:"id"`
-	Repository   string    `json:"repository"`
-	Format       string    `json:"format"`
-	LastModified time.Time `json:"lastModified"`
+	DownloadURL  string            `json:"downloadUrl"`
+	Path         string            `json:"path"`
+	ID           string            `json:"id"`
+	Repository   string            `json:"repository"`
+	Format       string            `json:"format"`
+	LastModified time.Time         `json:"lastModified"`
+	FileSize     int64             `json:"fileSize"`
+	Checksum     map[string]string `json:"checksum"`
+	BlobStore    string            `json:"blobStoreName"`
+	BlobCreated  time.Time         `json:"blobCreated"`
+	BlobUpdated  time.Time         `json:"blobUpdated"`
+	// LastDownloaded is zero for assets that were never downloaded
+	LastDownloaded time.Time `json:"lastDownloaded"`
+	// DownloadCount is only reported by some Nexus versions
+	DownloadCount int64 `json:"downloadCount"`
+	// Attributes holds all fields of the asset as returned by Nexus,
+	// including format-specific ones like "maven2"
+	Attributes map[string]any `json:"-"`
+}
+
+// UnmarshalJSON decodes an asset, keeping all of its fields as attributes.
+func (a *Asset) UnmarshalJSON(data []byte) error {
+	type plain Asset
+	if err := json.Unmarshal(data, (*plain)(a)); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &a.Attributes)
+}
+
+// Attribute returns the value at a dot-separated attribute path, e.g.
+// "maven2.extension". Objects and missing paths aren't found.
+func (a Asset) Attribute(path string) (string, bool) {
+	var value any = a.Attributes
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return "", false
+		}
+		if value, ok = object[key]; !ok {
+			return "", false
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case map[string]any, nil:
+		return "", false
+	default:
+		return fmt.Sprint(v), true
+	}
 }
 
 type ComponentPage struct {
@@ -46,31 +131,124 @@ type ComponentPage struct {
 	ContinuationToken string      `json:"continuationToken"`
 }
 
+type AssetPage struct {
+	Items             []Asset `json:"items"`
+	ContinuationToken string  `json:"continuationToken"`
+}
+
 type RepositoryPage struct {
 	Items             []Repository `json:"items"`
 	ContinuationToken string       `json:"continuationToken"`
 }
 
+// pageTokens holds the continuation tokens of a listing. A buggy server
+// returning a token again would otherwise make the listing loop forever.
+type pageTokens map[string]bool
+
+// next records the token of the next page, failing if it was seen before.
+func (s pageTokens) next(listing, token string) error {
+	if s[token] {
+		return fmt.Errorf("listing %s returned continuation token '%s' again after %d pages, aborting to avoid an endless loop", listing, token, len(s)+1)
+	}
+	s[token] = true
+	return nil
+}
+
+// APIError is returned when Nexus responds with a non-2xx status.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Body)
+}
+
 func NewClient(baseURL, username, password string, timeout int) *Client {
+	return NewClientWithHTTP(baseURL, username, password, &http.Client{
+		Timeout: time.Duration(timeout) * time.Second,
+	})
+}
+
+// NewClientWithHTTP creates a client using the given HTTP client, e.g. one
+// pointed at an httptest server or with a custom transport.
+func NewClientWithHTTP(baseURL, username, password string, httpClient *http.Client) *Client {
 	return &Client{
-		baseURL:  strings.TrimSuffix(baseURL, "/"),
-		username: username,
-		password: password,
-		httpClient: &http.Client{
-			Timeout: time.Duration(timeout) * time.Second,
-		},
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		username:   username,
+		password:   password,
+		httpClient: httpClient,
+	}
+}
+
+// SetMaxComponents limits GetComponents to the first max components of a
+// repository (0 = no limit). Nexus has no page size parameter, so this is
+// the only way to bound the requests made for large repositories.
+func (c *Client) SetMaxComponents(max int) {
+	c.maxComponents = max
+}
+
+// SetAPIKey authenticates with the key in the given header, e.g.
+// "X-Nexus-ApiKey", instead of the client's username and password.
+// Repositories with their own credentials still use basic auth.
+func (c *Client) SetAPIKey(header, key string) {
+	c.apiKeyHeader = header
+	c.apiKey = key
+}
+
+// SetRepositoryCredentials uses the given credentials instead of the
+// client's for requests that access the repository.
+func (c *Client) SetRepositoryCredentials(repository, username, password string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.repoCredentials == nil {
+		c.repoCredentials = make(map[string]credentials)
+	}
+	c.repoCredentials[repository] = credentials{username: username, password: password}
+}
+
+// credentialsFor returns the credentials for a repository, falling back to
+// the client's credentials for an empty or unknown repository. ok is false
+// for the fallback.
+func (c *Client) credentialsFor(repository string) (username, password string, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if creds, ok := c.repoCredentials[repository]; ok {
+		return creds.username, creds.password, true
+	}
+	return c.username, c.password, false
+}
+
+// authenticate sets the credentials for a request accessing the repository.
+func (c *Client) authenticate(req *http.Request, repository string) {
+	username, password, ok := c.credentialsFor(repository)
+	if !ok && c.apiKey != "" {
+		req.Header.Set(c.apiKeyHeader, c.apiKey)
+		return
 	}
+	req.SetBasicAuth(username, password)
 }
 
-func (c *Client) doRequest(method, path string) ([]byte, error) {
+func (c *Client) doRequest(method, path, repository string) ([]byte, error) {
+	return c.doRequestWithBody(method, path, repository, nil)
+}
+
+// doRequestWithBody sends a request with a JSON payload, or none if payload
+// is nil.
+func (c *Client) doRequestWithBody(method, path, repository string, payload io.Reader) ([]byte, error) {
 	url := fmt.Sprintf("%s%s", c.baseURL, path)
-	req, err := http.NewRequest(method, url, nil)
+	req, err := http.NewRequest(method, url, payload)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.SetBasicAuth(c.username, c.password)
+	c.authenticate(req, repository)
 	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -84,79 +262,232 @@ func (c *Client) doRequest(method, path string) ([]byte, error) {
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	return body, nil
 }
 
+func (c *Client) GetRepositories() ([]Repository, error) {
+	body, err := c.doRequest("GET", "/service/rest/v1/repositories", "")
+	if err != nil {
+		return nil, err
+	}
+
+	// This endpoint returns a plain list and doesn't use continuation tokens
+	var repos []Repository
+	if err := json.Unmarshal(body, &repos); err != nil {
+		return nil, fmt.Errorf("failed to parse repositories: %w", err)
+	}
+
+	return repos, nil
+}
+
 func (c *Client) GetDockerRepositories() ([]Repository, error) {
+	repos, err := c.GetRepositories()
+	if err != nil {
+		return nil, err
+	}
+
 	var allRepos []Repository
+	for _, repo := range repos {
+		if repo.IsDockerHosted() {
+			allRepos = append(allRepos, repo)
+		}
+	}
+
+	return allRepos, nil
+}
+
+func (c *Client) GetComponents(repository string) ([]Component, error) {
+	var allComponents []Component
+	err := c.IterComponents(repository, func(comp Component) error {
+		allComponents = append(allComponents, comp)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allComponents, nil
+}
+
+// ErrStopIteration is returned by the function passed to IterComponents to
+// stop the listing early. IterComponents itself then returns nil.
+var ErrStopIteration = errors.New("stop iteration")
+
+// IterComponents calls fn with every component of a repository in listing
+// order, requesting pages as they are needed, so at most one page is held.
+// Listing stops without requesting further pages when fn returns an error;
+// ErrStopIteration ends it successfully, other errors are returned.
+func (c *Client) IterComponents(repository string, fn func(comp Component) error) error {
+	err := c.EachComponentPage(repository, func(page []Component) error {
+		for _, comp := range page {
+			if err := fn(comp); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, ErrStopIteration) {
+		return nil
+	}
+	return err
+}
+
+// EachComponentPage lists the components of a repository page by page,
+// calling fn with each page as it is received, so large repositories can be
+// processed without holding all components. Listing stops at the first
+// error of fn, which is returned.
+func (c *Client) EachComponentPage(repository string, fn func(page []Component) error) error {
+	listed := 0
 	continuationToken := ""
+	seen := make(pageTokens)
 
 	for {
-		path := "/service/rest/v1/repositories"
+		path := fmt.Sprintf("/service/rest/v1/components?repository=%s", repository)
 		if continuationToken != "" {
-			path += "?continuationToken=" + continuationToken
+			path += "&continuationToken=" + continuationToken
 		}
 
-		body, err := c.doRequest("GET", path)
+		body, err := c.doRequest("GET", path, repository)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		var repos []Repository
-		if err := json.Unmarshal(body, &repos); err != nil {
-			return nil, fmt.Errorf("failed to parse repositories: %w", err)
+		var page ComponentPage
+		if err := json.Unmarshal(body, &page); err != nil {
+			return fmt.Errorf("failed to parse components: %w", err)
 		}
 
-		// Filter for docker hosted repositories
-		for _, repo := range repos {
-			if repo.Format == "docker" && repo.Type == "hosted" {
-				allRepos = append(allRepos, repo)
-			}
+		items := page.Items
+		if c.maxComponents > 0 && listed+len(items) >= c.maxComponents {
+			items = items[:c.maxComponents-listed]
+		}
+		listed += len(items)
+		c.rememberComponents(repository, items)
+		if err := fn(items); err != nil {
+			return err
 		}
 
-		// Check if there are more pages (this endpoint doesn't use continuation token)
-		break
+		if page.ContinuationToken == "" || (c.maxComponents > 0 && listed >= c.maxComponents) {
+			return nil
+		}
+		if err := seen.next("components of "+repository, page.ContinuationToken); err != nil {
+			return err
+		}
+		continuationToken = page.ContinuationToken
 	}
+}
 
-	return allRepos, nil
+// GetComponent fetches a single component with its assets. Details can
+// hold asset metadata, like timestamps and sizes, that listings leave out.
+func (c *Client) GetComponent(componentID string) (Component, error) {
+	path := fmt.Sprintf("/service/rest/v1/components/%s", componentID)
+	body, err := c.doRequest("GET", path, c.componentRepository(componentID))
+	if err != nil {
+		return Component{}, err
+	}
+
+	var comp Component
+	if err := json.Unmarshal(body, &comp); err != nil {
+		return Component{}, fmt.Errorf("failed to parse component: %w", err)
+	}
+	return comp, nil
 }
 
-func (c *Client) GetComponents(repository string) ([]Component, error) {
-	var allComponents []Component
+func (c *Client) DeleteComponent(componentID string) error {
+	path := fmt.Sprintf("/service/rest/v1/components/%s", componentID)
+	_, err := c.doRequest("DELETE", path, c.componentRepository(componentID))
+	return err
+}
+
+// GetAssets lists all assets of a repository, including assets that don't
+// belong to a component.
+func (c *Client) GetAssets(repository string) ([]Asset, error) {
+	var allAssets []Asset
 	continuationToken := ""
+	seen := make(pageTokens)
 
 	for {
-		path := fmt.Sprintf("/service/rest/v1/components?repository=%s", repository)
+		path := fmt.Sprintf("/service/rest/v1/assets?repository=%s", repository)
 		if continuationToken != "" {
 			path += "&continuationToken=" + continuationToken
 		}
 
-		body, err := c.doRequest("GET", path)
+		body, err := c.doRequest("GET", path, repository)
 		if err != nil {
 			return nil, err
 		}
 
-		var page ComponentPage
+		var page AssetPage
 		if err := json.Unmarshal(body, &page); err != nil {
-			return nil, fmt.Errorf("failed to parse components: %w", err)
+			return nil, fmt.Errorf("failed to parse assets: %w", err)
 		}
 
-		allComponents = append(allComponents, page.Items...)
+		allAssets = append(allAssets, page.Items...)
+		c.rememberAssets(repository, page.Items)
 
 		if page.ContinuationToken == "" {
 			break
 		}
+		if err := seen.next("assets of "+repository, page.ContinuationToken); err != nil {
+			return nil, err
+		}
 		continuationToken = page.ContinuationToken
 	}
 
-	return allComponents, nil
+	return allAssets, nil
 }
 
-func (c *Client) DeleteComponent(componentID string) error {
-	path := fmt.Sprintf("/service/rest/v1/components/%s", componentID)
-	_, err := c.doRequest("DELETE", path)
+// DeleteAsset deletes a single asset.
+func (c *Client) DeleteAsset(assetID string) error {
+	path := fmt.Sprintf("/service/rest/v1/assets/%s", assetID)
+	_, err := c.doRequest("DELETE", path, c.componentRepository(assetID))
 	return err
 }
+
+// rememberComponents records the repository of listed components when
+// credentials are overridden for it.
+func (c *Client) rememberComponents(repository string, components []Component) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.repoCredentials[repository]; !ok {
+		return
+	}
+	if c.componentRepos == nil {
+		c.componentRepos = make(map[string]string)
+	}
+	for _, comp := range components {
+		c.componentRepos[comp.ID] = repository
+		for _, asset := range comp.Assets {
+			c.componentRepos[asset.ID] = repository
+		}
+	}
+}
+
+// rememberAssets records the repository of listed assets when credentials
+// are overridden for it.
+func (c *Client) rememberAssets(repository string, assets []Asset) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.repoCredentials[repository]; !ok {
+		return
+	}
+	if c.componentRepos == nil {
+		c.componentRepos = make(map[string]string)
+	}
+	for _, asset := range assets {
+		c.componentRepos[asset.ID] = repository
+	}
+}
+
+// componentRepository returns the repository a component or asset was
+// listed from, or "" if it is unknown.
+func (c *Client) componentRepository(componentID string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.componentRepos[componentID]
+}
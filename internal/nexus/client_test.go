@@ -0,0 +1,286 @@
+package nexus
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// authServer is a Nexus stub listing one component per repository, named
+// after the repository, and recording the user of each request.
+type authServer struct {
+	*httptest.Server
+	mu    sync.Mutex
+	users map[string]string
+}
+
+func newAuthServer(t *testing.T) *authServer {
+	s := &authServer{users: make(map[string]string)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _, ok := r.BasicAuth()
+		if !ok {
+			user = "key:" + r.Header.Get("X-Nexus-ApiKey")
+		}
+		s.mu.Lock()
+		s.users[r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery] = user
+		s.mu.Unlock()
+
+		switch {
+		case r.URL.Path == "/service/rest/v1/repositories":
+			w.Write([]byte("[]"))
+		case r.URL.Path == "/service/rest/v1/components" && r.Method == "GET":
+			repo := r.URL.Query().Get("repository")
+			json.NewEncoder(w).Encode(ComponentPage{Items: []Component{{ID: repo + "-1", Repository: repo}}})
+		case r.Method == "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// user returns the user of the request, e.g. "GET /service/rest/v1/repositories?".
+func (s *authServer) user(request string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.users[request]
+}
+
+func TestRepositoryCredentials(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiKey bool
+		client string
+	}{
+		{name: "basic auth", client: "admin"},
+		{name: "api key", apiKey: true, client: "key:secret-key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newAuthServer(t)
+			c := NewClient(server.URL, "admin", "secret", 5)
+			if tt.apiKey {
+				c.SetAPIKey("X-Nexus-ApiKey", "secret-key")
+			}
+			c.SetRepositoryCredentials("docker-secure", "deployer", "pass")
+
+			if _, err := c.GetRepositories(); err != nil {
+				t.Fatalf("GetRepositories: %v", err)
+			}
+			for _, repo := range []string{"docker-hosted", "docker-secure"} {
+				components, err := c.GetComponents(repo)
+				if err != nil {
+					t.Fatalf("GetComponents: %v", err)
+				}
+				if err := c.DeleteComponent(components[0].ID); err != nil {
+					t.Fatalf("DeleteComponent: %v", err)
+				}
+			}
+
+			want := map[string]string{
+				"GET /service/rest/v1/repositories?":                       tt.client,
+				"GET /service/rest/v1/components?repository=docker-hosted": tt.client,
+				"DELETE /service/rest/v1/components/docker-hosted-1?":      tt.client,
+				"GET /service/rest/v1/components?repository=docker-secure": "deployer",
+				"DELETE /service/rest/v1/components/docker-secure-1?":      "deployer",
+			}
+			for request, user := range want {
+				if got := server.user(request); got != user {
+					t.Errorf("%s sent as %q, want %q", strings.TrimSuffix(request, "?"), got, user)
+				}
+			}
+		})
+	}
+}
+
+func TestGetAssetsPages(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+		switch {
+		case r.Method == "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Query().Get("continuationToken") == "":
+			json.NewEncoder(w).Encode(AssetPage{Items: []Asset{{ID: "a1", Path: "app/1.jar"}, {ID: "a2", Path: "app/2.jar"}}, ContinuationToken: "page2"})
+		default:
+			json.NewEncoder(w).Encode(AssetPage{Items: []Asset{{ID: "a3", Path: "app/3.jar"}}})
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "admin", "secret", 5)
+	assets, err := c.GetAssets("maven-releases")
+	if err != nil {
+		t.Fatalf("GetAssets: %v", err)
+	}
+	var ids []string
+	for _, asset := range assets {
+		ids = append(ids, asset.ID)
+	}
+	if strings.Join(ids, ",") != "a1,a2,a3" {
+		t.Errorf("assets %v, want a1,a2,a3", ids)
+	}
+
+	if err := c.DeleteAsset("a2"); err != nil {
+		t.Fatalf("DeleteAsset: %v", err)
+	}
+
+	want := []string{
+		"GET /service/rest/v1/assets?repository=maven-releases",
+		"GET /service/rest/v1/assets?repository=maven-releases&continuationToken=page2",
+		"DELETE /service/rest/v1/assets/a2?",
+	}
+	if strings.Join(requests, "\n") != strings.Join(want, "\n") {
+		t.Errorf("requests:\n%s\nwant:\n%s", strings.Join(requests, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestAssetAttribute(t *testing.T) {
+	var asset Asset
+	data := `{"id": "a1", "path": "app/1.0/app-1.0.jar", "maven2": {"extension": "jar", "baseVersion": "1.0"}, "fileSize": 1024}`
+	if err := json.Unmarshal([]byte(data), &asset); err != nil {
+		t.Fatalf("unmarshal asset: %v", err)
+	}
+	if asset.ID != "a1" || asset.FileSize != 1024 {
+		t.Errorf("asset fields not decoded: %+v", asset)
+	}
+
+	tests := []struct {
+		path  string
+		value string
+		ok    bool
+	}{
+		{"maven2.extension", "jar", true},
+		{"fileSize", "1024", true},
+		{"maven2", "", false},
+		{"maven2.classifier", "", false},
+		{"npm.name", "", false},
+	}
+	for _, tt := range tests {
+		if value, ok := asset.Attribute(tt.path); value != tt.value || ok != tt.ok {
+			t.Errorf("Attribute(%q) = %q, %t, want %q, %t", tt.path, value, ok, tt.value, tt.ok)
+		}
+	}
+}
+
+func TestListingDetectsTokenLoops(t *testing.T) {
+	// The server cycles through tokens a, b, a, ... forever
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		next := "a"
+		if r.URL.Query().Get("continuationToken") == "a" {
+			next = "b"
+		}
+		if r.URL.Path == "/service/rest/v1/assets" {
+			json.NewEncoder(w).Encode(AssetPage{Items: []Asset{{ID: "asset"}}, ContinuationToken: next})
+			return
+		}
+		json.NewEncoder(w).Encode(ComponentPage{Items: []Component{{ID: "comp"}}, ContinuationToken: next})
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "admin", "secret", 5)
+	listings := map[string]func() error{
+		"components": func() error { _, err := c.GetComponents("docker-hosted"); return err },
+		"assets":     func() error { _, err := c.GetAssets("docker-hosted"); return err },
+	}
+	for name, list := range listings {
+		requests = 0
+		err := list()
+		if err == nil || !strings.Contains(err.Error(), "returned continuation token 'a' again") {
+			t.Errorf("%s listing returned %v, want a token loop error", name, err)
+		}
+		if requests != 3 {
+			t.Errorf("%s listing made %d requests, want 3", name, requests)
+		}
+	}
+}
+
+// pagedServer lists components c1 to c6 in pages of two, failing the third
+// page with failStatus if set, and counts requests.
+func pagedServer(t *testing.T, failStatus int, requests *int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		pages := map[string]ComponentPage{
+			"":   {Items: []Component{{ID: "c1"}, {ID: "c2"}}, ContinuationToken: "p2"},
+			"p2": {Items: []Component{{ID: "c3"}, {ID: "c4"}}, ContinuationToken: "p3"},
+			"p3": {Items: []Component{{ID: "c5"}, {ID: "c6"}}},
+		}
+		token := r.URL.Query().Get("continuationToken")
+		if token == "p3" && failStatus != 0 {
+			w.WriteHeader(failStatus)
+			return
+		}
+		json.NewEncoder(w).Encode(pages[token])
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestIterComponents(t *testing.T) {
+	stop := errors.New("stop here")
+	tests := []struct {
+		name       string
+		failStatus int
+		stopAt     string
+		stopErr    error
+		ids        string
+		requests   int
+		err        error
+	}{
+		{name: "all", ids: "c1,c2,c3,c4,c5,c6", requests: 3},
+		{name: "stopped early", stopAt: "c3", stopErr: ErrStopIteration, ids: "c1,c2,c3", requests: 2},
+		{name: "callback error", stopAt: "c2", stopErr: stop, ids: "c1,c2", requests: 1, err: stop},
+		{name: "listing error", failStatus: 500, ids: "c1,c2,c3,c4", requests: 3, err: &APIError{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			c := NewClient(pagedServer(t, tt.failStatus, &requests).URL, "admin", "secret", 5)
+
+			var ids []string
+			err := c.IterComponents("docker-hosted", func(comp Component) error {
+				ids = append(ids, comp.ID)
+				if comp.ID == tt.stopAt {
+					return tt.stopErr
+				}
+				return nil
+			})
+
+			var apiErr *APIError
+			switch {
+			case tt.err == nil && err != nil:
+				t.Errorf("IterComponents: %v", err)
+			case tt.err == stop && !errors.Is(err, stop):
+				t.Errorf("IterComponents returned %v, want the callback's error", err)
+			case tt.err != nil && tt.err != stop && !errors.As(err, &apiErr):
+				t.Errorf("IterComponents returned %v, want an API error", err)
+			}
+			if strings.Join(ids, ",") != tt.ids || requests != tt.requests {
+				t.Errorf("iterated %v with %d requests, want %s with %d", ids, requests, tt.ids, tt.requests)
+			}
+		})
+	}
+}
+
+func TestGetComponentsListsAllPages(t *testing.T) {
+	requests := 0
+	c := NewClient(pagedServer(t, 0, &requests).URL, "admin", "secret", 5)
+
+	components, err := c.GetComponents("docker-hosted")
+	if err != nil {
+		t.Fatalf("GetComponents: %v", err)
+	}
+	if len(components) != 6 || components[5].ID != "c6" {
+		t.Errorf("listed %v, want c1 to c6", components)
+	}
+}
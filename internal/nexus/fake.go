@@ -0,0 +1,167 @@
+package nexus
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// FakeClient is an in-memory stand-in for Client. Deleted components are
+// removed from the store so subsequent listings reflect them.
+type FakeClient struct {
+	mu           sync.Mutex
+	Repositories []Repository
+	Components   map[string][]Component
+	Deleted      []string
+	// DeletedAssets lists the IDs of deleted assets
+	DeletedAssets []string
+	// ReadOnly simulates an account without delete permission: deletions
+	// fail with 403 and CanDelete reports false
+	ReadOnly bool
+	// Details overrides the components returned by GetComponent, by ID, to
+	// simulate listings that lack metadata
+	Details map[string]Component
+}
+
+func NewFakeClient() *FakeClient {
+	return &FakeClient{
+		Components: make(map[string][]Component),
+	}
+}
+
+// AddRepository registers a repository with its components.
+func (f *FakeClient) AddRepository(repo Repository, components ...Component) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Repositories = append(f.Repositories, repo)
+	for i := range components {
+		components[i].Repository = repo.Name
+	}
+	f.Components[repo.Name] = append(f.Components[repo.Name], components...)
+}
+
+func (f *FakeClient) GetRepositories() ([]Repository, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Repository(nil), f.Repositories...), nil
+}
+
+func (f *FakeClient) GetComponents(repository string) ([]Component, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Component(nil), f.Components[repository]...), nil
+}
+
+// fakePageSize is the page size of EachComponentPage, small enough for
+// listings of a few components to span several pages.
+const fakePageSize = 10
+
+// EachComponentPage passes a copy of the repository's components to fn in
+// pages of fakePageSize.
+func (f *FakeClient) EachComponentPage(repository string, fn func(page []Component) error) error {
+	components, _ := f.GetComponents(repository)
+	for start := 0; start < len(components); start += fakePageSize {
+		end := min(start+fakePageSize, len(components))
+		if err := fn(components[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IterComponents calls fn with each of the repository's components, with
+// the same stopping rules as Client.IterComponents.
+func (f *FakeClient) IterComponents(repository string, fn func(comp Component) error) error {
+	components, _ := f.GetComponents(repository)
+	for _, comp := range components {
+		if err := fn(comp); errors.Is(err, ErrStopIteration) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetComponent returns the component from Details, or else from the store.
+func (f *FakeClient) GetComponent(componentID string) (Component, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if comp, ok := f.Details[componentID]; ok {
+		return comp, nil
+	}
+	for _, components := range f.Components {
+		for _, comp := range components {
+			if comp.ID == componentID {
+				return comp, nil
+			}
+		}
+	}
+	return Component{}, &APIError{StatusCode: 404, Body: fmt.Sprintf("component %s not found", componentID)}
+}
+
+func (f *FakeClient) DeleteComponent(componentID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.ReadOnly {
+		return &APIError{StatusCode: 403, Body: "read-only account"}
+	}
+
+	for repo, components := range f.Components {
+		for i, comp := range components {
+			if comp.ID == componentID {
+				f.Components[repo] = append(components[:i:i], components[i+1:]...)
+				f.Deleted = append(f.Deleted, componentID)
+				return nil
+			}
+		}
+	}
+	return &APIError{StatusCode: 404, Body: fmt.Sprintf("component %s not found", componentID)}
+}
+
+// GetAssets returns the assets of all components in the repository.
+func (f *FakeClient) GetAssets(repository string) ([]Asset, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var assets []Asset
+	for _, comp := range f.Components[repository] {
+		assets = append(assets, comp.Assets...)
+	}
+	return assets, nil
+}
+
+func (f *FakeClient) DeleteAsset(assetID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.ReadOnly {
+		return &APIError{StatusCode: 403, Body: "read-only account"}
+	}
+
+	for _, components := range f.Components {
+		for i := range components {
+			for j, asset := range components[i].Assets {
+				if asset.ID == assetID {
+					components[i].Assets = append(components[i].Assets[:j:j], components[i].Assets[j+1:]...)
+					f.DeletedAssets = append(f.DeletedAssets, assetID)
+					return nil
+				}
+			}
+		}
+	}
+	return &APIError{StatusCode: 404, Body: fmt.Sprintf("asset %s not found", assetID)}
+}
+
+// CanDelete reports whether deletions are permitted.
+func (f *FakeClient) CanDelete(repo Repository) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return !f.ReadOnly, nil
+}
@@ -0,0 +1,109 @@
+package nexus
+
+import "sync"
+
+// MockClient is a hand-written mock of Client. Each method delegates to the
+// corresponding func field when set and records the call, so tests can inject
+// errors and assert on the requests made.
+type MockClient struct {
+	GetRepositoriesFunc func() ([]Repository, error)
+	GetComponentsFunc   func(repository string) ([]Component, error)
+	DeleteComponentFunc func(componentID string) error
+	GetAssetsFunc       func(repository string) ([]Asset, error)
+	DeleteAssetFunc     func(assetID string) error
+	CanDeleteFunc       func(repo Repository) (bool, error)
+
+	mu    sync.Mutex
+	Calls []MockCall
+}
+
+// MockCall records a single method invocation on MockClient.
+type MockCall struct {
+	Method string
+	Arg    string
+}
+
+func (m *MockClient) record(method, arg string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Calls = append(m.Calls, MockCall{Method: method, Arg: arg})
+}
+
+// CallsTo returns the arguments of all recorded calls to method.
+func (m *MockClient) CallsTo(method string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var args []string
+	for _, call := range m.Calls {
+		if call.Method == method {
+			args = append(args, call.Arg)
+		}
+	}
+	return args
+}
+
+func (m *MockClient) GetRepositories() ([]Repository, error) {
+	m.record("GetRepositories", "")
+	if m.GetRepositoriesFunc == nil {
+		return nil, nil
+	}
+	return m.GetRepositoriesFunc()
+}
+
+func (m *MockClient) GetDockerRepositories() ([]Repository, error) {
+	m.record("GetDockerRepositories", "")
+	repos, err := m.GetRepositories()
+	if err != nil {
+		return nil, err
+	}
+
+	var docker []Repository
+	for _, repo := range repos {
+		if repo.IsDockerHosted() {
+			docker = append(docker, repo)
+		}
+	}
+	return docker, nil
+}
+
+func (m *MockClient) GetComponents(repository string) ([]Component, error) {
+	m.record("GetComponents", repository)
+	if m.GetComponentsFunc == nil {
+		return nil, nil
+	}
+	return m.GetComponentsFunc(repository)
+}
+
+func (m *MockClient) DeleteComponent(componentID string) error {
+	m.record("DeleteComponent", componentID)
+	if m.DeleteComponentFunc == nil {
+		return nil
+	}
+	return m.DeleteComponentFunc(componentID)
+}
+
+func (m *MockClient) GetAssets(repository string) ([]Asset, error) {
+	m.record("GetAssets", repository)
+	if m.GetAssetsFunc == nil {
+		return nil, nil
+	}
+	return m.GetAssetsFunc(repository)
+}
+
+func (m *MockClient) DeleteAsset(assetID string) error {
+	m.record("DeleteAsset", assetID)
+	if m.DeleteAssetFunc == nil {
+		return nil
+	}
+	return m.DeleteAssetFunc(assetID)
+}
+
+func (m *MockClient) CanDelete(repo Repository) (bool, error) {
+	m.record("CanDelete", repo.Name)
+	if m.CanDeleteFunc == nil {
+		return true, nil
+	}
+	return m.CanDeleteFunc(repo)
+}
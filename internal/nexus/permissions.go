@@ -0,0 +1,79 @@
+package nexus
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// permissionsRequest asks the Ext.Direct API of the Nexus UI for the
+// permissions of the current user.
+const permissionsRequest = `{"action":"rapture_Security","method":"getPermissions","data":null,"type":"rpc","tid":1}`
+
+// CanDelete reports whether the account may delete components of the
+// repository. It reads the permissions Nexus reports to its UI for the
+// current user, so nothing is deleted.
+func (c *Client) CanDelete(repo Repository) (bool, error) {
+	body, err := c.doRequestWithBody("POST", "/service/extdirect", repo.Name, strings.NewReader(permissionsRequest))
+	if err != nil {
+		return false, err
+	}
+
+	var response struct {
+		Result struct {
+			Success bool   `json:"success"`
+			Message string `json:"message"`
+			Data    []struct {
+				ID        string `json:"id"`
+				Permitted bool   `json:"permitted"`
+			} `json:"data"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return false, fmt.Errorf("failed to parse permissions: %w", err)
+	}
+	if !response.Result.Success {
+		return false, fmt.Errorf("failed to get permissions: %s", response.Result.Message)
+	}
+
+	target := fmt.Sprintf("nexus:repository-view:%s:%s:delete", repo.Format, repo.Name)
+	for _, perm := range response.Result.Data {
+		if perm.Permitted && impliesPermission(perm.ID, target) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// impliesPermission reports whether a granted wildcard permission, such as
+// "nexus:repository-view:*:*:browse,delete", implies the target permission.
+// Parts are "*" or comma separated values, and missing trailing parts imply
+// everything.
+func impliesPermission(granted, target string) bool {
+	grantedParts := strings.Split(granted, ":")
+	targetParts := strings.Split(target, ":")
+
+	for i, part := range targetParts {
+		if i >= len(grantedParts) {
+			return true
+		}
+		if grantedParts[i] == "*" {
+			continue
+		}
+
+		matched := false
+		for _, value := range strings.Split(grantedParts[i], ",") {
+			matched = matched || value == part
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	for _, part := range grantedParts[len(targetParts):] {
+		if part != "*" {
+			return false
+		}
+	}
+	return true
+}
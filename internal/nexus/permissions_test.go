@@ -0,0 +1,74 @@
+package nexus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestImpliesPermission(t *testing.T) {
+	target := "nexus:repository-view:docker:docker-hosted:delete"
+	tests := []struct {
+		granted string
+		want    bool
+	}{
+		{"nexus:repository-view:docker:docker-hosted:delete", true},
+		{"nexus:repository-view:*:*:browse,delete", true},
+		{"nexus:repository-view:*:*:*", true},
+		{"nexus:repository-view", true},
+		{"nexus:*", true},
+		{"nexus:repository-view:docker:docker-hosted:browse,read", false},
+		{"nexus:repository-view:docker:other:*", false},
+		{"nexus:repository-view:maven2:*:delete", false},
+		{"nexus:repository-view:*:*:delete:extra", false},
+		{"nexus:repository-admin:*:*:*", false},
+	}
+
+	for _, tt := range tests {
+		if got := impliesPermission(tt.granted, target); got != tt.want {
+			t.Errorf("impliesPermission(%q) = %t, want %t", tt.granted, got, tt.want)
+		}
+	}
+}
+
+func TestCanDelete(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		allowed  bool
+		err      bool
+	}{
+		{
+			name:     "admin",
+			response: `{"result": {"success": true, "data": [{"id": "nexus:repository-view:*:*:*", "permitted": true}]}}`,
+			allowed:  true,
+		},
+		{
+			name:     "read-only",
+			response: `{"result": {"success": true, "data": [{"id": "nexus:repository-view:*:*:browse,read", "permitted": true}, {"id": "nexus:repository-view:*:*:delete", "permitted": false}]}}`,
+		},
+		{
+			name:     "failed",
+			response: `{"result": {"success": false, "message": "session expired"}}`,
+			err:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != "POST" || r.URL.Path != "/service/extdirect" {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			c := NewClient(server.URL, "admin", "secret", 5)
+			allowed, err := c.CanDelete(Repository{Name: "docker-hosted", Format: "docker"})
+			if (err != nil) != tt.err || allowed != tt.allowed {
+				t.Errorf("CanDelete() = %t, %v, want %t and error %t", allowed, err, tt.allowed, tt.err)
+			}
+		})
+	}
+}
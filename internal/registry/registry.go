@@ -0,0 +1,70 @@
+// Package registry creates the registry clients of a configuration. The
+// command line tool and the public API share it, so embedded engines talk to
+// the registry exactly like the tool does.
+package registry
+
+import (
+	"fmt"
+
+	"nexus-retention-policy/internal/artifactory"
+	"nexus-retention-policy/internal/config"
+	"nexus-retention-policy/internal/harbor"
+	"nexus-retention-policy/internal/nexus"
+	"nexus-retention-policy/internal/retention"
+)
+
+// client is a registry backend supporting per-repository credentials and a
+// cap on listed components.
+type client interface {
+	retention.Registry
+	SetRepositoryCredentials(repository, username, password string)
+	SetMaxComponents(max int)
+}
+
+// New creates the client for the configured backend. Requests send the
+// User-Agent and the headers of the nexus section.
+func New(cfg *config.Config, userAgent string) retention.Registry {
+	httpClient := NewHTTPClient(cfg.Nexus.Timeout, userAgent, cfg.Nexus.Headers)
+
+	switch cfg.Backend {
+	case config.BackendArtifactory:
+		return configure(artifactory.NewClientWithHTTP(cfg.Nexus.URL, cfg.Nexus.Username, cfg.Nexus.Password, httpClient), cfg)
+	case config.BackendHarbor:
+		return configure(harbor.NewClientWithHTTP(cfg.Nexus.URL, cfg.Nexus.Username, cfg.Nexus.Password, httpClient), cfg)
+	}
+	return NewNexus(cfg, userAgent)
+}
+
+// NewNexus creates a Nexus client for the configuration, whatever its
+// backend.
+func NewNexus(cfg *config.Config, userAgent string) *nexus.Client {
+	httpClient := NewHTTPClient(cfg.Nexus.Timeout, userAgent, cfg.Nexus.Headers)
+	c := nexus.NewClientWithHTTP(cfg.Nexus.URL, cfg.Nexus.Username, cfg.Nexus.Password, httpClient)
+	configure(c, cfg)
+	if cfg.Nexus.APIKey != "" {
+		c.SetAPIKey(cfg.Nexus.APIKeyHeader, cfg.Nexus.APIKey)
+	}
+	return c
+}
+
+// configure applies the listing settings and repository credentials of the
+// configuration to a client.
+func configure(c client, cfg *config.Config) client {
+	if cfg.PageSize > 0 {
+		if pager, ok := c.(interface{ SetPageSize(int) }); ok {
+			pager.SetPageSize(cfg.PageSize)
+		} else {
+			fmt.Printf("⚠️  page_size is not supported by the %s backend and is ignored\n", cfg.Backend)
+		}
+	}
+	if cfg.MaxComponentsPerRepo > 0 {
+		c.SetMaxComponents(cfg.MaxComponentsPerRepo)
+	}
+
+	for name, settings := range cfg.RepositorySettings {
+		if settings.Username != "" {
+			c.SetRepositoryCredentials(name, settings.Username, settings.Password)
+		}
+	}
+	return c
+}
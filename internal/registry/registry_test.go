@@ -0,0 +1,83 @@
+package registry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"nexus-retention-policy/internal/config"
+)
+
+func TestNewSendsConfiguredHeaders(t *testing.T) {
+	tests := []struct {
+		backend string
+		headers string
+		agent   string
+	}{
+		{backend: config.BackendNexus, agent: "nexus-retention-policy/1.2.3"},
+		{backend: config.BackendArtifactory, agent: "nexus-retention-policy/1.2.3"},
+		{backend: config.BackendHarbor, agent: "nexus-retention-policy/1.2.3"},
+		{backend: config.BackendNexus, headers: `{User-Agent: "custom/1.0"}`, agent: "custom/1.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.backend+tt.headers, func(t *testing.T) {
+			var got *http.Request
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+				w.Write([]byte("[]"))
+			}))
+			defer server.Close()
+
+			headers := `{X-Gateway-Route: nexus-internal}`
+			if tt.headers != "" {
+				headers = tt.headers
+			}
+			cfg, err := config.Parse([]byte(`
+backend: ` + tt.backend + `
+nexus: {url: "` + server.URL + `", username: admin, password: secret, headers: ` + headers + `}
+rules: [{name: all, regex: ".*", keep: 1}]
+`))
+			if err != nil {
+				t.Fatalf("parse config: %v", err)
+			}
+
+			if _, err := New(cfg, "nexus-retention-policy/1.2.3").GetRepositories(); err != nil {
+				t.Fatalf("GetRepositories: %v", err)
+			}
+			if ua := got.Header.Get("User-Agent"); ua != tt.agent {
+				t.Errorf("User-Agent %q, want %q", ua, tt.agent)
+			}
+			if tt.headers == "" && got.Header.Get("X-Gateway-Route") != "nexus-internal" {
+				t.Errorf("configured header not sent")
+			}
+		})
+	}
+}
+
+func TestNewSendsAPIKey(t *testing.T) {
+	var got *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	cfg, err := config.Parse([]byte(`
+nexus: {url: "` + server.URL + `", api_key: secret-key}
+rules: [{name: all, regex: ".*", keep: 1}]
+`))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	if _, err := New(cfg, "nexus-retention-policy/1.2.3").GetRepositories(); err != nil {
+		t.Fatalf("GetRepositories: %v", err)
+	}
+
+	if key := got.Header.Get("X-Nexus-ApiKey"); key != "secret-key" {
+		t.Errorf("X-Nexus-ApiKey %q, want secret-key", key)
+	}
+	if _, _, ok := got.BasicAuth(); ok {
+		t.Error("basic auth sent with an API key")
+	}
+}
@@ -0,0 +1,35 @@
+package registry
+
+import (
+	"net/http"
+	"time"
+)
+
+// headerTransport sets static headers on every request.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the caller's request
+	req = req.Clone(req.Context())
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// NewHTTPClient returns the HTTP client for registry requests, sending the
+// User-Agent and the extra headers, which may override it.
+func NewHTTPClient(timeout int, userAgent string, extra map[string]string) *http.Client {
+	headers := map[string]string{"User-Agent": userAgent}
+	for name, value := range extra {
+		headers[name] = value
+	}
+
+	return &http.Client{
+		Timeout:   time.Duration(timeout) * time.Second,
+		Transport: &headerTransport{base: http.DefaultTransport, headers: headers},
+	}
+}
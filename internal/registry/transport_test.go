@@ -0,0 +1,33 @@
+package registry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPClientHeaders(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(5, "nexus-retention-policy/1.2.3", map[string]string{"X-Gateway-Route": "nexus-internal"})
+	req, err := http.NewRequest("DELETE", server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+
+	if got.Get("User-Agent") != "nexus-retention-policy/1.2.3" || got.Get("X-Gateway-Route") != "nexus-internal" {
+		t.Errorf("sent headers %v", got)
+	}
+	if len(req.Header) != 0 {
+		t.Errorf("caller's request modified: %v", req.Header)
+	}
+}
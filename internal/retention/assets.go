@@ -0,0 +1,86 @@
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"nexus-retention-policy/internal/config"
+	"nexus-retention-policy/internal/logger"
+	"nexus-retention-policy/internal/nexus"
+)
+
+// errAssetsUnsupported is returned for asset deletions on registries that
+// don't implement AssetDeleter.
+var errAssetsUnsupported = errors.New("registry does not support deleting assets")
+
+// deleteAsset deletes an asset if the registry supports it.
+func deleteAsset(registry Registry, assetID string) error {
+	deleter, ok := registry.(AssetDeleter)
+	if !ok {
+		return errAssetsUnsupported
+	}
+	return deleter.DeleteAsset(assetID)
+}
+
+// processAssetRules deletes the assets of a repository's components whose
+// path matches an asset rule. Assets of protected components are kept.
+func (p *PolicyEngine) processAssetRules(repoName string, components []nexus.Component) {
+	deleted := 0
+	for _, comp := range components {
+		if p.abortErr() != nil {
+			break
+		}
+		if p.protectionReason(comp) != "" {
+			continue
+		}
+
+		for _, asset := range comp.Assets {
+			// Guardrails may abort the run between deletions
+			if p.abortErr() != nil {
+				break
+			}
+			rule := p.config.MatchAssetRule(repoName, asset.Path)
+			if rule == nil {
+				continue
+			}
+
+			if !p.dryRun {
+				err := deleteAsset(p.client, asset.ID)
+				if isAlreadyDeleted(err) {
+					p.alreadyDeleted++
+				} else if err != nil {
+					category := p.errors.add(fmt.Sprintf("%s/%s", repoName, asset.Path), err)
+					fmt.Printf("  ⚠️  Failed to delete asset %s (%s)\n", asset.Path, category)
+					continue
+				}
+			}
+
+			if p.options.Verbosity >= VerbosityTag {
+				fmt.Printf("  🗑️  DELETE asset %s (asset rule: %s)\n", asset.Path, rule.Name)
+			}
+
+			p.logDeletion(logger.DeletionRecord{
+				ExecutionID: p.executionID,
+				Timestamp:   time.Now(),
+				Repository:  repoName,
+				ImageName:   groupName(comp),
+				Tag:         comp.Version,
+				ComponentID: asset.ID,
+				Rule:        assetRuleName(rule),
+				DryRun:      p.dryRun,
+			})
+			deleted++
+		}
+	}
+
+	if deleted > 0 && p.options.Verbosity >= VerbosityImage {
+		fmt.Printf("  📎 Asset rules deleted %d assets\n", deleted)
+	}
+	p.assetsDeleted += deleted
+}
+
+// assetRuleName returns the rule name recorded in the deletion log.
+func assetRuleName(rule *config.AssetRule) string {
+	return "asset: " + rule.Name
+}
@@ -0,0 +1,59 @@
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// sourceJars returns a Maven component with n source jars.
+func sourceJars(n int) nexus.Component {
+	comp := nexus.Component{ID: "lib-1.0", Name: "lib", Group: "com.example", Version: "1.0", Format: "maven2"}
+	for i := 0; i < n; i++ {
+		comp.Assets = append(comp.Assets, nexus.Asset{
+			ID:           fmt.Sprintf("asset-%d", i),
+			Path:         fmt.Sprintf("com/example/lib/1.0/lib-1.0-%d-sources.jar", i),
+			LastModified: testTime,
+		})
+	}
+	return comp
+}
+
+const assetRulesConfig = `
+rules: [{name: all, regex: ".*", keep: 1}]
+asset_rules: [{name: sources, path_regex: "-sources\\.jar$"}]
+`
+
+func TestAssetRules(t *testing.T) {
+	mock := mockRegistry([]nexus.Component{sourceJars(3)})
+	engine, log := newTestEngine(mock, parseConfig(t, assetRulesConfig), Options{})
+	execute(t, engine)
+
+	if got := mock.CallsTo("DeleteAsset"); !equalStrings(got, []string{"asset-0", "asset-1", "asset-2"}) {
+		t.Errorf("deleted assets %v", got)
+	}
+	if got := len(log.Records()); got != 3 {
+		t.Errorf("logged %d asset deletions, want 3", got)
+	}
+}
+
+func TestAssetRulesStopOnAbort(t *testing.T) {
+	mock := mockRegistry([]nexus.Component{sourceJars(5)})
+
+	cfg := parseConfig(t, assetRulesConfig+"log_failure: abort\n")
+	engine := NewPolicyEngine(mock, cfg, failingLog{}, Options{})
+	_, err := engine.Execute()
+
+	var guardrail *GuardrailError
+	if !errors.As(err, &guardrail) {
+		t.Fatalf("Execute returned %v, want a guardrail error", err)
+	}
+	if got := len(mock.CallsTo("DeleteAsset")); got != 1 {
+		t.Errorf("made %d asset deletions, want 1 before the failed log write", got)
+	}
+	if len(mock.CallsTo("DeleteComponent")) != 0 {
+		t.Errorf("deleted components after the run was aborted")
+	}
+}
@@ -0,0 +1,163 @@
+package retention
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// backupManifest appends the full metadata of components to backup_manifest
+// before they are deleted, one JSON object per line, so deletions can be
+// audited or the components restored from backups.
+type backupManifest struct {
+	file *os.File
+}
+
+// backupEntry is the metadata of a deleted component.
+type backupEntry struct {
+	ExecutionID string    `json:"execution_id"`
+	Timestamp   time.Time `json:"timestamp"`
+	Repository  string    `json:"repository"`
+	ImageName   string    `json:"image_name"`
+	Tag         string    `json:"tag"`
+	ComponentID string    `json:"component_id"`
+	Format      string    `json:"format,omitempty"`
+	Group       string    `json:"group,omitempty"`
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	// Digest is the sha256 digest of the component's manifest, for pulling
+	// it by digest from a backup
+	Digest string        `json:"digest,omitempty"`
+	Assets []backupAsset `json:"assets"`
+}
+
+type backupAsset struct {
+	ID           string            `json:"id"`
+	Path         string            `json:"path"`
+	DownloadURL  string            `json:"download_url"`
+	FileSize     int64             `json:"file_size"`
+	Checksum     map[string]string `json:"checksum,omitempty"`
+	BlobStore    string            `json:"blob_store,omitempty"`
+	LastModified time.Time         `json:"last_modified"`
+	// Attributes holds all fields of the asset as returned by the registry,
+	// including format-specific ones
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// openBackupManifest opens the manifest for appending.
+func openBackupManifest(path string) (*backupManifest, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup manifest: %w", err)
+	}
+	return &backupManifest{file: file}, nil
+}
+
+// write appends the entries and syncs the file, so the metadata is on disk
+// before the components are deleted.
+func (m *backupManifest) write(entries []backupEntry) error {
+	var lines []byte
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode backup manifest entry: %w", err)
+		}
+		lines = append(append(lines, data...), '\n')
+	}
+
+	if _, err := m.file.Write(lines); err != nil {
+		return fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+	if err := m.file.Sync(); err != nil {
+		return fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+	return nil
+}
+
+func (m *backupManifest) close() {
+	m.file.Close()
+}
+
+func newBackupEntry(executionID, repoName, imageName string, comp nexus.Component, now time.Time) backupEntry {
+	entry := backupEntry{
+		ExecutionID: executionID,
+		Timestamp:   now,
+		Repository:  repoName,
+		ImageName:   imageName,
+		Tag:         comp.Version,
+		ComponentID: comp.ID,
+		Format:      comp.Format,
+		Group:       comp.Group,
+		Name:        comp.Name,
+		Version:     comp.Version,
+		Digest:      componentDigest(comp),
+		Assets:      make([]backupAsset, len(comp.Assets)),
+	}
+	for i, asset := range comp.Assets {
+		entry.Assets[i] = backupAsset{
+			ID:           asset.ID,
+			Path:         asset.Path,
+			DownloadURL:  asset.DownloadURL,
+			FileSize:     asset.FileSize,
+			Checksum:     asset.Checksum,
+			BlobStore:    asset.BlobStore,
+			LastModified: asset.LastModified,
+			Attributes:   asset.Attributes,
+		}
+	}
+	return entry
+}
+
+// componentDigest returns the sha256 digest of the component's Docker
+// manifest, or of its first asset with a sha256 checksum.
+func componentDigest(comp nexus.Component) string {
+	digest := ""
+	for _, asset := range comp.Assets {
+		sum, ok := asset.Checksum["sha256"]
+		if !ok {
+			continue
+		}
+		if strings.Contains(asset.Path, "/manifests/") {
+			return "sha256:" + sum
+		}
+		if digest == "" {
+			digest = "sha256:" + sum
+		}
+	}
+	return digest
+}
+
+// backupPlan records the components of an applied plan in the backup
+// manifest. components holds the listed component of each deletion.
+func (p *PolicyEngine) backupPlan(deletions []PlannedDeletion, components []nexus.Component) error {
+	backup, err := openBackupManifest(p.config.BackupManifest)
+	if err != nil {
+		return err
+	}
+	defer backup.close()
+
+	now := time.Now()
+	entries := make([]backupEntry, len(deletions))
+	for i, d := range deletions {
+		entries[i] = newBackupEntry(p.executionID, d.Repository, d.ImageName, components[i], now)
+	}
+	return backup.write(entries)
+}
+
+// backupDeletions records the components in the backup manifest, if one is
+// configured.
+func (p *PolicyEngine) backupDeletions(repoName, imageName string, components []nexus.Component) error {
+	if p.backup == nil || len(components) == 0 {
+		return nil
+	}
+	now := time.Now()
+	entries := make([]backupEntry, len(components))
+	for i, comp := range components {
+		entries[i] = newBackupEntry(p.executionID, repoName, imageName, comp, now)
+	}
+	return p.backup.write(entries)
+}
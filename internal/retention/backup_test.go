@@ -0,0 +1,70 @@
+package retention
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+func readBackup(t *testing.T, path string) []backupEntry {
+	t.Helper()
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatalf("open backup manifest: %v", err)
+	}
+	defer file.Close()
+
+	var entries []backupEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry backupEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("parse backup manifest: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestBackupManifest(t *testing.T) {
+	for _, dryRun := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "backup.jsonl")
+		fake := nexus.NewFakeClient()
+		fake.AddRepository(dockerRepo("docker-hosted"), numbered("app", 3)...)
+
+		cfg := parseConfig(t, "backup_manifest: "+path+"\nrules: [{name: all, regex: \".*\", keep: 1}]\n")
+		engine, _ := newTestEngine(fake, cfg, Options{DryRun: dryRun})
+		execute(t, engine)
+
+		entries := readBackup(t, path)
+		if dryRun {
+			if len(entries) != 0 {
+				t.Errorf("dry run wrote %d backup entries", len(entries))
+			}
+			continue
+		}
+
+		var ids []string
+		for _, entry := range entries {
+			ids = append(ids, entry.ComponentID)
+			if entry.ImageName != "app" || entry.Repository != "docker-hosted" || len(entry.Assets) != 1 {
+				t.Errorf("unexpected entry %+v", entry)
+			}
+			if entry.Digest != "sha256:"+entry.Assets[0].Checksum["sha256"] {
+				t.Errorf("digest %q doesn't match the manifest checksum", entry.Digest)
+			}
+		}
+		sort.Strings(ids)
+		if want := deletedTags(fake); !equalStrings(ids, want) {
+			t.Errorf("backed up %v, deleted %v", ids, want)
+		}
+	}
+}
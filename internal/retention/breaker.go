@@ -0,0 +1,85 @@
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"nexus-retention-policy/internal/config"
+)
+
+// CircuitOpenError is returned when a run was aborted because too many
+// registry requests failed. It wraps the last failure.
+type CircuitOpenError struct {
+	Reason string
+	Last   error
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("run aborted after %s, last error: %v", e.Reason, e.Last)
+}
+
+func (e *CircuitOpenError) Unwrap() error {
+	return e.Last
+}
+
+// circuitBreaker counts failed registry requests and opens once the
+// configured thresholds are reached. While open, requests fail immediately
+// instead of being sent. A nil breaker never opens.
+type circuitBreaker struct {
+	mu          sync.Mutex
+	cfg         config.CircuitBreakerConfig
+	consecutive int
+	failures    int
+	open        *CircuitOpenError
+}
+
+func newCircuitBreaker(cfg config.CircuitBreakerConfig) *circuitBreaker {
+	if cfg.ConsecutiveFailures == 0 && cfg.MaxFailures == 0 {
+		return nil
+	}
+	return &circuitBreaker{cfg: cfg}
+}
+
+// record counts the outcome of a request. Missing components and rate
+// limiting, which is retried with backoff, don't count as failures.
+func (b *circuitBreaker) record(err error) {
+	if b == nil || isAlreadyDeleted(err) || isRateLimited(err) || errors.Is(err, errAssetsUnsupported) || errors.Is(err, errPermissionCheckUnsupported) || errors.Is(err, errStreamingUnsupported) || errors.Is(err, errDetailsUnsupported) {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.consecutive = 0
+		return
+	}
+	b.consecutive++
+	b.failures++
+
+	if b.open != nil {
+		return
+	}
+	switch {
+	case b.cfg.ConsecutiveFailures > 0 && b.consecutive >= b.cfg.ConsecutiveFailures:
+		b.open = &CircuitOpenError{Reason: fmt.Sprintf("%d consecutive failed requests", b.consecutive), Last: err}
+	case b.cfg.MaxFailures > 0 && b.failures >= b.cfg.MaxFailures:
+		b.open = &CircuitOpenError{Reason: fmt.Sprintf("%d failed requests", b.failures), Last: err}
+	}
+}
+
+// err returns the CircuitOpenError once the breaker is open, nil otherwise.
+func (b *circuitBreaker) err() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.open == nil {
+		return nil
+	}
+	return b.open
+}
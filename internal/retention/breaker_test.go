@@ -0,0 +1,58 @@
+package retention
+
+import (
+	"errors"
+	"testing"
+
+	"nexus-retention-policy/internal/config"
+	"nexus-retention-policy/internal/nexus"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	failure := &nexus.APIError{StatusCode: 500}
+
+	b := newCircuitBreaker(config.CircuitBreakerConfig{ConsecutiveFailures: 2, MaxFailures: 3})
+	// Successes reset the consecutive count, missing components aren't failures
+	for _, err := range []error{failure, nil, failure, &nexus.APIError{StatusCode: 404}, nil} {
+		b.record(err)
+	}
+	if b.err() != nil {
+		t.Fatalf("breaker open after non-consecutive failures: %v", b.err())
+	}
+
+	b.record(failure)
+	var open *CircuitOpenError
+	if !errors.As(b.err(), &open) || open.Reason != "3 failed requests" || open.Last != failure {
+		t.Errorf("breaker error %v, want open after 3 failed requests", b.err())
+	}
+
+	if newCircuitBreaker(config.CircuitBreakerConfig{}) != nil {
+		t.Error("breaker created without thresholds")
+	}
+}
+
+func TestExecuteCircuitBreaker(t *testing.T) {
+	mock := &nexus.MockClient{
+		GetRepositoriesFunc: func() ([]nexus.Repository, error) {
+			return []nexus.Repository{dockerRepo("a"), dockerRepo("b"), dockerRepo("c"), dockerRepo("d")}, nil
+		},
+		GetComponentsFunc: func(repository string) ([]nexus.Component, error) {
+			return nil, &nexus.APIError{StatusCode: 503}
+		},
+	}
+
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 1}]
+circuit_breaker: {consecutive_failures: 2}
+`)
+	engine, _ := newTestEngine(mock, cfg, Options{})
+	_, err := engine.Execute()
+
+	var open *CircuitOpenError
+	if !errors.As(err, &open) {
+		t.Fatalf("Execute returned %v, want a circuit open error", err)
+	}
+	if got := len(mock.CallsTo("GetComponents")); got != 2 {
+		t.Errorf("listed %d repositories, want the run aborted after 2", got)
+	}
+}
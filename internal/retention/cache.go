@@ -0,0 +1,113 @@
+package retention
+
+import (
+	"sync"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// componentCache caches component listings per repository within a run, so
+// each repository is fetched at most once. Deleting a component invalidates
+// the listing of its repository.
+type componentCache struct {
+	next Registry
+
+	mu         sync.Mutex
+	components map[string][]nexus.Component
+	// repoOf maps cached component IDs to their repository
+	repoOf map[string]string
+}
+
+func newComponentCache(next Registry) *componentCache {
+	c := &componentCache{next: next}
+	c.reset()
+	return c
+}
+
+// reset drops all cached listings, e.g. at the start of a run.
+func (c *componentCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.components = make(map[string][]nexus.Component)
+	c.repoOf = make(map[string]string)
+}
+
+// invalidate drops the cached listing of a repository.
+func (c *componentCache) invalidate(repository string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, comp := range c.components[repository] {
+		delete(c.repoOf, comp.ID)
+	}
+	delete(c.components, repository)
+}
+
+func (c *componentCache) GetRepositories() ([]nexus.Repository, error) {
+	return c.next.GetRepositories()
+}
+
+// GetComponents returns a copy of the cached listing, fetching it on first
+// use. Errors are not cached.
+func (c *componentCache) GetComponents(repository string) ([]nexus.Component, error) {
+	c.mu.Lock()
+	cached, ok := c.components[repository]
+	c.mu.Unlock()
+	if ok {
+		return append([]nexus.Component(nil), cached...), nil
+	}
+
+	components, err := c.next.GetComponents(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.components[repository] = components
+	for _, comp := range components {
+		c.repoOf[comp.ID] = repository
+	}
+	c.mu.Unlock()
+
+	return append([]nexus.Component(nil), components...), nil
+}
+
+// DeleteAsset deletes an asset. The cached listing of the asset's component
+// is kept, callers skip deleted assets themselves.
+func (c *componentCache) DeleteAsset(assetID string) error {
+	return deleteAsset(c.next, assetID)
+}
+
+// EachComponentPage lists components page by page without caching them;
+// streamed listings are never held in full.
+func (c *componentCache) EachComponentPage(repository string, fn func(page []nexus.Component) error) error {
+	return eachComponentPage(c.next, repository, fn)
+}
+
+// GetComponent fetches component details without caching them.
+func (c *componentCache) GetComponent(componentID string) (nexus.Component, error) {
+	return getComponent(c.next, componentID)
+}
+
+// Truncated reports whether the cached listing was cut off.
+func (c *componentCache) Truncated(repository string) bool {
+	return listingTruncated(c.next, repository)
+}
+
+func (c *componentCache) CanDelete(repo nexus.Repository) (bool, error) {
+	return canDelete(c.next, repo)
+}
+
+func (c *componentCache) DeleteComponent(componentID string) error {
+	err := c.next.DeleteComponent(componentID)
+
+	c.mu.Lock()
+	repository, ok := c.repoOf[componentID]
+	c.mu.Unlock()
+	if ok {
+		c.invalidate(repository)
+	}
+
+	return err
+}
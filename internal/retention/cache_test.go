@@ -0,0 +1,52 @@
+package retention
+
+import "testing"
+
+func TestExecuteListsEachRepositoryOnce(t *testing.T) {
+	mock := mockRegistry(append(numbered("app", 3), numbered("api", 3)...))
+
+	cfg := parseConfig(t, `
+rules:
+  - {name: app, regex: "^app$", keep: 2}
+  - {name: api, regex: "^api$", keep: 1}
+`)
+	engine, _ := newTestEngine(mock, cfg, Options{DryRun: true})
+	execute(t, engine)
+
+	if got := mock.CallsTo("GetComponents"); len(got) != 1 {
+		t.Errorf("listed components %d times, want once", len(got))
+	}
+}
+
+func TestComponentCacheInvalidatedByDeletion(t *testing.T) {
+	mock := mockRegistry(numbered("app", 2))
+	cache := newComponentCache(mock)
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.GetComponents("docker-hosted"); err != nil {
+			t.Fatalf("GetComponents: %v", err)
+		}
+	}
+	if got := len(mock.CallsTo("GetComponents")); got != 1 {
+		t.Fatalf("listed components %d times before deleting, want once", got)
+	}
+
+	if err := cache.DeleteComponent("app:v1"); err != nil {
+		t.Fatalf("DeleteComponent: %v", err)
+	}
+	if _, err := cache.GetComponents("docker-hosted"); err != nil {
+		t.Fatalf("GetComponents: %v", err)
+	}
+	if got := len(mock.CallsTo("GetComponents")); got != 2 {
+		t.Errorf("listed components %d times after deleting, want twice", got)
+	}
+
+	// Listings are reset between runs
+	cache.reset()
+	if _, err := cache.GetComponents("docker-hosted"); err != nil {
+		t.Fatalf("GetComponents: %v", err)
+	}
+	if got := len(mock.CallsTo("GetComponents")); got != 3 {
+		t.Errorf("listed components %d times after a reset, want 3", got)
+	}
+}
@@ -0,0 +1,142 @@
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// maxThrottleRetries is how often a rate-limited deletion is retried.
+const maxThrottleRetries = 5
+
+// aimdLimiter bounds concurrent deletions. The limit is halved whenever Nexus
+// rate limits a request (multiplicative decrease) and raised by one after a
+// full window of successful requests (additive increase), up to max.
+type aimdLimiter struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	limit     int
+	max       int
+	inFlight  int
+	successes int
+}
+
+func newAIMDLimiter(max int) *aimdLimiter {
+	if max < 1 {
+		max = 1
+	}
+	l := &aimdLimiter{limit: max, max: max}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+func (l *aimdLimiter) acquire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for l.inFlight >= l.limit {
+		l.cond.Wait()
+	}
+	l.inFlight++
+}
+
+func (l *aimdLimiter) release(throttled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	if throttled {
+		if l.limit > 1 {
+			l.limit /= 2
+			fmt.Printf("     ⚠️  Rate limited by Nexus, reducing concurrency to %d\n", l.limit)
+		}
+		l.successes = 0
+	} else {
+		l.successes++
+		if l.successes >= l.limit && l.limit < l.max {
+			l.limit++
+			l.successes = 0
+		}
+	}
+	l.cond.Broadcast()
+}
+
+// errAborted is passed to deleteAll callbacks for components that weren't
+// deleted because guardrails aborted the run.
+var errAborted = errors.New("run aborted before deletion")
+
+// deleteAll deletes components concurrently within the limiter's bounds and
+// calls done with the index and error of each component. Calls of done are
+// serialized and finish before the deletion's limiter slot is released, so
+// deletions are logged before further ones start. Once the run is aborted,
+// e.g. because a deletion couldn't be logged, no further deletions start and
+// done gets errAborted for the remaining components.
+func (p *PolicyEngine) deleteAll(components []nexus.Component, done func(i int, err error)) {
+	p.progress.addPlanned(len(components))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i, comp := range components {
+		p.limiter.acquire()
+		mu.Lock()
+		aborted := p.abortErr() != nil
+		mu.Unlock()
+		if aborted {
+			p.limiter.release(false)
+			wg.Wait()
+			for j := i; j < len(components); j++ {
+				done(j, errAborted)
+			}
+			return
+		}
+
+		wg.Add(1)
+		go func(i int, comp nexus.Component) {
+			defer wg.Done()
+			err := p.deleteWithBackoff(comp.ID)
+			if now := time.Now(); p.progress.completed(now) {
+				p.progress.report(now)
+			}
+			mu.Lock()
+			done(i, err)
+			mu.Unlock()
+			p.limiter.release(false)
+		}(i, comp)
+	}
+
+	wg.Wait()
+}
+
+// deleteWithBackoff deletes a component, backing off exponentially and
+// retrying while Nexus responds with 429. The caller must hold a limiter slot
+// and release it after the deletion.
+func (p *PolicyEngine) deleteWithBackoff(componentID string) error {
+	backoff := time.Duration(p.config.RateLimitBackoff) * time.Second
+
+	for attempt := 0; ; attempt++ {
+		err := p.client.DeleteComponent(componentID)
+		if !isRateLimited(err) || attempt >= maxThrottleRetries {
+			return err
+		}
+
+		p.limiter.release(true)
+		time.Sleep(backoff << attempt)
+		p.limiter.acquire()
+	}
+}
+
+// isAlreadyDeleted reports whether a deletion failed because the component
+// no longer exists, e.g. after a concurrent or resumed run. Such deletions
+// count as successful.
+func isAlreadyDeleted(err error) bool {
+	var apiErr *nexus.APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == 404
+}
+
+func isRateLimited(err error) bool {
+	var apiErr *nexus.APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == 429
+}
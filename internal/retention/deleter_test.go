@@ -0,0 +1,128 @@
+package retention
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+func TestExecuteStopsDeletingWhenLogFails(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), numbered("app", 6)...)
+
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 1}]
+log_failure: abort
+delete_concurrency: 1
+`)
+	engine := NewPolicyEngine(fake, cfg, failingLog{}, Options{})
+	result, err := engine.Execute()
+
+	var guardrail *GuardrailError
+	if !errors.As(err, &guardrail) {
+		t.Fatalf("Execute returned %v, want a guardrail error", err)
+	}
+	if len(fake.Deleted) != 1 {
+		t.Errorf("deleted %v, want a single deletion before the failed log write", fake.Deleted)
+	}
+	if result != nil && result.Deleted > 1 {
+		t.Errorf("result.Deleted = %d, want at most 1", result.Deleted)
+	}
+}
+
+func TestApplyStopsDeletingWhenLogFails(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	components := numbered("app", 4)
+	fake.AddRepository(dockerRepo("docker-hosted"), components...)
+
+	plan := &Plan{}
+	for _, comp := range components {
+		plan.add("docker-hosted", "app", "all", comp)
+	}
+
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 1}]
+log_failure: abort
+delete_concurrency: 1
+`)
+	engine := NewPolicyEngine(fake, cfg, failingLog{}, Options{Force: true})
+	var guardrail *GuardrailError
+	if err := engine.Apply(plan); !errors.As(err, &guardrail) {
+		t.Fatalf("Apply returned %v, want a guardrail error", err)
+	}
+	if len(fake.Deleted) != 1 {
+		t.Errorf("deleted %v, want a single deletion before the failed log write", fake.Deleted)
+	}
+}
+
+func TestExecuteAlreadyDeleted(t *testing.T) {
+	mock := mockRegistry(numbered("app", 3))
+	mock.DeleteComponentFunc = func(componentID string) error {
+		if componentID == "app:v1" {
+			return &nexus.APIError{StatusCode: 404}
+		}
+		return nil
+	}
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`)
+	engine, log := newTestEngine(mock, cfg, Options{Verbosity: VerbosityTag})
+	var result *RunResult
+	out := captureStdout(t, func() { result = execute(t, engine) })
+
+	if result.Deleted != 2 || result.AlreadyDeleted != 1 {
+		t.Errorf("deleted %d, already deleted %d, want 2 and 1", result.Deleted, result.AlreadyDeleted)
+	}
+	if len(log.Records()) != 2 {
+		t.Errorf("logged %d deletions, want 2", len(log.Records()))
+	}
+	for _, want := range []string{"v1 was already deleted", "Already deleted: 1 components"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output doesn't contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestDeleteRetriesRateLimitedRequests(t *testing.T) {
+	mock := mockRegistry(numbered("app", 2))
+	attempts := 0
+	mock.DeleteComponentFunc = func(string) error {
+		attempts++
+		if attempts == 1 {
+			return &nexus.APIError{StatusCode: 429}
+		}
+		return nil
+	}
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`)
+	engine, log := newTestEngine(mock, cfg, Options{})
+	execute(t, engine)
+
+	if got := mock.CallsTo("DeleteComponent"); len(got) != 2 || got[0] != "app:v1" || got[1] != "app:v1" {
+		t.Errorf("deletions %v, want app:v1 retried once", got)
+	}
+	if len(log.Records()) != 1 {
+		t.Errorf("logged %d deletions, want 1", len(log.Records()))
+	}
+}
+
+func TestAIMDLimiter(t *testing.T) {
+	l := newAIMDLimiter(4)
+
+	// Throttled requests halve the limit
+	l.acquire()
+	l.release(true)
+	if l.limit != 2 {
+		t.Fatalf("limit after throttling = %d, want 2", l.limit)
+	}
+
+	// A window of successes raises it by one, up to the maximum
+	for _, want := range []int{2, 3, 3, 3, 4, 4, 4, 4, 4} {
+		l.acquire()
+		l.release(false)
+		if l.limit != want {
+			t.Fatalf("limit = %d, want %d", l.limit, want)
+		}
+	}
+}
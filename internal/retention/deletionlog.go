@@ -0,0 +1,109 @@
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"nexus-retention-policy/internal/config"
+	"nexus-retention-policy/internal/logger"
+)
+
+// retrier is implemented by errors of deletion loggers that can retry only
+// the part of a write that failed, e.g. the sinks of a fanout.
+type retrier interface {
+	Retry() error
+}
+
+// unloggedDeletion is a deletion whose log write failed, with the error of
+// its last attempt.
+type unloggedDeletion struct {
+	record logger.DeletionRecord
+	err    error
+}
+
+// retry writes the record again. Loggers that wrote part of it retry only
+// the rest, so no sink records it twice.
+func (u *unloggedDeletion) retry(log DeletionLogger) error {
+	var r retrier
+	if errors.As(u.err, &r) {
+		u.err = r.Retry()
+	} else {
+		u.err = log.LogDeletion(u.record)
+	}
+	return u.err
+}
+
+// logDeletion records a deletion with the deletion logger. A failed write
+// is handled according to log_failure: it is reported as an error of the
+// run, stops the run, or is kept to be retried with the next deletion and at
+// the end of the run.
+func (p *PolicyEngine) logDeletion(record logger.DeletionRecord) {
+	// Retry earlier records first, so the log stays in order
+	if len(p.unlogged) > 0 && p.retryUnlogged() != nil {
+		p.unlogged = append(p.unlogged, unloggedDeletion{record: record})
+		return
+	}
+
+	err := p.logger.LogDeletion(record)
+	if err == nil {
+		return
+	}
+
+	subject := fmt.Sprintf("deletion log %s/%s:%s", record.Repository, record.ImageName, record.Tag)
+	switch p.config.LogFailure {
+	case config.LogFailureBufferRetry:
+		fmt.Printf("  ⚠️  Failed to log deletion of %s/%s:%s, retrying later: %v\n", record.Repository, record.ImageName, record.Tag, err)
+		p.unlogged = append(p.unlogged, unloggedDeletion{record: record, err: err})
+	case config.LogFailureAbort:
+		p.errors.add(subject, err)
+		if p.logAbort == nil {
+			p.logAbort = &GuardrailError{Reason: fmt.Sprintf("failed to log deletion of %s/%s:%s: %v", record.Repository, record.ImageName, record.Tag, err)}
+		}
+	default:
+		p.errors.add(subject, err)
+		fmt.Printf("  ⚠️  Failed to log deletion of %s/%s:%s: %v\n", record.Repository, record.ImageName, record.Tag, err)
+	}
+}
+
+// retryUnlogged writes the records of failed writes in order, stopping at
+// the first failure.
+func (p *PolicyEngine) retryUnlogged() error {
+	for len(p.unlogged) > 0 {
+		if err := p.unlogged[0].retry(p.logger); err != nil {
+			return err
+		}
+		p.unlogged = p.unlogged[1:]
+	}
+	return nil
+}
+
+// flushUnlogged retries the records of failed writes at the end of a run.
+// Records that still can't be written are printed, so they can be added to
+// the log by hand, and reported as errors of the run.
+func (p *PolicyEngine) flushUnlogged() {
+	err := p.retryUnlogged()
+	if err == nil {
+		return
+	}
+
+	fmt.Printf("\n⚠️  %d deletions could not be logged: %v\n", len(p.unlogged), err)
+	for _, u := range p.unlogged {
+		record := u.record
+		fmt.Printf("   %s %s/%s:%s (component %s, rule %s)\n", record.Timestamp.Format(time.RFC3339), record.Repository, record.ImageName, record.Tag, record.ComponentID, record.Rule)
+		p.errors.add(fmt.Sprintf("deletion log %s/%s:%s", record.Repository, record.ImageName, record.Tag), err)
+	}
+	p.unlogged = nil
+}
+
+// abortErr returns why the current run must stop early: an open circuit
+// breaker or, with log_failure: abort, a failed deletion log write.
+func (p *PolicyEngine) abortErr() error {
+	if err := p.breaker.err(); err != nil {
+		return err
+	}
+	if p.logAbort != nil {
+		return p.logAbort
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package retention
+
+import (
+	"errors"
+	"testing"
+
+	"nexus-retention-policy/internal/events"
+	"nexus-retention-policy/internal/nexus"
+)
+
+// recordingSink records the tags of published deletions, failing the first
+// failures of them.
+type recordingSink struct {
+	failures int
+	tags     []string
+}
+
+func (s *recordingSink) Publish(event events.Event) error {
+	if event.Type != events.TypeDeletion {
+		return nil
+	}
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("unavailable")
+	}
+	s.tags = append(s.tags, event.Deletion.Tag)
+	return nil
+}
+
+func (s *recordingSink) Close() error { return nil }
+
+func TestBufferRetryRepublishesToFailedSinksOnly(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), numbered("app", 4)...)
+
+	working, failing := &recordingSink{}, &recordingSink{failures: 2}
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 1}]
+log_failure: buffer-retry
+delete_concurrency: 1
+`)
+	engine := NewPolicyEngine(fake, cfg, events.NewFanout(working, failing), Options{})
+	execute(t, engine)
+
+	want := []string{"v3", "v2", "v1"}
+	if !equalStrings(working.tags, want) {
+		t.Errorf("working sink received %v, want each deletion once: %v", working.tags, want)
+	}
+	if !equalStrings(failing.tags, want) {
+		t.Errorf("failing sink received %v, want %v after retries", failing.tags, want)
+	}
+}
@@ -0,0 +1,68 @@
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// PlanDelta lists the differences between two consecutive plans.
+type PlanDelta struct {
+	// Added are deletions that weren't planned in the previous run
+	Added []PlannedDeletion
+	// Removed are previously planned deletions that are no longer planned
+	Removed []PlannedDeletion
+}
+
+// Diff compares the plan with a previous one by component ID.
+func (pl *Plan) Diff(previous *Plan) PlanDelta {
+	before := make(map[string]bool, len(previous.Deletions))
+	for _, d := range previous.Deletions {
+		before[d.ComponentID] = true
+	}
+	after := make(map[string]bool, len(pl.Deletions))
+	for _, d := range pl.Deletions {
+		after[d.ComponentID] = true
+	}
+
+	var delta PlanDelta
+	for _, d := range pl.Deletions {
+		if !before[d.ComponentID] {
+			delta.Added = append(delta.Added, d)
+		}
+	}
+	for _, d := range previous.Deletions {
+		if !after[d.ComponentID] {
+			delta.Removed = append(delta.Removed, d)
+		}
+	}
+	return delta
+}
+
+// reportDelta prints the changes since the plan stored at path and replaces
+// it with the current plan.
+func (p *PolicyEngine) reportDelta(plan *Plan, path string) error {
+	previous, err := LoadPlan(path)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Println("\n📋 No previous dry run to compare with")
+		return plan.Save(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	delta := plan.Diff(previous)
+	fmt.Printf("\n📋 Changes since last dry run (%s)\n", previous.CreatedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("   Newly eligible for deletion: %d\n", len(delta.Added))
+	for _, d := range delta.Added {
+		fmt.Printf("     + %s/%s:%s (%s)\n", d.Repository, d.ImageName, d.Tag, d.Rule)
+	}
+	fmt.Printf("   No longer eligible: %d\n", len(delta.Removed))
+	if p.options.Verbosity >= VerbosityTag {
+		for _, d := range delta.Removed {
+			fmt.Printf("     - %s/%s:%s (%s)\n", d.Repository, d.ImageName, d.Tag, d.Rule)
+		}
+	}
+
+	return plan.Save(path)
+}
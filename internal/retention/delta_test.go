@@ -0,0 +1,59 @@
+package retention
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+func TestPlanDiff(t *testing.T) {
+	previous, current := &Plan{}, &Plan{}
+	for _, comp := range tags("app", "v3", "v2") {
+		previous.add("docker-hosted", "app", "all", comp)
+	}
+	for _, comp := range tags("app", "v2", "v1") {
+		current.add("docker-hosted", "app", "all", comp)
+	}
+
+	delta := current.Diff(previous)
+	if len(delta.Added) != 1 || delta.Added[0].ComponentID != "app:v1" {
+		t.Errorf("Added = %+v, want app:v1", delta.Added)
+	}
+	if len(delta.Removed) != 1 || delta.Removed[0].ComponentID != "app:v3" {
+		t.Errorf("Removed = %+v, want app:v3", delta.Removed)
+	}
+}
+
+func TestDryRunReportsDelta(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "last_plan.json")
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), numbered("app", 4)...)
+
+	dryRun := func(keep int) string {
+		cfg := parseConfig(t, fmt.Sprintf("rules: [{name: all, regex: \".*\", keep: %d}]\nlast_plan_file: %s\n", keep, path))
+		engine, _ := newTestEngine(fake, cfg, Options{DryRun: true})
+		return captureStdout(t, func() { execute(t, engine) })
+	}
+
+	if out := dryRun(3); !strings.Contains(out, "No previous dry run to compare with") {
+		t.Errorf("first dry run compared with a previous one:\n%s", out)
+	}
+	out := dryRun(2)
+	for _, want := range []string{"Newly eligible for deletion: 1", "+ docker-hosted/app:v2 (all)", "No longer eligible: 0"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("second dry run doesn't report %q:\n%s", want, out)
+		}
+	}
+
+	// The plan of the second dry run is stored for the next one
+	plan, err := LoadPlan(path)
+	if err != nil {
+		t.Fatalf("LoadPlan: %v", err)
+	}
+	if len(plan.Deletions) != 2 {
+		t.Errorf("stored plan has %d deletions, want 2", len(plan.Deletions))
+	}
+}
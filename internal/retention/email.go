@@ -0,0 +1,191 @@
+package retention
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// maxEmailDeletions limits the tags listed in an email summary. Counts and
+// sizes still cover all deletions.
+const maxEmailDeletions = 500
+
+// emailRepository is a repository listed in an email summary.
+type emailRepository struct {
+	name    string
+	deleted int
+	bytes   int64
+	err     string
+	// tags lists "image:tag" references, with a note for deletions that
+	// weren't made
+	tags []string
+}
+
+// emailReport sends the summary of the run's deletions to the configured
+// recipients. Runs without deletions send nothing. A failed send is only
+// reported, since it doesn't affect the run.
+func (p *PolicyEngine) emailReport() {
+	email := p.config.Email
+	if email.SMTPHost == "" || p.result.Deleted == 0 {
+		return
+	}
+
+	msg, err := p.emailMessage(time.Now())
+	if err != nil {
+		fmt.Printf("⚠️  Failed to build email summary: %v\n", err)
+		return
+	}
+
+	var auth smtp.Auth
+	if email.Username != "" {
+		host, _, _ := net.SplitHostPort(email.SMTPHost)
+		auth = smtp.PlainAuth("", email.Username, email.Password, host)
+	}
+	if err := smtp.SendMail(email.SMTPHost, auth, email.From, email.To, msg); err != nil {
+		fmt.Printf("⚠️  Failed to send email summary: %v\n", err)
+		return
+	}
+	fmt.Printf("📧 Email summary sent to %s\n", strings.Join(email.To, ", "))
+}
+
+// emailRepositories collects the deletions of the run per repository, and
+// the number of tags beyond maxEmailDeletions that aren't listed.
+// Repositories without deletions are left out unless they failed.
+func (p *PolicyEngine) emailRepositories() (repos []emailRepository, omitted int) {
+	listed := 0
+	for _, result := range p.result.Repositories {
+		if result.Deleted == 0 && result.Error == "" {
+			continue
+		}
+		repo := emailRepository{name: result.Name, deleted: result.Deleted, bytes: result.ReclaimedBytes, err: result.Error}
+
+		for _, image := range result.Images {
+			failed := make(map[string]bool, len(image.Failed))
+			for _, id := range image.Failed {
+				failed[id] = true
+			}
+			ruleDryRun := false
+			if rule := p.config.Rule(image.Rule); rule != nil {
+				ruleDryRun = rule.DryRun
+			}
+
+			for _, d := range image.Decisions {
+				if d.Action != ActionDelete {
+					continue
+				}
+				listed++
+				if listed > maxEmailDeletions {
+					omitted++
+					continue
+				}
+				tag := p.reportedName(groupName(d.Component)) + ":" + displayTag(d.Component)
+				switch {
+				case failed[d.Component.ID]:
+					tag += " (failed)"
+				case ruleDryRun && !p.dryRun:
+					tag += " (dry-run rule, not deleted)"
+				}
+				repo.tags = append(repo.tags, tag)
+			}
+		}
+		repos = append(repos, repo)
+	}
+	return repos, omitted
+}
+
+// emailMessage builds the summary as a multipart message with plain text
+// and HTML versions.
+func (p *PolicyEngine) emailMessage(now time.Time) ([]byte, error) {
+	r := p.result
+	registry := p.config.Nexus.URL
+
+	verb := "deleted"
+	subject := fmt.Sprintf("Nexus retention: %d components deleted on %s", r.Deleted, registry)
+	intro := fmt.Sprintf("Execution %s on %s deleted %d components.", r.ExecutionID, registry, r.Deleted)
+	if r.DryRun {
+		verb = "would be deleted"
+		subject = fmt.Sprintf("Nexus retention: %d deletions planned on %s", r.Deleted, registry)
+		intro = fmt.Sprintf("Dry run %s on %s would delete %d components.", r.ExecutionID, registry, r.Deleted)
+	}
+
+	var notes []string
+	if r.Aborted {
+		notes = append(notes, "The run was aborted early, so not all repositories were processed.")
+	}
+	if errs := p.errors.total(); errs > 0 {
+		notes = append(notes, fmt.Sprintf("%d requests failed, see the run's output for details.", errs))
+	}
+
+	repos, omitted := p.emailRepositories()
+
+	var text, htmlBody strings.Builder
+	fmt.Fprintf(&text, "%s\n", intro)
+	fmt.Fprintf(&htmlBody, "<p>%s</p>\n", html.EscapeString(intro))
+	for _, note := range notes {
+		fmt.Fprintf(&text, "%s\n", note)
+		fmt.Fprintf(&htmlBody, "<p><strong>%s</strong></p>\n", html.EscapeString(note))
+	}
+
+	for _, repo := range repos {
+		if repo.err != "" {
+			fmt.Fprintf(&text, "\n%s: %s\n", repo.name, repo.err)
+			fmt.Fprintf(&htmlBody, "<h3>%s</h3>\n<p>%s</p>\n", html.EscapeString(repo.name), html.EscapeString(repo.err))
+			continue
+		}
+		summary := fmt.Sprintf("%d components %s, %s", repo.deleted, verb, FormatBytes(repo.bytes))
+		fmt.Fprintf(&text, "\n%s: %s\n", repo.name, summary)
+		fmt.Fprintf(&htmlBody, "<h3>%s</h3>\n<p>%s</p>\n<ul>\n", html.EscapeString(repo.name), html.EscapeString(summary))
+		for _, tag := range repo.tags {
+			fmt.Fprintf(&text, "  %s\n", tag)
+			fmt.Fprintf(&htmlBody, "<li>%s</li>\n", html.EscapeString(tag))
+		}
+		htmlBody.WriteString("</ul>\n")
+	}
+	if omitted > 0 {
+		fmt.Fprintf(&text, "\n... and %d more\n", omitted)
+		fmt.Fprintf(&htmlBody, "<p>... and %d more</p>\n", omitted)
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", text.String()},
+		{"text/html; charset=utf-8", "<html><body>\n" + htmlBody.String() + "</body></html>\n"},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode email: %w", err)
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("failed to encode email: %w", err)
+		}
+		if err := qp.Close(); err != nil {
+			return nil, fmt.Errorf("failed to encode email: %w", err)
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode email: %w", err)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", p.config.Email.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(p.config.Email.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
@@ -0,0 +1,110 @@
+package retention
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"nexus-retention-policy/internal/logger"
+	"nexus-retention-policy/internal/nexus"
+)
+
+// emailParts returns the decoded plain text and HTML parts of a message.
+func emailParts(t *testing.T, msg []byte) (text, html string) {
+	t.Helper()
+	m, err := mail.ReadMessage(bytes.NewReader(msg))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	_, params, _ := strings.Cut(m.Header.Get("Content-Type"), "boundary=")
+	parts := multipart.NewReader(m.Body, params)
+	var bodies []string
+	for {
+		part, err := parts.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+		body, _ := io.ReadAll(quotedprintable.NewReader(part))
+		bodies = append(bodies, string(body))
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("message has %d parts, want 2", len(bodies))
+	}
+	return bodies[0], bodies[1]
+}
+
+func TestEmailMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		redact bool
+		want   string
+	}{
+		{name: "names", want: "secret-app:v1"},
+		{name: "redacted", redact: true, want: logger.RedactImageName("secret-app") + ":v1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := nexus.NewFakeClient()
+			fake.AddRepository(dockerRepo("docker-hosted"), numbered("secret-app", 2)...)
+
+			yaml := `rules: [{name: all, regex: ".*", keep: 1}]
+email: {smtp_host: "127.0.0.1:1", from: "retention@example.com", to: ["ops@example.com"]}
+`
+			if tt.redact {
+				yaml += "redact_image_names: true\n"
+			}
+			engine, _ := newTestEngine(fake, parseConfig(t, yaml), Options{DryRun: true})
+			execute(t, engine)
+
+			msg, err := engine.emailMessage(time.Now())
+			if err != nil {
+				t.Fatalf("emailMessage: %v", err)
+			}
+			text, html := emailParts(t, msg)
+			for _, body := range []string{text, html} {
+				if !strings.Contains(body, tt.want) {
+					t.Errorf("body doesn't list %s:\n%s", tt.want, body)
+				}
+				if tt.redact && strings.Contains(body, "secret-app") {
+					t.Errorf("body leaks the image name:\n%s", body)
+				}
+			}
+		})
+	}
+}
+
+func TestProtectedOverrideReportRedactsNames(t *testing.T) {
+	report := filepath.Join(t.TempDir(), "overrides.csv")
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), tags("secret-app", "v2", "stable")...)
+
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 1}]
+protected_tags: ["stable"]
+redact_image_names: true
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{DryRun: true, ProtectedOverrideReport: report})
+	execute(t, engine)
+
+	data, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	if !strings.Contains(string(data), logger.RedactImageName("secret-app")+",stable") {
+		t.Errorf("override of stable not reported with the redacted name:\n%s", data)
+	}
+	if strings.Contains(string(data), "secret-app,") {
+		t.Errorf("report leaks the image name:\n%s", data)
+	}
+}
@@ -0,0 +1,409 @@
+package retention
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"nexus-retention-policy/internal/config"
+	"nexus-retention-policy/internal/logger"
+	"nexus-retention-policy/internal/nexus"
+)
+
+// testNexus is the registry section of test configurations.
+const testNexus = `
+nexus:
+  url: "http://nexus.test"
+  username: "admin"
+  password: "secret"
+`
+
+// testTime is the push time of the newest component built by tags.
+var testTime = time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+
+// parseConfig parses a test configuration, prefixed with the registry
+// section.
+func parseConfig(t *testing.T, yaml string) *config.Config {
+	t.Helper()
+	cfg, err := config.Parse([]byte(testNexus + yaml))
+	if err != nil {
+		t.Fatalf("parse config: %v", err)
+	}
+	return cfg
+}
+
+// dockerRepo returns a hosted Docker repository.
+func dockerRepo(name string) nexus.Repository {
+	return nexus.Repository{Name: name, Format: "docker", Type: "hosted"}
+}
+
+// tags returns components of an image with the given tags, the first one
+// pushed most recently and each following one an hour earlier.
+func tags(image string, versions ...string) []nexus.Component {
+	components := make([]nexus.Component, len(versions))
+	for i, version := range versions {
+		components[i] = component(image, version, testTime.Add(-time.Duration(i)*time.Hour))
+	}
+	return components
+}
+
+// component returns a Docker component with one manifest asset modified at
+// the given time.
+func component(image, version string, modified time.Time) nexus.Component {
+	id := image + ":" + version
+	return nexus.Component{
+		ID:      id,
+		Name:    image,
+		Version: version,
+		Format:  "docker",
+		Assets: []nexus.Asset{{
+			ID:           id + "#manifest",
+			Path:         fmt.Sprintf("v2/%s/manifests/%s", image, version),
+			LastModified: modified,
+			FileSize:     1024,
+			Checksum:     map[string]string{"sha256": fmt.Sprintf("%x", id)},
+		}},
+	}
+}
+
+// numbered returns n components of an image tagged v<n> down to v1, the
+// highest pushed most recently.
+func numbered(image string, n int) []nexus.Component {
+	versions := make([]string, n)
+	for i := range versions {
+		versions[i] = fmt.Sprintf("v%d", n-i)
+	}
+	return tags(image, versions...)
+}
+
+// newTestEngine returns an engine on the registry that logs to memory.
+func newTestEngine(registry Registry, cfg *config.Config, opts Options) (*PolicyEngine, *logger.MemoryLogger) {
+	log := logger.NewMemoryLogger()
+	return NewPolicyEngine(registry, cfg, log, opts), log
+}
+
+// execute runs the engine and fails the test on errors.
+func execute(t *testing.T, engine *PolicyEngine) *RunResult {
+	t.Helper()
+	result, err := engine.Execute()
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	return result
+}
+
+// deletedTags returns the sorted "image:tag" IDs deleted from the fake.
+func deletedTags(fake *nexus.FakeClient) []string {
+	deleted := append([]string(nil), fake.Deleted...)
+	sort.Strings(deleted)
+	return deleted
+}
+
+// decisionsOf returns the action of every decided tag of a run by
+// component ID.
+func decisionsOf(result *RunResult) map[string]Action {
+	actions := make(map[string]Action)
+	for _, repo := range result.Repositories {
+		for _, image := range repo.Images {
+			for _, d := range image.Decisions {
+				actions[d.Component.ID] = d.Action
+			}
+		}
+	}
+	return actions
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestExecuteKeepCounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		images  [][]nexus.Component
+		deleted []string
+	}{
+		{
+			name:    "keeps newest",
+			rules:   `{name: all, regex: ".*", keep: 2}`,
+			images:  [][]nexus.Component{numbered("app", 4)},
+			deleted: []string{"app:v1", "app:v2"},
+		},
+		{
+			name:   "keep above tag count",
+			rules:  `{name: all, regex: ".*", keep: 5}`,
+			images: [][]nexus.Component{numbered("app", 3)},
+		},
+		{
+			name:    "first matching rule wins",
+			rules:   `{name: prod, regex: "^prod-", keep: 3}, {name: all, regex: ".*", keep: 1}`,
+			images:  [][]nexus.Component{numbered("prod-api", 4), numbered("dev-api", 3)},
+			deleted: []string{"dev-api:v1", "dev-api:v2", "prod-api:v1"},
+		},
+		{
+			name:    "unmatched images are skipped",
+			rules:   `{name: prod, regex: "^prod-", keep: 1}`,
+			images:  [][]nexus.Component{numbered("prod-api", 2), numbered("dev-api", 3)},
+			deleted: []string{"prod-api:v1"},
+		},
+		{
+			name:    "keep 0 with allow_delete_all",
+			rules:   `{name: all, regex: ".*", keep: 0, allow_delete_all: true}`,
+			images:  [][]nexus.Component{numbered("app", 2)},
+			deleted: []string{"app:v1", "app:v2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := nexus.NewFakeClient()
+			var components []nexus.Component
+			for _, image := range tt.images {
+				components = append(components, image...)
+			}
+			fake.AddRepository(dockerRepo("docker-hosted"), components...)
+
+			cfg := parseConfig(t, "rules: ["+tt.rules+"]\n")
+			engine, log := newTestEngine(fake, cfg, Options{})
+			result := execute(t, engine)
+
+			if got := deletedTags(fake); !equalStrings(got, tt.deleted) {
+				t.Errorf("deleted %v, want %v", got, tt.deleted)
+			}
+			if result.Deleted != len(tt.deleted) {
+				t.Errorf("result.Deleted = %d, want %d", result.Deleted, len(tt.deleted))
+			}
+			if got := len(log.Records()); got != len(tt.deleted) {
+				t.Errorf("logged %d deletions, want %d", got, len(tt.deleted))
+			}
+		})
+	}
+}
+
+func TestExecuteOrdersByLastModified(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	// Listed out of order; v1 was pushed last
+	fake.AddRepository(dockerRepo("docker-hosted"),
+		component("app", "v3", testTime.Add(-3*time.Hour)),
+		component("app", "v1", testTime),
+		component("app", "v2", testTime.Add(-time.Hour)),
+	)
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	execute(t, engine)
+
+	if got, want := deletedTags(fake), []string{"app:v2", "app:v3"}; !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+}
+
+func TestExecuteDryRunDeletesNothing(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), numbered("app", 3)...)
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`)
+	engine, log := newTestEngine(fake, cfg, Options{DryRun: true})
+	result := execute(t, engine)
+
+	if len(fake.Deleted) != 0 {
+		t.Errorf("dry run deleted %v", fake.Deleted)
+	}
+	if !result.DryRun || result.Deleted != 2 {
+		t.Errorf("result DryRun=%t Deleted=%d, want true and 2", result.DryRun, result.Deleted)
+	}
+	for _, record := range log.Records() {
+		if !record.DryRun {
+			t.Errorf("record %s:%s not marked dry run", record.ImageName, record.Tag)
+		}
+	}
+	if got := len(log.Records()); got != 2 {
+		t.Errorf("logged %d dry-run deletions, want 2", got)
+	}
+}
+
+func TestExecuteProtectedTags(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), tags("app", "v4", "v3", "stable", "v1")...)
+
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 1}]
+protected_tags: ["stable"]
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	result := execute(t, engine)
+
+	if got, want := deletedTags(fake), []string{"app:v1", "app:v3"}; !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+	if got := decisionsOf(result)["app:stable"]; got != ActionProtected {
+		t.Errorf("stable decided %s, want %s", got, ActionProtected)
+	}
+}
+
+func TestPlanImageDeletesUntagged(t *testing.T) {
+	components := []nexus.Component{
+		component("app", "", testTime),
+		component("app", "v3", testTime.Add(-time.Hour)),
+		component("app", "", testTime.Add(-2*time.Hour)),
+		component("app", "v2", testTime.Add(-3*time.Hour)),
+		component("app", "v1", testTime.Add(-4*time.Hour)),
+	}
+	components[0].ID, components[2].ID = "app@new", "app@old"
+
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 2}]
+delete_untagged: true
+`)
+	engine, _ := newTestEngine(nil, cfg, Options{})
+	decisions := engine.planImage("docker-hosted", &cfg.Rules[0], components)
+
+	// Untagged manifests are deleted without filling keep slots
+	want := []string{"DELETE app@new", "KEEP app:v3", "DELETE app@old", "KEEP app:v2", "DELETE app:v1"}
+	var got []string
+	for _, d := range decisions {
+		got = append(got, string(d.Action)+" "+d.Component.ID)
+	}
+	if !equalStrings(got, want) {
+		t.Errorf("decisions %v, want %v", got, want)
+	}
+}
+
+func TestExecuteDeletesUntagged(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), append(numbered("app", 3), component("app", "", testTime.Add(time.Hour)))...)
+
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 2}]
+delete_untagged: true
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	execute(t, engine)
+
+	// The untagged manifest doesn't take one of the two keep slots
+	if got, want := deletedTags(fake), []string{"app:", "app:v1"}; !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+}
+
+func TestExecuteDeletionRecords(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), numbered("app", 2)...)
+
+	cfg := parseConfig(t, `rules: [{name: old, regex: ".*", keep: 1}]`)
+	engine, log := newTestEngine(fake, cfg, Options{})
+	result := execute(t, engine)
+
+	records := log.Records()
+	if len(records) != 1 {
+		t.Fatalf("logged %d records, want 1", len(records))
+	}
+	r := records[0]
+	if r.ExecutionID != result.ExecutionID || r.Repository != "docker-hosted" || r.ImageName != "app" || r.Tag != "v1" || r.ComponentID != "app:v1" || r.Rule != "old" || r.DryRun {
+		t.Errorf("unexpected record %+v", r)
+	}
+}
+
+func TestExecuteExecutionID(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-a"), numbered("app", 3)...)
+	fake.AddRepository(dockerRepo("docker-b"), numbered("api", 3)...)
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`)
+	engine, log := newTestEngine(fake, cfg, Options{})
+	first := execute(t, engine)
+
+	records := log.Records()
+	if first.ExecutionID == "" || len(records) != 4 {
+		t.Fatalf("run %q logged %d records, want an execution ID and 4 records", first.ExecutionID, len(records))
+	}
+	for _, r := range records {
+		if r.ExecutionID != first.ExecutionID {
+			t.Errorf("record %s:%s has execution ID %q, want %q", r.ImageName, r.Tag, r.ExecutionID, first.ExecutionID)
+		}
+	}
+
+	// The next run of the same engine gets a new ID
+	fake.Components["docker-a"] = append(fake.Components["docker-a"], component("app", "v0", testTime.Add(-time.Hour*24)))
+	second := execute(t, engine)
+	records = log.Records()[4:]
+	if second.ExecutionID == first.ExecutionID || len(records) != 1 || records[0].ExecutionID != second.ExecutionID {
+		t.Errorf("second run %q logged %+v, want a new execution ID", second.ExecutionID, records)
+	}
+}
+
+func TestExecuteSkipsNonHostedRepositories(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(nexus.Repository{Name: "docker-proxy", Format: "docker", Type: "proxy"}, numbered("app", 3)...)
+	fake.AddRepository(nexus.Repository{Name: "npm-hosted", Format: "npm", Type: "hosted"}, numbered("lib", 3)...)
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	result := execute(t, engine)
+
+	if len(fake.Deleted) != 0 || len(result.Repositories) != 0 {
+		t.Errorf("processed %d repositories and deleted %v, want none", len(result.Repositories), fake.Deleted)
+	}
+}
+
+func TestExecuteWarnsAboutUncleanableRepositories(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), numbered("app", 3)...)
+	fake.AddRepository(nexus.Repository{Name: "docker-proxy", Format: "docker", Type: "proxy"}, numbered("app", 3)...)
+	fake.AddRepository(nexus.Repository{Name: "docker-group", Format: "docker", Type: "group"})
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1, repositories: [docker-hosted, docker-proxy, docker-group, docker-gone]}]`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	out := captureStdout(t, func() { execute(t, engine) })
+
+	for _, want := range []string{
+		"Repository 'docker-proxy' referenced in rules is a docker proxy repository and will not be cleaned",
+		"Repository 'docker-group' referenced in rules is a docker group repository and will not be cleaned",
+		"Repository 'docker-gone' referenced in rules was not found",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output doesn't contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "'docker-hosted' referenced in rules") {
+		t.Errorf("warned about the hosted repository:\n%s", out)
+	}
+	if got, want := deletedTags(fake), []string{"app:v1", "app:v2"}; !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+}
+
+func TestPlanMatchesExecute(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), append(numbered("app", 4), numbered("api", 3)...)...)
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 2}]`)
+	engine, _ := newTestEngine(fake, cfg, Options{DryRun: true})
+	plan, _, err := engine.Plan()
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	var planned []string
+	for _, d := range plan.Deletions {
+		planned = append(planned, d.ComponentID)
+	}
+	sort.Strings(planned)
+
+	engine, _ = newTestEngine(fake, cfg, Options{})
+	execute(t, engine)
+
+	if got := deletedTags(fake); !equalStrings(planned, got) {
+		t.Errorf("planned %v, executed %v", planned, got)
+	}
+}
@@ -0,0 +1,99 @@
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// ComponentDetailer is implemented by registries that can fetch single
+// components. Only Nexus supports enrich_details.
+type ComponentDetailer interface {
+	GetComponent(componentID string) (nexus.Component, error)
+}
+
+// errDetailsUnsupported is returned for detail fetches on registries that
+// don't implement ComponentDetailer.
+var errDetailsUnsupported = errors.New("registry does not support fetching component details")
+
+// getComponent fetches a component's details if the registry supports it.
+func getComponent(registry Registry, componentID string) (nexus.Component, error) {
+	detailer, ok := registry.(ComponentDetailer)
+	if !ok {
+		return nexus.Component{}, errDetailsUnsupported
+	}
+	return detailer.GetComponent(componentID)
+}
+
+// needsDetails reports whether the listing of a component lacks the asset
+// timestamps or sizes that its details may provide.
+func needsDetails(comp nexus.Component) bool {
+	if len(comp.Assets) == 0 {
+		return true
+	}
+	for _, asset := range comp.Assets {
+		if asset.LastModified.IsZero() || asset.FileSize == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// enrichDetails replaces the components whose listing lacks timestamps or
+// sizes with their details, fetching up to enrich_concurrency at a time.
+// Without timestamps components would sort oldest, so a failed fetch is
+// returned for the caller to skip the repository rather than plan with
+// incomplete metadata.
+func (p *PolicyEngine) enrichDetails(components []nexus.Component) error {
+	if !p.config.EnrichDetails {
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	failed, fetched := 0, 0
+	slots := make(chan struct{}, p.config.EnrichConcurrency)
+
+	for i, comp := range components {
+		if !needsDetails(comp) {
+			continue
+		}
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int, id string) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			details, err := getComponent(p.client, id)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed++
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			components[i] = details
+			fetched++
+		}(i, comp.ID)
+	}
+	wg.Wait()
+
+	if errors.Is(firstErr, errDetailsUnsupported) {
+		fmt.Println("  ⚠️  Registry can't fetch component details, planning with the listed metadata")
+		return nil
+	}
+	if firstErr != nil {
+		return fmt.Errorf("failed to fetch details of %d components: %w", failed, firstErr)
+	}
+	if fetched > 0 && p.options.Verbosity >= VerbosityImage {
+		fmt.Printf("  Fetched details of %d components\n", fetched)
+	}
+	return nil
+}
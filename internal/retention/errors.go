@@ -0,0 +1,133 @@
+package retention
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// Error categories used in the run summary.
+const (
+	errAuth      = "auth"
+	errNotFound  = "not-found"
+	errTimeout   = "timeout"
+	errRateLimit = "rate-limit"
+	errServer    = "server"
+	errOther     = "other"
+)
+
+var errorCategories = []string{errAuth, errNotFound, errTimeout, errRateLimit, errServer, errOther}
+
+// maxErrorExamples is the number of examples shown per category.
+const maxErrorExamples = 3
+
+// categorizeError maps an error to a summary category.
+func categorizeError(err error) string {
+	if errors.Is(err, errDeleteDenied) {
+		return errAuth
+	}
+
+	var apiErr *nexus.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == 401 || apiErr.StatusCode == 403:
+			return errAuth
+		case apiErr.StatusCode == 404:
+			return errNotFound
+		case apiErr.StatusCode == 408 || apiErr.StatusCode == 504:
+			return errTimeout
+		case apiErr.StatusCode == 429:
+			return errRateLimit
+		case apiErr.StatusCode >= 500:
+			return errServer
+		}
+		return errOther
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return errTimeout
+	}
+	return errOther
+}
+
+// PartialFailureError is returned by a run that completed although some
+// requests failed.
+type PartialFailureError struct {
+	Failed int
+}
+
+func (e *PartialFailureError) Error() string {
+	return fmt.Sprintf("run completed with %d failed requests", e.Failed)
+}
+
+// GuardrailError is returned when a safety check stopped a run.
+type GuardrailError struct {
+	Reason string
+}
+
+func (e *GuardrailError) Error() string {
+	return e.Reason
+}
+
+// errorSummary aggregates errors by category for the run summary.
+type errorSummary struct {
+	counts   map[string]int
+	examples map[string][]string
+}
+
+func newErrorSummary() *errorSummary {
+	return &errorSummary{
+		counts:   make(map[string]int),
+		examples: make(map[string][]string),
+	}
+}
+
+// add records an error and returns its category.
+func (s *errorSummary) add(subject string, err error) string {
+	category := categorizeError(err)
+	s.counts[category]++
+	if len(s.examples[category]) < maxErrorExamples {
+		s.examples[category] = append(s.examples[category], fmt.Sprintf("%s: %v", subject, err))
+	}
+	return category
+}
+
+func (s *errorSummary) total() int {
+	total := 0
+	for _, count := range s.counts {
+		total += count
+	}
+	return total
+}
+
+// err returns a PartialFailureError if any error was recorded.
+func (s *errorSummary) err() error {
+	if s.total() == 0 {
+		return nil
+	}
+	return &PartialFailureError{Failed: s.total()}
+}
+
+func (s *errorSummary) print() {
+	if s.total() == 0 {
+		return
+	}
+
+	fmt.Printf("   Errors: %d\n", s.total())
+	for _, category := range errorCategories {
+		count := s.counts[category]
+		if count == 0 {
+			continue
+		}
+		fmt.Printf("     %s: %d\n", category, count)
+		for _, example := range s.examples[category] {
+			fmt.Printf("       - %s\n", example)
+		}
+		if count > len(s.examples[category]) {
+			fmt.Printf("       ... and %d more\n", count-len(s.examples[category]))
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// timeoutError is a net.Error of a timed out request.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestCategorizeError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&nexus.APIError{StatusCode: 401}, errAuth},
+		{&nexus.APIError{StatusCode: 403}, errAuth},
+		{fmt.Errorf("docker-hosted: %w", errDeleteDenied), errAuth},
+		{&nexus.APIError{StatusCode: 404}, errNotFound},
+		{&nexus.APIError{StatusCode: 504}, errTimeout},
+		{fmt.Errorf("failed to list: %w", timeoutError{}), errTimeout},
+		{&nexus.APIError{StatusCode: 429}, errRateLimit},
+		{fmt.Errorf("failed to delete: %w", &nexus.APIError{StatusCode: 502}), errServer},
+		{&nexus.APIError{StatusCode: 400}, errOther},
+		{context.Canceled, errOther},
+	}
+
+	for _, tt := range tests {
+		if got := categorizeError(tt.err); got != tt.want {
+			t.Errorf("categorizeError(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestErrorSummary(t *testing.T) {
+	s := newErrorSummary()
+	if s.err() != nil {
+		t.Fatal("empty summary returned an error")
+	}
+
+	for i := 0; i < 5; i++ {
+		s.add(fmt.Sprintf("app:v%d", i), &nexus.APIError{StatusCode: 500})
+	}
+	s.add("app:v9", errors.New("unexpected"))
+
+	var partial *PartialFailureError
+	if err := s.err(); !errors.As(err, &partial) || partial.Failed != 6 {
+		t.Fatalf("err() = %v, want 6 failed requests", err)
+	}
+
+	out := captureStdout(t, s.print)
+	for _, want := range []string{"Errors: 6", "server: 5", "... and 2 more", "other: 1"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("summary doesn't contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "app:v3") {
+		t.Errorf("summary shows more than %d examples:\n%s", maxErrorExamples, out)
+	}
+}
@@ -0,0 +1,121 @@
+package retention
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"nexus-retention-policy/internal/config"
+	"nexus-retention-policy/internal/nexus"
+)
+
+// Explain prints the full decision trace for a single image without
+// deleting anything. The image is given as <repository>/<image>.
+func (p *PolicyEngine) Explain(ref string) error {
+	repoName, imageName, ok := strings.Cut(ref, "/")
+	if !ok || repoName == "" || imageName == "" {
+		return fmt.Errorf("invalid image '%s', expected <repository>/<image>", ref)
+	}
+
+	if err := p.loadProtections(); err != nil {
+		return err
+	}
+
+	if canonical := p.config.CanonicalImage(imageName); canonical != imageName {
+		fmt.Printf("%s is an alias of %s\n", imageName, canonical)
+		imageName = canonical
+	}
+	fmt.Printf("🔎 Explaining %s in repository %s\n\n", imageName, repoName)
+
+	if !p.config.IncludesRepository(repoName) {
+		fmt.Println("Repository is excluded by include_repositories/exclude_repositories")
+	}
+
+	all, err := p.client.GetComponents(repoName)
+	if err != nil {
+		return fmt.Errorf("failed to get components: %w", err)
+	}
+
+	var components []nexus.Component
+	for _, comp := range all {
+		if p.config.CanonicalImage(groupName(comp)) == imageName {
+			components = append(components, comp)
+		}
+	}
+	if len(components) == 0 {
+		return fmt.Errorf("image '%s' not found in repository %s", imageName, repoName)
+	}
+	if err := p.enrichDetails(components); err != nil {
+		return err
+	}
+
+	// Filter like processRepository, so the trace matches executions
+	if len(p.config.ExcludeBlobStores) > 0 {
+		components = p.filterBlobStores(components)
+	}
+	if p.config.FloatingTagMode == config.FloatingTagIgnore {
+		var ignored []string
+		for _, comp := range components {
+			if p.config.FloatingTag(comp.Version, config.FloatingTagIgnore) {
+				ignored = append(ignored, comp.Version)
+			}
+		}
+		if len(ignored) > 0 {
+			fmt.Printf("Ignoring floating tags: %s\n\n", strings.Join(ignored, ", "))
+		}
+		components = p.filterFloatingTags(components)
+	}
+
+	fmt.Println("Rules:")
+	if keep, ok := p.config.KeepOverrides[imageName]; ok {
+		fmt.Printf("  → keep override: keep %d (takes precedence over rules)\n", keep)
+	}
+	for _, rule := range p.config.Rules {
+		status := "no match"
+		if !rule.AppliesTo(repoName) {
+			status = "not applied to this repository"
+		} else if rule.Matches(imageName) {
+			status = "match"
+		}
+		fmt.Printf("  - %s (%s): %s\n", rule.Name, rule.Regex, status)
+	}
+
+	rule := p.matchRule(repoName, imageName, components)
+	if rule == nil {
+		fmt.Println("\nNo rule matches, the image is never cleaned")
+		return nil
+	}
+
+	basis := rule.TimeBasis
+	if basis == "" {
+		basis = config.TimeBasisLastModified
+	}
+	fmt.Printf("\nApplied rule: %s\n", rule.Name)
+	fmt.Printf("  keep: %d, thin_every: %d, time_basis: %s\n", rule.Keep, rule.ThinEvery, basis)
+	if s := rule.KeepByDownloads; s != nil {
+		fmt.Printf("  keep scaled by %d downloads (min %d, max %d at %d downloads)\n", downloadCount(components), s.MinKeep, s.MaxKeep, s.MaxDownloads)
+	}
+	if len(rule.GroupKeep) > 0 {
+		groups := make([]string, 0, len(rule.GroupKeep))
+		for group, keep := range rule.GroupKeep {
+			groups = append(groups, fmt.Sprintf("%s=%d", group, keep))
+		}
+		sort.Strings(groups)
+		fmt.Printf("  group keep: %s (other groups keep %d)\n", strings.Join(groups, ", "), rule.Keep)
+	}
+	fmt.Printf("  protected tags: %s\n", strings.Join(p.config.ProtectedTags, ", "))
+	fmt.Printf("  floating tags: %s (%s)\n", strings.Join(p.config.FloatingTags, ", "), p.config.FloatingTagMode)
+	fmt.Printf("  protect newest: %t, protect most recent from age: %t, delete untagged: %t\n", p.config.ProtectsNewest(repoName), p.config.ProtectMostRecentFromAge, p.config.DeleteUntagged)
+
+	p.sortComponents(rule, components)
+	decisions := p.planImage(repoName, rule, components)
+
+	fmt.Printf("\nTags (newest first by %s):\n", basis)
+	for i, d := range decisions {
+		fmt.Printf("  %2d. %-10s %-30s %s  (%s)\n", i+1, d.Action, displayRef(imageName, d.Component),
+			p.componentTime(d.Component, rule.TimeBasis).Format(time.RFC3339), d.Reason)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package retention
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// captureStdout returns what fn prints.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan []byte)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		done <- buf.Bytes()
+	}()
+	fn()
+	w.Close()
+	return string(<-done)
+}
+
+// explain runs Explain and returns its output.
+func explain(t *testing.T, engine *PolicyEngine, ref string) string {
+	t.Helper()
+	var err error
+	out := captureStdout(t, func() { err = engine.Explain(ref) })
+	if err != nil {
+		t.Fatalf("Explain: %v", err)
+	}
+	return out
+}
+
+// explainedActions returns the actions Explain printed by tag.
+func explainedActions(out string) map[string]Action {
+	actions := make(map[string]Action)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && strings.HasSuffix(fields[0], ".") {
+			actions[fields[2]] = Action(fields[1])
+		}
+	}
+	return actions
+}
+
+// explainedAction returns the action Explain printed for a tag.
+func explainedAction(t *testing.T, out, tag string) Action {
+	t.Helper()
+	action, ok := explainedActions(out)[tag]
+	if !ok {
+		t.Fatalf("tag %s not explained:\n%s", tag, out)
+	}
+	return action
+}
+
+func TestExplainEnrichesDetails(t *testing.T) {
+	// Listed without timestamps, v1 would sort after v2
+	listed := numbered("app", 2)
+	fake := nexus.NewFakeClient()
+	fake.Details = make(map[string]nexus.Component)
+	for i, comp := range listed {
+		fake.Details[comp.ID] = component("app", comp.Version, testTime.Add(-time.Duration(1-i)*time.Hour))
+		listed[i].Assets[0].LastModified = time.Time{}
+	}
+	fake.AddRepository(dockerRepo("docker-hosted"), listed...)
+
+	cfg := parseConfig(t, `
+enrich_details: true
+rules: [{name: all, regex: ".*", keep: 1}]
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{DryRun: true})
+	out := explain(t, engine, "docker-hosted/app")
+
+	if got := explainedAction(t, out, "v2"); got != ActionDelete {
+		t.Errorf("v2 explained as %s, want %s:\n%s", got, ActionDelete, out)
+	}
+	if got := explainedAction(t, out, "v1"); got != ActionKeep {
+		t.Errorf("v1 explained as %s, want %s:\n%s", got, ActionKeep, out)
+	}
+}
+
+func TestExplainMatchesExecute(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		// skipped is the tag left out by the filters
+		skipped string
+	}{
+		{
+			name:    "excluded blob store",
+			config:  "exclude_blob_stores: [archive]\n",
+			skipped: "v4",
+		},
+		{
+			name:    "ignored floating tag",
+			config:  "floating_tag_mode: ignore\n",
+			skipped: "latest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			components := tags("app", "v4", "latest", "v2", "v1")
+			components[0].Assets[0].BlobStore = "archive"
+			fake := nexus.NewFakeClient()
+			fake.AddRepository(dockerRepo("docker-hosted"), components...)
+
+			cfg := parseConfig(t, tt.config+`rules: [{name: all, regex: ".*", keep: 1}]`)
+			engine, _ := newTestEngine(fake, cfg, Options{DryRun: true})
+			out := explain(t, engine, "docker-hosted/app")
+			executed := decisionsOf(execute(t, engine))
+
+			if _, ok := executed["app:"+tt.skipped]; ok {
+				t.Fatalf("execution decided %s", tt.skipped)
+			}
+			if _, ok := explainedActions(out)[tt.skipped]; ok {
+				t.Errorf("explain lists %s:\n%s", tt.skipped, out)
+			}
+			for id, action := range executed {
+				tag := strings.TrimPrefix(id, "app:")
+				if got := explainedAction(t, out, tag); got != action {
+					t.Errorf("%s explained as %s, executed as %s", tag, got, action)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package retention
+
+import (
+	"fmt"
+	"time"
+
+	"nexus-retention-policy/internal/config"
+)
+
+// gfsBuckets selects the components kept by the gfs strategy. Components
+// are bucketed by the calendar day, ISO week and month of their timestamp
+// in the configured timezone. Fed newest first, the first component of a
+// bucket is its newest; it is kept if the bucket is among the N most recent
+// buckets of its tier that have components. Empty periods don't use up a
+// bucket, and one component can fill buckets of several tiers.
+type gfsBuckets struct {
+	schedule *config.GFSRetention
+	location *time.Location
+	// filled holds the filled buckets by group and tier
+	filled map[string]map[string]bool
+}
+
+func newGFSBuckets(schedule *config.GFSRetention, location *time.Location) *gfsBuckets {
+	return &gfsBuckets{
+		schedule: schedule,
+		location: location,
+		filled:   make(map[string]map[string]bool),
+	}
+}
+
+// fill files the component with timestamp t of a group_by_regex group into
+// its buckets and returns the buckets it is the newest of, e.g.
+// ["daily 2024-01-15", "weekly 2024-W03"]. Components must be passed most
+// recent first.
+func (g *gfsBuckets) fill(group string, t time.Time) []string {
+	t = t.In(g.location)
+	year, week := t.ISOWeek()
+	tiers := []struct {
+		name   string
+		keep   int
+		bucket string
+	}{
+		{"daily", g.schedule.Daily, t.Format("2006-01-02")},
+		{"weekly", g.schedule.Weekly, fmt.Sprintf("%d-W%02d", year, week)},
+		{"monthly", g.schedule.Monthly, t.Format("2006-01")},
+	}
+
+	var kept []string
+	for _, tier := range tiers {
+		key := group + "\x00" + tier.name
+		buckets := g.filled[key]
+		if buckets == nil {
+			buckets = make(map[string]bool)
+			g.filled[key] = buckets
+		}
+		if buckets[tier.bucket] || len(buckets) >= tier.keep {
+			continue
+		}
+		buckets[tier.bucket] = true
+		kept = append(kept, tier.name+" "+tier.bucket)
+	}
+	return kept
+}
@@ -0,0 +1,84 @@
+package retention
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"nexus-retention-policy/internal/config"
+	"nexus-retention-policy/internal/nexus"
+)
+
+func TestGFSBuckets(t *testing.T) {
+	at := func(day, hour int) time.Time { return time.Date(2024, 3, day, hour, 0, 0, 0, time.UTC) }
+	timeline := []struct {
+		t    time.Time
+		kept string
+	}{
+		{at(20, 12), "daily 2024-03-20, weekly 2024-W12, monthly 2024-03"},
+		{at(20, 8), ""},
+		{at(19, 12), "daily 2024-03-19"},
+		{at(17, 12), "weekly 2024-W11"},
+		{at(10, 12), ""},
+		{time.Date(2024, 2, 15, 12, 0, 0, 0, time.UTC), "monthly 2024-02"},
+		{time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC), "monthly 2024-01"},
+		{time.Date(2023, 12, 10, 12, 0, 0, 0, time.UTC), ""},
+	}
+
+	g := newGFSBuckets(&config.GFSRetention{Daily: 2, Weekly: 2, Monthly: 3}, time.UTC)
+	for _, tt := range timeline {
+		if got := strings.Join(g.fill("", tt.t), ", "); got != tt.kept {
+			t.Errorf("%s kept for %q, want %q", tt.t.Format(time.RFC3339), got, tt.kept)
+		}
+	}
+
+	// Groups fill their own buckets
+	if got := g.fill("dev", at(10, 12)); len(got) != 3 {
+		t.Errorf("dev group kept for %v, want all tiers", got)
+	}
+}
+
+func TestGFSBucketsTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	// 02:00 UTC is still the previous day in New York
+	g := newGFSBuckets(&config.GFSRetention{Daily: 2}, loc)
+	if got := g.fill("", time.Date(2024, 3, 20, 2, 0, 0, 0, time.UTC)); !equalStrings(got, []string{"daily 2024-03-19"}) {
+		t.Errorf("kept for %v, want daily 2024-03-19", got)
+	}
+}
+
+func TestExecuteGFS(t *testing.T) {
+	// One tag every 12 hours over 20 days, t0 on Wednesday 2024-03-20 noon
+	newest := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	var components []nexus.Component
+	for i := 0; i < 40; i++ {
+		components = append(components, component("app", fmt.Sprintf("t%d", i), newest.Add(-time.Duration(i)*12*time.Hour)))
+	}
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), components...)
+
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 1, strategy: gfs, gfs: {daily: 3, weekly: 2}}]
+timezone: UTC
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{DryRun: true})
+	result := execute(t, engine)
+
+	// t0 is the newest and fills today and this week, t2 and t4 the two
+	// previous days and t6, on Sunday, the previous week
+	var kept []string
+	for _, d := range result.Repositories[0].Images[0].Decisions {
+		if d.Action == ActionKeep {
+			kept = append(kept, d.Component.Version+" ("+d.Reason+")")
+		}
+	}
+	want := []string{"t0 (newest 1)", "t2 (gfs daily 2024-03-19)", "t4 (gfs daily 2024-03-18)", "t6 (gfs weekly 2024-W11)"}
+	if !equalStrings(kept, want) {
+		t.Errorf("kept %v, want %v", kept, want)
+	}
+}
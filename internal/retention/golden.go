@@ -0,0 +1,49 @@
+package retention
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// goldenVersions maps image names to versions that must always be kept.
+type goldenVersions map[string]map[string]bool
+
+// loadGolden reads a YAML file mapping image names to lists of versions.
+func loadGolden(path string) (goldenVersions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read golden versions file: %w", err)
+	}
+
+	var raw map[string][]string
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse golden versions file: %w", err)
+	}
+
+	golden := make(goldenVersions)
+	for image, versions := range raw {
+		golden[image] = make(map[string]bool)
+		for _, version := range versions {
+			golden[image][version] = true
+		}
+	}
+
+	return golden, nil
+}
+
+func (g goldenVersions) contains(comp nexus.Component) bool {
+	return g[comp.Name][comp.Version]
+}
+
+// count returns the total number of golden versions across all images.
+func (g goldenVersions) count() int {
+	total := 0
+	for _, versions := range g {
+		total += len(versions)
+	}
+	return total
+}
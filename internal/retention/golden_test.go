@@ -0,0 +1,51 @@
+package retention
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+func TestExecuteKeepsGoldenVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden.yaml")
+	if err := os.WriteFile(path, []byte("app: [v1, v9]\napi: [v1]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), append(numbered("app", 4), numbered("api", 3)...)...)
+
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 1}]
+golden_versions_file: `+path+`
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	result := execute(t, engine)
+
+	// Protected golden versions are kept in addition to keep
+	if got, want := deletedTags(fake), []string{"api:v2", "app:v2", "app:v3"}; !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+	if got := decisionsOf(result)["app:v1"]; got != ActionProtected {
+		t.Errorf("app:v1 decided %s, want %s", got, ActionProtected)
+	}
+}
+
+func TestExecuteGoldenVersionsFileMissing(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), numbered("app", 2)...)
+
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 1}]
+golden_versions_file: `+filepath.Join(t.TempDir(), "missing.yaml")+`
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	if _, err := engine.Execute(); err == nil {
+		t.Fatal("Execute succeeded without the golden versions file")
+	}
+	if len(fake.Deleted) != 0 {
+		t.Errorf("deleted %v without the golden versions file", fake.Deleted)
+	}
+}
@@ -0,0 +1,92 @@
+package retention
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// runState is the state file of incremental runs. It records when the last
+// successful full-coverage execution started and the configuration it
+// applied.
+type runState struct {
+	LastRun    time.Time `json:"last_run"`
+	ConfigHash string    `json:"config_hash"`
+}
+
+// configHash fingerprints the configuration, so incremental runs fall back
+// to a full scan after the rules changed.
+func (p *PolicyEngine) configHash() (string, error) {
+	data, err := json.Marshal(p.config)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode config: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// loadSince returns the start of the last successful run recorded in the
+// state file, or the zero time if there is none or the configuration
+// changed since, which means a full scan.
+func (p *PolicyEngine) loadSince() (time.Time, error) {
+	data, err := os.ReadFile(p.config.StateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Println("No previous run recorded, scanning all images")
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var state runState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse state file: %w", err)
+	}
+	hash, err := p.configHash()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if state.ConfigHash != hash {
+		fmt.Println("Configuration changed since the last run, scanning all images")
+		return time.Time{}, nil
+	}
+
+	fmt.Printf("Incremental run: only images changed since %s\n", state.LastRun.In(p.config.Location()).Format("2006-01-02 15:04:05 MST"))
+	return state.LastRun, nil
+}
+
+// saveSince records the start of this run in the state file.
+func (p *PolicyEngine) saveSince(startedAt time.Time) error {
+	hash, err := p.configHash()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(runState{LastRun: startedAt, ConfigHash: hash}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+	if err := os.WriteFile(p.config.StateFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return nil
+}
+
+// unchanged reports whether no component of an image was modified since the
+// last run, so the image can be skipped in an incremental run.
+func (p *PolicyEngine) unchanged(components []nexus.Component) bool {
+	if p.since.IsZero() {
+		return false
+	}
+	for _, comp := range components {
+		if !p.getLastModified(comp).Before(p.since) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package retention
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+func TestExecuteSinceLastRun(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "state.json")
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), numbered("app", 3)...)
+
+	settings := `
+rules: [{name: all, regex: ".*", keep: 1}]
+state_file: ` + state + `
+`
+	run := func(settings string) *RunResult {
+		engine, _ := newTestEngine(fake, parseConfig(t, settings), Options{SinceLastRun: true})
+		var result *RunResult
+		captureStdout(t, func() { result = execute(t, engine) })
+		return result
+	}
+
+	// Without a state file, all images are scanned
+	if result := run(settings); result.UnchangedImages != 0 || result.Deleted != 2 {
+		t.Fatalf("first run deleted %d and skipped %d images, want a full scan", result.Deleted, result.UnchangedImages)
+	}
+	if _, err := os.Stat(state); err != nil {
+		t.Fatalf("state file not written: %v", err)
+	}
+
+	// Only images changed since are scanned: old was pushed before the last
+	// run and is skipped, new was pushed after it
+	pushed := time.Now().Add(time.Minute)
+	fake.Components["docker-hosted"] = append(fake.Components["docker-hosted"], append(numbered("old", 2),
+		component("new", "v2", pushed), component("new", "v1", pushed.Add(-time.Second)))...)
+	result := run(settings)
+	if result.UnchangedImages != 2 {
+		t.Errorf("second run skipped %d images, want app and old", result.UnchangedImages)
+	}
+	if got, want := deletedTags(fake), []string{"app:v1", "app:v2", "new:v1"}; !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+
+	// A changed configuration falls back to a full scan
+	if result := run(settings + "protected_tags: [stable]\n"); result.UnchangedImages != 0 {
+		t.Errorf("run with a changed configuration skipped %d images", result.UnchangedImages)
+	}
+	if got, want := deletedTags(fake), []string{"app:v1", "app:v2", "new:v1", "old:v1"}; !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+package retention
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// inUseSet holds images reported as running, which must never be deleted.
+// Entries are either digests (sha256:...) or image references (name:tag),
+// optionally prefixed with a registry host.
+type inUseSet struct {
+	digests map[string]bool
+	refs    map[string]bool
+}
+
+func loadInUse(path string) (*inUseSet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open in-use file: %w", err)
+	}
+	defer file.Close()
+
+	set := &inUseSet{
+		digests: make(map[string]bool),
+		refs:    make(map[string]bool),
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// image@sha256:... references pin a digest
+		if i := strings.Index(line, "@"); i >= 0 {
+			line = line[i+1:]
+		}
+
+		if strings.HasPrefix(line, "sha256:") {
+			set.digests[line] = true
+		} else {
+			set.refs[line] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read in-use file: %w", err)
+	}
+
+	return set, nil
+}
+
+// contains reports whether the component is listed by digest or reference.
+func (s *inUseSet) contains(comp nexus.Component) bool {
+	if s == nil {
+		return false
+	}
+
+	for _, asset := range comp.Assets {
+		if sum, ok := asset.Checksum["sha256"]; ok && s.digests["sha256:"+sum] {
+			return true
+		}
+	}
+
+	ref := comp.Name + ":" + comp.Version
+	if s.refs[ref] {
+		return true
+	}
+	for listed := range s.refs {
+		if strings.HasSuffix(listed, "/"+ref) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package retention
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeInUse writes an in-use file with the lines and returns its path.
+func writeInUse(t *testing.T, lines string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "in-use.txt")
+	if err := os.WriteFile(path, []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadInUse(t *testing.T) {
+	set, err := loadInUse(writeInUse(t, `
+# running in production
+registry.example.com/team/app:v2
+api:v1
+
+sha256:abc
+web@sha256:def
+`))
+	if err != nil {
+		t.Fatalf("loadInUse: %v", err)
+	}
+
+	if len(set.refs) != 2 || !set.refs["registry.example.com/team/app:v2"] || !set.refs["api:v1"] {
+		t.Errorf("references = %v, want app:v2 and api:v1", set.refs)
+	}
+	if len(set.digests) != 2 || !set.digests["sha256:abc"] || !set.digests["sha256:def"] {
+		t.Errorf("digests = %v, want sha256:abc and sha256:def", set.digests)
+	}
+}
+
+func TestLoadInUseMissingFile(t *testing.T) {
+	if _, err := loadInUse(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("loaded a missing in-use file")
+	}
+}
+
+func TestPlanImageProtectsInUse(t *testing.T) {
+	components := tags("app", "v5", "v4", "v3", "v2", "v1")
+	digest := components[4].Assets[0].Checksum["sha256"]
+	inUse, err := loadInUse(writeInUse(t, fmt.Sprintf("registry.example.com/app:v3\nother@sha256:%s\n", digest)))
+	if err != nil {
+		t.Fatalf("loadInUse: %v", err)
+	}
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`)
+	engine, _ := newTestEngine(nil, cfg, Options{})
+	engine.inUse = inUse
+	decisions := engine.planImage("docker-hosted", &cfg.Rules[0], components)
+
+	if len(decisions) != len(components) {
+		t.Fatalf("decided %d components, want %d", len(decisions), len(components))
+	}
+	want := map[string]Action{
+		"v5": ActionKeep,
+		"v4": ActionDelete,
+		"v3": ActionProtected,
+		"v2": ActionDelete,
+		"v1": ActionProtected,
+	}
+	for _, d := range decisions {
+		if d.Action != want[d.Component.Version] {
+			t.Errorf("%s decided %s (%s), want %s", d.Component.Version, d.Action, d.Reason, want[d.Component.Version])
+		}
+		if d.Action == ActionProtected && d.Reason != "in use" {
+			t.Errorf("%s protected as %q, want in use", d.Component.Version, d.Reason)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package retention
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// lockedRefs holds the image references of a deploy lockfile, which must
+// never be deleted. References may be prefixed with a registry host.
+type lockedRefs map[string]bool
+
+// lockfileEntry is an image reference, written either as "image:tag" or as
+// an object with separate image and tag fields.
+type lockfileEntry struct {
+	Image string `yaml:"image"`
+	Tag   string `yaml:"tag"`
+}
+
+func (e *lockfileEntry) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		ref := node.Value
+		// The tag follows the last colon after the registry host and path
+		i := strings.LastIndex(ref, ":")
+		if i < 0 || i < strings.LastIndex(ref, "/") {
+			return fmt.Errorf("line %d: image reference '%s' has no tag", node.Line, ref)
+		}
+		e.Image, e.Tag = ref[:i], ref[i+1:]
+		return nil
+	}
+
+	type plain lockfileEntry
+	if err := node.Decode((*plain)(e)); err != nil {
+		return err
+	}
+	if e.Image == "" || e.Tag == "" {
+		return fmt.Errorf("line %d: lockfile entries need an image and a tag", node.Line)
+	}
+	return nil
+}
+
+// loadLockfile reads a JSON or YAML lockfile with an images list.
+func loadLockfile(path string) (lockedRefs, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+
+	var raw struct {
+		Images []lockfileEntry `yaml:"images"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile: %w", err)
+	}
+
+	refs := make(lockedRefs, len(raw.Images))
+	for _, entry := range raw.Images {
+		refs[entry.Image+":"+entry.Tag] = true
+	}
+	return refs, nil
+}
+
+// contains reports whether the component is referenced by the lockfile.
+func (l lockedRefs) contains(comp nexus.Component) bool {
+	ref := comp.Name + ":" + comp.Version
+	if l[ref] {
+		return true
+	}
+	for locked := range l {
+		if strings.HasSuffix(locked, "/"+ref) {
+			return true
+		}
+	}
+	return false
+}
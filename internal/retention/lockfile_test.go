@@ -0,0 +1,63 @@
+package retention
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+func TestExecuteKeepsLockedTags(t *testing.T) {
+	lockfiles := map[string]string{
+		"lock.yaml": `
+images:
+  - registry.example.com/team/app:v1
+  - {image: api, tag: v2}
+`,
+		"lock.json": `{"images": ["registry.example.com/team/app:v1", {"image": "api", "tag": "v2"}]}`,
+	}
+
+	for name, content := range lockfiles {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			fake := nexus.NewFakeClient()
+			fake.AddRepository(dockerRepo("docker-hosted"), append(numbered("team/app", 3), numbered("api", 3)...)...)
+
+			cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 1}]
+lock_file: `+path+`
+`)
+			engine, _ := newTestEngine(fake, cfg, Options{})
+			result := execute(t, engine)
+
+			// Locked tags beyond keep are protected
+			if got, want := deletedTags(fake), []string{"api:v1", "team/app:v2"}; !equalStrings(got, want) {
+				t.Errorf("deleted %v, want %v", got, want)
+			}
+			if got := decisionsOf(result)["team/app:v1"]; got != ActionProtected {
+				t.Errorf("team/app:v1 decided %s, want %s", got, ActionProtected)
+			}
+		})
+	}
+}
+
+func TestLoadLockfileInvalid(t *testing.T) {
+	for _, content := range []string{
+		"images: [app]",
+		"images: [{image: app}]",
+		"images: [registry.example.com:5000/app]",
+	} {
+		path := filepath.Join(t.TempDir(), "lock.yaml")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadLockfile(path); err == nil {
+			t.Errorf("loadLockfile accepted %q", content)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package retention
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// mockRegistry returns a mock listing the components in the hosted Docker
+// repository docker-hosted.
+func mockRegistry(components []nexus.Component) *nexus.MockClient {
+	return &nexus.MockClient{
+		GetRepositoriesFunc: func() ([]nexus.Repository, error) {
+			return []nexus.Repository{dockerRepo("docker-hosted")}, nil
+		},
+		GetComponentsFunc: func(repository string) ([]nexus.Component, error) {
+			return components, nil
+		},
+	}
+}
+
+func TestExecuteFailedListingIsReported(t *testing.T) {
+	mock := &nexus.MockClient{
+		GetRepositoriesFunc: func() ([]nexus.Repository, error) {
+			return []nexus.Repository{dockerRepo("broken"), dockerRepo("docker-hosted")}, nil
+		},
+		GetComponentsFunc: func(repository string) ([]nexus.Component, error) {
+			if repository == "broken" {
+				return nil, &nexus.APIError{StatusCode: 500, Body: "boom"}
+			}
+			return numbered("app", 2), nil
+		},
+	}
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`)
+	engine, _ := newTestEngine(mock, cfg, Options{})
+	result, err := engine.Execute()
+	if err == nil {
+		t.Fatal("Execute succeeded despite a failed listing")
+	}
+	if result == nil || result.Repositories[0].Error == "" {
+		t.Fatalf("failed repository not reported in %+v", result)
+	}
+	if got, want := mock.CallsTo("DeleteComponent"), []string{"app:v1"}; !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+}
+
+func TestExecuteDeleteErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		// logged is whether the deletion counts as made
+		logged bool
+	}{
+		{name: "forbidden", status: 403},
+		{name: "server error", status: 500},
+		{name: "already deleted", status: 404, logged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := mockRegistry(numbered("app", 3))
+			mock.DeleteComponentFunc = func(componentID string) error {
+				if componentID == "app:v1" {
+					return &nexus.APIError{StatusCode: tt.status}
+				}
+				return nil
+			}
+
+			cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`)
+			engine, log := newTestEngine(mock, cfg, Options{})
+			result, _ := engine.Execute()
+
+			if got := len(mock.CallsTo("DeleteComponent")); got != 2 {
+				t.Errorf("made %d delete requests, want 2", got)
+			}
+			var logged []string
+			for _, record := range log.Records() {
+				logged = append(logged, record.ComponentID)
+			}
+			want := []string{"app:v2"}
+			if tt.logged {
+				want = append(want, "app:v1")
+			}
+			sort.Strings(logged)
+			sort.Strings(want)
+			if !equalStrings(logged, want) {
+				t.Errorf("logged %v, want %v", logged, want)
+			}
+
+			failed := result.Repositories[0].Images[0].Failed
+			if tt.logged != (len(failed) == 0) {
+				t.Errorf("failed deletions %v", failed)
+			}
+		})
+	}
+}
+
+func TestExecuteDryRunChecksPermissions(t *testing.T) {
+	mock := mockRegistry(numbered("app", 2))
+	mock.CanDeleteFunc = func(repo nexus.Repository) (bool, error) {
+		return false, nil
+	}
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`)
+	engine, _ := newTestEngine(mock, cfg, Options{DryRun: true, CheckPermissions: true})
+	_, err := engine.Execute()
+
+	if got, want := mock.CallsTo("CanDelete"), []string{"docker-hosted"}; !equalStrings(got, want) {
+		t.Errorf("checked permissions of %v, want %v", got, want)
+	}
+	if len(mock.CallsTo("DeleteComponent")) != 0 {
+		t.Errorf("dry run made delete requests")
+	}
+	var partial *PartialFailureError
+	if !errors.As(err, &partial) {
+		t.Errorf("Execute returned %v, want a partial failure for the denied permission", err)
+	}
+}
+
+func TestExecuteDryRunReadOnlyAccount(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.ReadOnly = true
+	fake.AddRepository(dockerRepo("docker-hosted"), numbered("app", 2)...)
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`)
+	engine, _ := newTestEngine(fake, cfg, Options{DryRun: true, CheckPermissions: true})
+	var result *RunResult
+	out := captureStdout(t, func() { result, _ = engine.Execute() })
+
+	if !strings.Contains(out, "Delete permission: denied") {
+		t.Errorf("denied permission not reported:\n%s", out)
+	}
+	if result == nil || result.Errors["auth"] != 1 {
+		t.Errorf("denied permission not counted as an auth error")
+	}
+}
@@ -0,0 +1,62 @@
+package retention
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"time"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// overrideReport records protected components that a rule would have
+// deleted, for reviewing protections without blocking deletions.
+type overrideReport struct {
+	file   *os.File
+	writer *csv.Writer
+}
+
+// openOverrideReport opens the report for appending, writing a header if
+// the file is new.
+func openOverrideReport(path string) (*overrideReport, error) {
+	_, statErr := os.Stat(path)
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open protected override report: %w", err)
+	}
+
+	r := &overrideReport{file: file, writer: csv.NewWriter(file)}
+	if os.IsNotExist(statErr) {
+		r.writer.Write([]string{"Execution ID", "Timestamp", "Repository", "Image Name", "Tag", "Component ID", "Rule", "Protection"})
+	}
+	return r, nil
+}
+
+func (r *overrideReport) add(executionID, repoName, imageName, ruleName, protection string, comp nexus.Component) error {
+	if r == nil {
+		return nil
+	}
+
+	r.writer.Write([]string{
+		executionID,
+		time.Now().Format(time.RFC3339),
+		repoName,
+		imageName,
+		comp.Version,
+		comp.ID,
+		ruleName,
+		protection,
+	})
+	r.writer.Flush()
+	return r.writer.Error()
+}
+
+func (r *overrideReport) close() error {
+	if r == nil {
+		return nil
+	}
+
+	r.writer.Flush()
+	return r.file.Close()
+}
@@ -0,0 +1,117 @@
+package retention
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"nexus-retention-policy/internal/logger"
+	"nexus-retention-policy/internal/nexus"
+)
+
+func TestExecuteProtectedOverrideReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.csv")
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), tags("app", "v4", "stable", "v2", "v1")...)
+
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 1}]
+protected_tags: [stable]
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{ProtectedOverrideReport: path})
+	execute(t, engine)
+
+	// The protected tag is reported and kept, the others deleted as usual
+	if got, want := deletedTags(fake), []string{"app:v1", "app:v2"}; !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open report: %v", err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	if len(rows) != 2 || rows[1][5] != "app:stable" || rows[1][6] != "all" {
+		t.Errorf("report rows %v, want a header and app:stable", rows)
+	}
+}
+
+func TestProtectedOverrideReportRedactsImageNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.csv")
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), tags("app", "v2", "stable")...)
+
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 1}]
+protected_tags: [stable]
+redact_image_names: true
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{ProtectedOverrideReport: path})
+	execute(t, engine)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	// Component IDs are kept for traceability
+	if !strings.Contains(string(data), logger.RedactImageName("app")+",stable,app:stable") {
+		t.Errorf("report not redacted:\n%s", data)
+	}
+}
+
+func TestExecuteWarnsProtectedOverrides(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     []string
+		settings string
+		warned   bool
+	}{
+		{
+			name:     "protected beyond keep",
+			tags:     []string{"v4", "stable", "v2", "v1"},
+			settings: "warn_protected_overrides: true",
+			warned:   true,
+		},
+		{
+			name:     "protected within keep",
+			tags:     []string{"stable", "v3", "v2", "v1"},
+			settings: "warn_protected_overrides: true",
+		},
+		{
+			name: "warnings off",
+			tags: []string{"v4", "stable", "v2", "v1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := nexus.NewFakeClient()
+			fake.AddRepository(dockerRepo("docker-hosted"), tags("app", tt.tags...)...)
+
+			cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 1}]
+protected_tags: [stable]
+`+tt.settings)
+			engine, _ := newTestEngine(fake, cfg, Options{})
+			var result *RunResult
+			out := captureStdout(t, func() { result = execute(t, engine) })
+
+			warned := strings.Contains(out, "Protection overrides rule for stable")
+			if warned != tt.warned {
+				t.Errorf("warned = %t, want %t:\n%s", warned, tt.warned, out)
+			}
+			if overrides := result.ProtectionOverrides; (overrides == 1) != tt.warned || overrides > 1 {
+				t.Errorf("protection overrides = %d, want warned %t", overrides, tt.warned)
+			}
+			if strings.Contains(out, "Protection overrides rule for v") {
+				t.Errorf("warned about an unprotected tag:\n%s", out)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package retention
+
+import (
+	"errors"
+	"fmt"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// PermissionChecker is implemented by registries that can check whether the
+// account may delete in a repository without deleting anything.
+type PermissionChecker interface {
+	CanDelete(repo nexus.Repository) (bool, error)
+}
+
+var (
+	// errPermissionCheckUnsupported is returned for permission checks on
+	// registries that don't implement PermissionChecker.
+	errPermissionCheckUnsupported = errors.New("registry does not support checking permissions")
+	// errDeleteDenied is recorded for repositories the account may not
+	// delete in.
+	errDeleteDenied = errors.New("account may not delete components")
+)
+
+// canDelete checks the delete permission if the registry supports it.
+func canDelete(registry Registry, repo nexus.Repository) (bool, error) {
+	checker, ok := registry.(PermissionChecker)
+	if !ok {
+		return false, errPermissionCheckUnsupported
+	}
+	return checker.CanDelete(repo)
+}
+
+// checkDeletePermission reports whether the account may delete in the
+// repository, so a dry run doesn't hide deletions that would fail. Denied
+// repositories are recorded as errors of the run.
+func (p *PolicyEngine) checkDeletePermission(repo nexus.Repository) {
+	allowed, err := canDelete(p.client, repo)
+	switch {
+	case errors.Is(err, errPermissionCheckUnsupported):
+		fmt.Printf("  ⚠️  Delete permission can't be checked with the %s backend\n", p.config.Backend)
+	case err != nil:
+		category := p.errors.add(repo.Name, fmt.Errorf("failed to check delete permission: %w", err))
+		fmt.Printf("  ⚠️  Failed to check delete permission (%s): %v\n", category, err)
+	case allowed:
+		fmt.Println("  🔓 Delete permission: granted")
+	default:
+		p.errors.add(repo.Name, errDeleteDenied)
+		fmt.Println("  ⛔ Delete permission: denied, deletions in this repository would fail")
+	}
+}
@@ -0,0 +1,278 @@
+package retention
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"nexus-retention-policy/internal/config"
+	"nexus-retention-policy/internal/logger"
+	"nexus-retention-policy/internal/nexus"
+)
+
+// Action is the outcome planned for a component.
+type Action string
+
+const (
+	ActionKeep      Action = "KEEP"
+	ActionDelete    Action = "DELETE"
+	ActionProtected Action = "PROTECTED"
+)
+
+// Decision is the planned action for a single component and why.
+type Decision struct {
+	Component nexus.Component
+	Action    Action
+	Reason    string
+}
+
+// rankKey identifies the components ranked together against a keep count:
+// Maven rules rank snapshots and releases separately, and group_by_regex
+// ranks each group separately.
+type rankKey struct {
+	snapshot bool
+	group    string
+}
+
+func rankOf(rule *config.Rule, comp nexus.Component) rankKey {
+	snapshot := rule.IsMaven() && comp.Format == "maven2" && isSnapshot(comp.Version)
+	return rankKey{snapshot: snapshot, group: rule.GroupKey(comp.Version)}
+}
+
+// keepFor returns the keep count of ranked components and the class
+// describing them in reasons, e.g. " snapshots in group 1.2".
+func keepFor(rule *config.Rule, comp nexus.Component, key rankKey) (keep int, class string) {
+	keep = rule.KeepForGroup(key.group)
+	if rule.IsMaven() && comp.Format == "maven2" {
+		class = " releases"
+		if key.snapshot {
+			class = " snapshots"
+		}
+		keep = rule.KeepFor(key.snapshot)
+	}
+
+	if key.group != "" {
+		class += fmt.Sprintf(" in group %s", key.group)
+	}
+	return keep, class
+}
+
+// fixedDecision decides components whose action doesn't depend on their
+// rank. It returns false for components ranked against the keep count.
+func (p *PolicyEngine) fixedDecision(rule *config.Rule, comp nexus.Component) (Decision, bool) {
+	d := Decision{Component: comp}
+	if p.config.DeleteUntagged && isUntagged(comp) {
+		// Untagged manifests don't count toward the keep limit
+		d.Action, d.Reason = ActionDelete, "untagged manifest"
+	} else if reason := p.protectionReason(comp); reason != "" {
+		d.Action, d.Reason = ActionProtected, reason
+	} else if anyAsset(comp, rule.ProtectsAttributes) {
+		d.Action, d.Reason = ActionProtected, "protected attributes"
+	} else if reason := rule.VersionProtection(comp.Version); reason != "" {
+		d.Action, d.Reason = ActionProtected, reason
+	} else if !anyAsset(comp, rule.TargetsAttributes) {
+		// Components the rule doesn't target don't count toward the keep limit
+		d.Action, d.Reason = ActionKeep, "attributes not targeted"
+	} else if rule.AlwaysKeeps(comp.Version) {
+		// Always kept tags don't count toward the keep limit
+		d.Action, d.Reason = ActionKeep, "always keep"
+	} else {
+		return d, false
+	}
+	return d, true
+}
+
+// holdYoung protects a deletion of a component younger than min_age.
+func (p *PolicyEngine) holdYoung(d *Decision, now time.Time) {
+	if minAge := p.config.MinimumAge(); d.Action == ActionDelete && minAge > 0 && now.Sub(p.getLastModified(d.Component)) < minAge {
+		d.Action, d.Reason = ActionProtected, fmt.Sprintf("younger than %s", p.config.MinAge)
+	}
+}
+
+// mostRecentlyPushed returns the tagged component with the latest last
+// modification, or nil if protect_most_recent_from_age is off. Unlike the
+// newest tag, it doesn't depend on the rule's time_basis.
+func (p *PolicyEngine) mostRecentlyPushed(components []nexus.Component) *nexus.Component {
+	if !p.config.ProtectMostRecentFromAge {
+		return nil
+	}
+	var mostRecent *nexus.Component
+	for i, comp := range components {
+		if !isUntagged(comp) && (mostRecent == nil || p.pushedAfter(comp, *mostRecent)) {
+			mostRecent = &components[i]
+		}
+	}
+	return mostRecent
+}
+
+// pushedAfter reports whether a was pushed after b, by last modification and
+// then tag.
+func (p *PolicyEngine) pushedAfter(a, b nexus.Component) bool {
+	if ta, tb := p.getLastModified(a), p.getLastModified(b); !ta.Equal(tb) {
+		return ta.After(tb)
+	}
+	return a.Version > b.Version
+}
+
+// planImage decides the action for every component of an image. Components
+// must already be sorted most recent first; decisions keep that order.
+func (p *PolicyEngine) planImage(repoName string, rule *config.Rule, components []nexus.Component) []Decision {
+	decisions := make([]Decision, 0, len(components))
+	ranks := make(map[rankKey]int)
+
+	var gfs *gfsBuckets
+	if rule.Strategy == config.StrategyGFS {
+		gfs = newGFSBuckets(rule.GFS, p.config.Location())
+	}
+
+	// Protected components occupy keep slots before any other component, so
+	// only the newest unprotected components fill the remaining slots
+	if p.config.ProtectedCountTowardKeep {
+		for _, comp := range components {
+			if p.config.DeleteUntagged && isUntagged(comp) {
+				continue
+			}
+			if p.protectionReason(comp) != "" || anyAsset(comp, rule.ProtectsAttributes) || rule.VersionProtection(comp.Version) != "" {
+				ranks[rankOf(rule, comp)]++
+			}
+		}
+	}
+
+	protectNewest := p.config.ProtectsNewest(repoName)
+	mostRecent := p.mostRecentlyPushed(components)
+	now := time.Now()
+	seenTagged := false
+
+	for _, comp := range components {
+		newest := !seenTagged && !isUntagged(comp)
+		if !isUntagged(comp) {
+			seenTagged = true
+		}
+
+		d, fixed := p.fixedDecision(rule, comp)
+		if !fixed {
+			key := rankOf(rule, comp)
+			keep, class := keepFor(rule, comp, key)
+
+			// Every ranked component fills its gfs buckets, including the
+			// newest keep ones
+			var buckets []string
+			if gfs != nil {
+				buckets = gfs.fill(key.group, p.componentTime(comp, rule.TimeBasis))
+			}
+
+			older := ranks[key] - keep
+			switch {
+			case older < 0:
+				d.Action, d.Reason = ActionKeep, fmt.Sprintf("newest %d%s", keep, class)
+			case len(buckets) > 0:
+				d.Action, d.Reason = ActionKeep, "gfs "+strings.Join(buckets, ", ")
+			case gfs != nil:
+				d.Action, d.Reason = ActionDelete, fmt.Sprintf("beyond keep %d and gfs%s", keep, class)
+			case rule.ThinEvery > 0 && (older+1)%rule.ThinEvery == 0:
+				// Thin older components, keeping every Nth one counted from the newest
+				d.Action, d.Reason = ActionKeep, fmt.Sprintf("thinned, every %d", rule.ThinEvery)
+			default:
+				d.Action, d.Reason = ActionDelete, fmt.Sprintf("beyond keep %d%s", keep, class)
+			}
+			ranks[key]++
+
+			if d.Action == ActionDelete && newest && protectNewest {
+				d.Action, d.Reason = ActionProtected, "newest tag"
+			}
+			if d.Action == ActionDelete && mostRecent != nil && comp.ID == mostRecent.ID {
+				d.Action, d.Reason = ActionProtected, "most recently pushed tag"
+			}
+		}
+
+		p.holdYoung(&d, now)
+		decisions = append(decisions, d)
+	}
+
+	if p.config.DeleteEmptyImages && emptiesImage(decisions) {
+		for i, d := range decisions {
+			if d.Action == ActionKeep && isUntagged(d.Component) {
+				decisions[i].Action, decisions[i].Reason = ActionDelete, "no tags left in image"
+			}
+		}
+	}
+
+	return decisions
+}
+
+// emptiesImage reports whether the decisions delete every tagged component
+// of an image.
+func emptiesImage(decisions []Decision) bool {
+	tagged := false
+	for _, d := range decisions {
+		if isUntagged(d.Component) {
+			continue
+		}
+		if d.Action != ActionDelete {
+			return false
+		}
+		tagged = true
+	}
+	return tagged
+}
+
+// anyAsset reports whether match accepts the attributes of any asset of the
+// component. Components without assets are matched against no attributes.
+func anyAsset(comp nexus.Component, match func(lookup func(path string) (string, bool)) bool) bool {
+	if len(comp.Assets) == 0 {
+		return match(func(string) (string, bool) { return "", false })
+	}
+	for _, asset := range comp.Assets {
+		if match(asset.Attribute) {
+			return true
+		}
+	}
+	return false
+}
+
+// printDecisions lists the decisions in order, truncated to the list limit.
+func (p *PolicyEngine) printDecisions(imageName string, decisions []Decision) {
+	for i, d := range decisions {
+		if p.options.ListLimit > 0 && i >= p.options.ListLimit {
+			fmt.Printf("     ... and %d more\n", len(decisions)-i)
+			break
+		}
+
+		tag := displayRef(imageName, d.Component)
+
+		switch d.Action {
+		case ActionKeep:
+			fmt.Printf("     ✓ KEEP       %s (%s)\n", tag, d.Reason)
+		case ActionProtected:
+			fmt.Printf("     🔒 PROTECTED %s (%s)\n", tag, d.Reason)
+		case ActionDelete:
+			fmt.Printf("     🗑️  DELETE     %s (%s)\n", tag, d.Reason)
+		}
+	}
+}
+
+// displayTag returns the component's tag for output.
+func displayTag(comp nexus.Component) string {
+	if comp.Version == "" {
+		return "<untagged>"
+	}
+	return comp.Version
+}
+
+// displayRef returns the component's tag, prefixed with its image name when
+// it was grouped under another name through an alias.
+func displayRef(imageName string, comp nexus.Component) string {
+	if name := groupName(comp); name != imageName {
+		return name + ":" + displayTag(comp)
+	}
+	return displayTag(comp)
+}
+
+// reportedName returns an image name as written to files and messages
+// leaving the host, hashed like in deletion events with redact_image_names.
+func (p *PolicyEngine) reportedName(imageName string) string {
+	if p.config.RedactImageNames {
+		return logger.RedactImageName(imageName)
+	}
+	return imageName
+}
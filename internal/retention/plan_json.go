@@ -0,0 +1,118 @@
+package retention
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// StablePlanSchemaVersion is the version of the stable plan format. It is
+// increased when the format changes incompatibly.
+const StablePlanSchemaVersion = 1
+
+// stablePlan is the diff-friendly form of a plan, for committing to version
+// control and reviewing changes in pull requests. It leaves out what changes
+// between runs of the same policy, like the execution ID and timestamps.
+// Fields are declared in key order and maps are encoded with sorted keys, so
+// the same deletions always encode to the same bytes.
+type stablePlan struct {
+	// Deletions maps repositories to images to their planned deletions
+	Deletions     map[string]map[string][]stableDeletion `json:"deletions"`
+	SchemaVersion int                                    `json:"schema_version"`
+	Total         int                                    `json:"total"`
+}
+
+type stableDeletion struct {
+	ComponentID string `json:"component_id"`
+	Rule        string `json:"rule"`
+	Tag         string `json:"tag"`
+}
+
+// StableJSON encodes the plan in the stable format: deletions grouped by
+// repository and image, each image's deletions sorted by tag and component
+// ID.
+func (pl *Plan) StableJSON() ([]byte, error) {
+	stable := stablePlan{
+		Deletions:     make(map[string]map[string][]stableDeletion),
+		SchemaVersion: StablePlanSchemaVersion,
+		Total:         len(pl.Deletions),
+	}
+	for _, d := range pl.Deletions {
+		images := stable.Deletions[d.Repository]
+		if images == nil {
+			images = make(map[string][]stableDeletion)
+			stable.Deletions[d.Repository] = images
+		}
+		images[d.ImageName] = append(images[d.ImageName], stableDeletion{ComponentID: d.ComponentID, Rule: d.Rule, Tag: d.Tag})
+	}
+	for _, images := range stable.Deletions {
+		for _, deletions := range images {
+			sort.Slice(deletions, func(i, j int) bool {
+				if deletions[i].Tag != deletions[j].Tag {
+					return deletions[i].Tag < deletions[j].Tag
+				}
+				return deletions[i].ComponentID < deletions[j].ComponentID
+			})
+		}
+	}
+
+	data, err := json.MarshalIndent(stable, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode plan: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// SaveStable writes the plan in the stable format. LoadPlan reads it back,
+// so a reviewed plan can be applied.
+func (pl *Plan) SaveStable(path string) error {
+	data, err := pl.StableJSON()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write plan file: %w", err)
+	}
+	return nil
+}
+
+// parseStablePlan converts a plan in the stable format, in repository,
+// image and tag order.
+func parseStablePlan(data []byte) (*Plan, error) {
+	var stable stablePlan
+	if err := json.Unmarshal(data, &stable); err != nil {
+		return nil, fmt.Errorf("failed to parse plan file: %w", err)
+	}
+	if stable.SchemaVersion > StablePlanSchemaVersion {
+		return nil, fmt.Errorf("plan file has schema version %d, this version supports up to %d", stable.SchemaVersion, StablePlanSchemaVersion)
+	}
+
+	repos := make([]string, 0, len(stable.Deletions))
+	for repo := range stable.Deletions {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	pl := &Plan{}
+	for _, repo := range repos {
+		images := make([]string, 0, len(stable.Deletions[repo]))
+		for image := range stable.Deletions[repo] {
+			images = append(images, image)
+		}
+		sort.Strings(images)
+
+		for _, image := range images {
+			for _, d := range stable.Deletions[repo][image] {
+				pl.Deletions = append(pl.Deletions, PlannedDeletion{
+					Repository:  repo,
+					ImageName:   image,
+					Tag:         d.Tag,
+					ComponentID: d.ComponentID,
+					Rule:        d.Rule,
+				})
+			}
+		}
+	}
+	return pl, nil
+}
@@ -0,0 +1,111 @@
+package retention
+
+import (
+	"bytes"
+	"flag"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// update rewrites golden files with the current output: go test -update
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// assertGolden compares output with testdata/<name>.golden, or writes it
+// there with -update.
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("update golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file (run go test -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s (run go test -update if the change is intended)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// goldenPlan plans deletions in two repositories with two rules.
+func goldenPlan(t *testing.T) *Plan {
+	t.Helper()
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), append(numbered("prod-api", 5), numbered("app", 4)...)...)
+	fake.AddRepository(dockerRepo("docker-staging"), tags("app", "rc-3", "rc-2", "rc-1", "latest")...)
+
+	cfg := parseConfig(t, `
+rules: [{name: prod, regex: "^prod-", keep: 3}, {name: all, regex: ".*", keep: 1}]
+protected_tags: ["latest"]
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{DryRun: true})
+	var plan *Plan
+	captureStdout(t, func() {
+		var err error
+		if plan, _, err = engine.Plan(); err != nil {
+			t.Fatalf("Plan: %v", err)
+		}
+	})
+	return plan
+}
+
+func TestStableJSONGolden(t *testing.T) {
+	data, err := goldenPlan(t).StableJSON()
+	if err != nil {
+		t.Fatalf("StableJSON: %v", err)
+	}
+	assertGolden(t, "stable_plan", data)
+}
+
+func TestStableJSONIgnoresOrder(t *testing.T) {
+	plan := goldenPlan(t)
+	want, err := plan.StableJSON()
+	if err != nil {
+		t.Fatalf("StableJSON: %v", err)
+	}
+
+	shuffled := &Plan{ExecutionID: "other", Deletions: append([]PlannedDeletion(nil), plan.Deletions...)}
+	rand.New(rand.NewSource(1)).Shuffle(len(shuffled.Deletions), func(i, j int) {
+		shuffled.Deletions[i], shuffled.Deletions[j] = shuffled.Deletions[j], shuffled.Deletions[i]
+	})
+	got, err := shuffled.StableJSON()
+	if err != nil {
+		t.Fatalf("StableJSON: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("shuffled deletions encode differently:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLoadStablePlan(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	plan := goldenPlan(t)
+	if err := plan.SaveStable(path); err != nil {
+		t.Fatalf("SaveStable: %v", err)
+	}
+
+	loaded, err := LoadPlan(path)
+	if err != nil {
+		t.Fatalf("LoadPlan: %v", err)
+	}
+	got := make(map[PlannedDeletion]bool)
+	for _, d := range loaded.Deletions {
+		got[d] = true
+	}
+	want := make(map[PlannedDeletion]bool)
+	for _, d := range plan.Deletions {
+		want[d] = true
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded %v, want %v", loaded.Deletions, plan.Deletions)
+	}
+}
@@ -0,0 +1,401 @@
+package retention
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+func TestExecuteThinEvery(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), numbered("app", 10)...)
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 2, thin_every: 3}]`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	result := execute(t, engine)
+
+	// Tags 5 and 8 counted from the newest are kept
+	want := []string{"app:v1", "app:v2", "app:v4", "app:v5", "app:v7", "app:v8"}
+	if got := deletedTags(fake); !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+	if got := decisionsOf(result)["app:v6"]; got != ActionKeep {
+		t.Errorf("app:v6 decided %s, want %s", got, ActionKeep)
+	}
+}
+
+// decisionLines returns the decision lines of verbose output with their
+// action and tag, e.g. "DELETE v1".
+func decisionLines(out string) []string {
+	var lines []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && (fields[1] == "KEEP" || fields[1] == "PROTECTED" || fields[1] == "DELETE") {
+			lines = append(lines, fields[1]+" "+fields[2])
+		}
+	}
+	return lines
+}
+
+func TestDryRunListsDecisionsInOrder(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), tags("app", "v4", "stable", "v3", "v2", "v1")...)
+
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 2}]
+protected_tags: ["stable"]
+`)
+	tests := []struct {
+		name  string
+		limit int
+		want  []string
+		more  bool
+	}{
+		{
+			name: "all",
+			want: []string{"KEEP v4", "PROTECTED stable", "KEEP v3", "DELETE v2", "DELETE v1"},
+		},
+		{
+			name:  "limited",
+			limit: 2,
+			want:  []string{"KEEP v4", "PROTECTED stable"},
+			more:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, _ := newTestEngine(fake, cfg, Options{DryRun: true, Verbosity: VerbosityTag, ListLimit: tt.limit})
+			out := captureStdout(t, func() { execute(t, engine) })
+
+			if got := decisionLines(out); !equalStrings(got, tt.want) {
+				t.Errorf("listed %q, want %q", got, tt.want)
+			}
+			if got := strings.Contains(out, "... and 3 more"); got != tt.more {
+				t.Errorf("truncation notice shown = %t, want %t:\n%s", got, tt.more, out)
+			}
+		})
+	}
+}
+
+func TestExecuteTimeBasis(t *testing.T) {
+	// Retagging updates last modified but not the blob creation time
+	stamps := []struct {
+		version                       string
+		modified, created, downloaded time.Duration
+	}{
+		{"v1", 0, 3 * time.Hour, 0},
+		{"v2", 2 * time.Hour, 1 * time.Hour, 3 * time.Hour},
+		{"v3", 1 * time.Hour, 2 * time.Hour, time.Hour},
+	}
+	var components []nexus.Component
+	for _, s := range stamps {
+		comp := component("app", s.version, testTime.Add(-s.modified))
+		comp.Assets[0].BlobCreated = testTime.Add(-s.created)
+		comp.Assets[0].LastDownloaded = testTime.Add(-s.downloaded)
+		components = append(components, comp)
+	}
+	// v4 was never downloaded
+	components = append(components, component("app", "v4", testTime.Add(-4*time.Hour)))
+
+	tests := []struct {
+		basis string
+		kept  string
+	}{
+		{"last_modified", "app:v1"},
+		{"blob_created", "app:v2"},
+		{"last_downloaded", "app:v1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.basis, func(t *testing.T) {
+			fake := nexus.NewFakeClient()
+			fake.AddRepository(dockerRepo("docker-hosted"), append([]nexus.Component(nil), components...)...)
+
+			cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1, time_basis: `+tt.basis+`}]`)
+			engine, _ := newTestEngine(fake, cfg, Options{})
+			result := execute(t, engine)
+
+			for id, action := range decisionsOf(result) {
+				want := ActionDelete
+				if id == tt.kept {
+					want = ActionKeep
+				}
+				if action != want {
+					t.Errorf("%s decided %s, want %s", id, action, want)
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteProtectNewest(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-dev"), numbered("dev", 3)...)
+	fake.AddRepository(dockerRepo("docker-prod"), numbered("prod", 3)...)
+
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 0, allow_delete_all: true}]
+protect_newest: false
+repository_settings:
+  docker-prod:
+    protect_newest: true
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	result := execute(t, engine)
+
+	want := []string{"dev:v1", "dev:v2", "dev:v3", "prod:v1", "prod:v2"}
+	if got := deletedTags(fake); !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+	if got := decisionsOf(result)["prod:v3"]; got != ActionProtected {
+		t.Errorf("prod:v3 decided %s, want %s", got, ActionProtected)
+	}
+}
+
+func TestExecuteMinAge(t *testing.T) {
+	now := time.Now()
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"),
+		component("app", "v4", now.Add(-time.Hour)),
+		component("app", "v3", now.Add(-2*time.Hour)),
+		component("app", "v2", now.Add(-50*time.Hour)),
+		component("app", "v1", now.Add(-60*time.Hour)),
+	)
+
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 1}]
+min_age: 2d
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	result := execute(t, engine)
+
+	if got, want := deletedTags(fake), []string{"app:v1", "app:v2"}; !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+	if got := decisionsOf(result)["app:v3"]; got != ActionProtected {
+		t.Errorf("app:v3 decided %s, want %s", got, ActionProtected)
+	}
+}
+
+func TestExecuteAlwaysKeepRegex(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), tags("app", "dev-5", "release-2", "dev-4", "dev-3", "release-1", "dev-2", "dev-1")...)
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 2, always_keep_regex: "^release-"}]`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	execute(t, engine)
+
+	// Releases are kept and keep applies to the dev tags only
+	if got, want := deletedTags(fake), []string{"app:dev-1", "app:dev-2", "app:dev-3"}; !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+}
+
+func TestExecuteAttributes(t *testing.T) {
+	components := numbered("app", 5)
+	for i, stage := range []string{"ci", "ci", "release", "ci", "ci"} {
+		components[i].Assets[0].Attributes = map[string]any{"docker": map[string]any{"stage": stage}}
+	}
+	components[3].Assets[0].Attributes["pinned"] = true
+
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), components...)
+
+	cfg := parseConfig(t, `
+rules:
+  - name: ci
+    regex: ".*"
+    keep: 1
+    attributes: {docker.stage: "^ci$"}
+    protect_attributes: {pinned: "^true$"}
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	result := execute(t, engine)
+
+	// Only ci builds count toward keep and pinned ones are protected
+	if got, want := deletedTags(fake), []string{"app:v1", "app:v4"}; !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+	decisions := decisionsOf(result)
+	if decisions["app:v3"] != ActionKeep || decisions["app:v2"] != ActionProtected {
+		t.Errorf("decisions %v", decisions)
+	}
+}
+
+func TestExecuteGroupByRegex(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), tags("app", "5-prod", "5-dev", "4-dev", "4-prod", "3-dev", "3-prod", "2", "1")...)
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1, group_by_regex: "-(dev|prod)$"}]`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	execute(t, engine)
+
+	// The newest tag of each environment and of the ungrouped tags is kept
+	want := []string{"app:1", "app:3-dev", "app:3-prod", "app:4-dev", "app:4-prod"}
+	if got := deletedTags(fake); !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+}
+
+func TestExecuteProtectedCountTowardKeep(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		keep     string
+		deleted  []string
+	}{
+		{
+			name:    "kept in addition",
+			keep:    "2",
+			deleted: []string{"app:v1", "app:v2"},
+		},
+		{
+			name:     "counted",
+			settings: "protected_count_toward_keep: true",
+			keep:     "2",
+			deleted:  []string{"app:v1", "app:v2", "app:v3"},
+		},
+		{
+			name:     "protected fill the quota",
+			settings: "protected_count_toward_keep: true",
+			keep:     "1",
+			deleted:  []string{"app:v1", "app:v2", "app:v3", "app:v5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := nexus.NewFakeClient()
+			fake.AddRepository(dockerRepo("docker-hosted"), tags("app", "v5", "stable", "v3", "v2", "v1")...)
+
+			cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: `+tt.keep+`}]
+protected_tags: [stable]
+`+tt.settings)
+			engine, _ := newTestEngine(fake, cfg, Options{})
+			execute(t, engine)
+
+			if got := deletedTags(fake); !equalStrings(got, tt.deleted) {
+				t.Errorf("deleted %v, want %v", got, tt.deleted)
+			}
+		})
+	}
+}
+
+func TestExecuteOrdersByDownloadRecency(t *testing.T) {
+	downloaded := func(comp nexus.Component, ago time.Duration) nexus.Component {
+		comp.Assets[0].LastDownloaded = testTime.Add(-ago)
+		return comp
+	}
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"),
+		component("app", "new-unused", testTime),
+		downloaded(component("app", "old-busy", testTime.Add(-5*time.Hour)), time.Hour),
+		downloaded(component("app", "mid", testTime.Add(-2*time.Hour)), 3*time.Hour),
+		component("app", "old-unused", testTime.Add(-4*time.Hour)),
+	)
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 2, time_basis: last_downloaded}]`)
+	engine, _ := newTestEngine(fake, cfg, Options{DryRun: true})
+	result := execute(t, engine)
+
+	// Never downloaded tags sort oldest, by last modified among themselves
+	var order []string
+	for _, d := range result.Repositories[0].Images[0].Decisions {
+		order = append(order, string(d.Action)+" "+d.Component.Version)
+	}
+	want := []string{"KEEP old-busy", "KEEP mid", "DELETE new-unused", "DELETE old-unused"}
+	if !equalStrings(order, want) {
+		t.Errorf("decisions %v, want %v", order, want)
+	}
+}
+
+func TestExecuteProtectMostRecentFromAge(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		deleted  []string
+	}{
+		{name: "off", deleted: []string{"app:new-unused", "app:old-unused"}},
+		{name: "on", settings: "protect_most_recent_from_age: true", deleted: []string{"app:old-unused"}},
+		{name: "streamed", settings: "protect_most_recent_from_age: true\nstream_components: true", deleted: []string{"app:old-unused"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The most recently pushed tag was never downloaded, so it
+			// ranks oldest by download recency
+			fake := nexus.NewFakeClient()
+			busy := component("app", "busy", testTime.Add(-5*time.Hour))
+			busy.Assets[0].LastDownloaded = testTime
+			fake.AddRepository(dockerRepo("docker-hosted"),
+				component("app", "new-unused", testTime),
+				busy,
+				component("app", "old-unused", testTime.Add(-4*time.Hour)),
+			)
+
+			cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1, time_basis: last_downloaded}]`+"\n"+tt.settings)
+			engine, _ := newTestEngine(fake, cfg, Options{})
+			result := execute(t, engine)
+
+			if got := deletedTags(fake); !equalStrings(got, tt.deleted) {
+				t.Errorf("deleted %v, want %v", got, tt.deleted)
+			}
+			if tt.name == "on" && decisionsOf(result)["app:new-unused"] != ActionProtected {
+				t.Errorf("app:new-unused decided %v, want protected", decisionsOf(result)["app:new-unused"])
+			}
+		})
+	}
+}
+
+func TestExecuteGroupKeep(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), tags("app",
+		"6-prod", "6-staging", "5-prod", "5-staging", "4-prod", "4-staging", "3-prod", "3-staging", "2", "1")...)
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1, group_by_regex: "-(staging|prod)$", group_keep: {prod: 3, staging: 2}}]`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	execute(t, engine)
+
+	// Each environment keeps its own count, ungrouped tags keep keep
+	want := []string{"app:1", "app:3-prod", "app:3-staging", "app:4-staging"}
+	if got := deletedTags(fake); !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+}
+
+func TestExecuteFloatingTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		deleted  []string
+		// decided reports whether latest is evaluated at all
+		decided bool
+	}{
+		{name: "normal", deleted: []string{"app:stable", "app:v1", "app:v2"}, decided: true},
+		{name: "protect", settings: "floating_tag_mode: protect", deleted: []string{"app:v1", "app:v2"}, decided: true},
+		{name: "ignore", settings: "floating_tag_mode: ignore", deleted: []string{"app:v1", "app:v2"}},
+		{name: "protect several", settings: "floating_tag_mode: protect\nfloating_tags: [latest, stable]", deleted: []string{"app:v1"}, decided: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := nexus.NewFakeClient()
+			fake.AddRepository(dockerRepo("docker-hosted"), tags("app", "latest", "v3", "stable", "v2", "v1")...)
+
+			cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 2}]`+"\n"+tt.settings)
+			engine, _ := newTestEngine(fake, cfg, Options{})
+			result := execute(t, engine)
+
+			if got := deletedTags(fake); !equalStrings(got, tt.deleted) {
+				t.Errorf("deleted %v, want %v", got, tt.deleted)
+			}
+			if _, ok := decisionsOf(result)["app:latest"]; ok != tt.decided {
+				t.Errorf("latest decided = %t, want %t", ok, tt.decided)
+			}
+		})
+	}
+}
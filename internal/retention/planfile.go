@@ -0,0 +1,209 @@
+package retention
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"nexus-retention-policy/internal/logger"
+	"nexus-retention-policy/internal/nexus"
+)
+
+// Plan is a reviewed set of deletions that can be applied later.
+type Plan struct {
+	ExecutionID string            `json:"execution_id"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Deletions   []PlannedDeletion `json:"deletions"`
+}
+
+// PlannedDeletion identifies a single component to delete.
+type PlannedDeletion struct {
+	Repository  string `json:"repository"`
+	ImageName   string `json:"image_name"`
+	Tag         string `json:"tag"`
+	ComponentID string `json:"component_id"`
+	Rule        string `json:"rule"`
+}
+
+func (pl *Plan) add(repoName, imageName, ruleName string, comp nexus.Component) {
+	pl.Deletions = append(pl.Deletions, PlannedDeletion{
+		Repository:  repoName,
+		ImageName:   imageName,
+		Tag:         comp.Version,
+		ComponentID: comp.ID,
+		Rule:        ruleName,
+	})
+}
+
+// Save writes the plan as JSON.
+func (pl *Plan) Save(path string) error {
+	data, err := json.MarshalIndent(pl, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode plan: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write plan file: %w", err)
+	}
+	return nil
+}
+
+// LoadPlan reads a plan written by Save or SaveStable.
+func LoadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan file: %w", err)
+	}
+
+	var format struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if json.Unmarshal(data, &format) == nil && format.SchemaVersion > 0 {
+		return parseStablePlan(data)
+	}
+
+	var pl Plan
+	if err := json.Unmarshal(data, &pl); err != nil {
+		return nil, fmt.Errorf("failed to parse plan file: %w", err)
+	}
+	return &pl, nil
+}
+
+// Plan runs the policy in dry-run mode and returns the deletions it would
+// perform with the result of the run. On a PartialFailureError the plan is
+// returned as well, without the repositories that couldn't be listed.
+func (p *PolicyEngine) Plan() (*Plan, *RunResult, error) {
+	p.planned = &Plan{CreatedAt: time.Now()}
+	defer func() { p.planned = nil }()
+
+	result, err := p.Execute()
+	var partial *PartialFailureError
+	if err != nil && !errors.As(err, &partial) {
+		return nil, result, err
+	}
+
+	plan := p.planned
+	plan.ExecutionID = p.executionID
+	return plan, result, err
+}
+
+// Apply deletes exactly the components of a plan. Components that no longer
+// exist are skipped, so a plan can be applied safely after review.
+func (p *PolicyEngine) Apply(plan *Plan) error {
+	p.executionID = newExecutionID()
+	p.alreadyDeleted = 0
+	p.cache.reset()
+	p.errors = newErrorSummary()
+	p.breaker = newCircuitBreaker(p.config.CircuitBreaker)
+	p.unlogged, p.logAbort = nil, nil
+	p.limiter = newAIMDLimiter(p.config.DeleteConcurrency)
+	p.progress = newProgress(time.Now())
+	p.dryRun = p.options.DryRun
+
+	// Stable plans don't record the execution that planned them
+	if plan.ExecutionID != "" {
+		fmt.Printf("Applying plan %s (%d deletions)\n", plan.ExecutionID, len(plan.Deletions))
+	} else {
+		fmt.Printf("Applying plan (%d deletions)\n", len(plan.Deletions))
+	}
+	fmt.Printf("Execution ID: %s\n", p.executionID)
+
+	deleted := 0
+	p.logRun(logger.RunStarted, 0, 0)
+	defer func() { p.logRun(logger.RunFinished, deleted, 0) }()
+
+	if !p.dryRun && !p.options.Force && !p.config.InAllowedHours(time.Now()) {
+		return &GuardrailError{Reason: fmt.Sprintf("outside allowed hours (%s), use -force to override", p.config.AllowedHours)}
+	}
+
+	// Re-validate that planned components still exist
+	existing := make(map[string]map[string]nexus.Component)
+	var toApply []PlannedDeletion
+	var components []nexus.Component
+	for _, d := range plan.Deletions {
+		byID, ok := existing[d.Repository]
+		if !ok {
+			listed, err := p.client.GetComponents(d.Repository)
+			if err != nil {
+				return fmt.Errorf("failed to get components of %s: %w", d.Repository, err)
+			}
+			byID = make(map[string]nexus.Component)
+			for _, comp := range listed {
+				byID[comp.ID] = comp
+			}
+			existing[d.Repository] = byID
+		}
+
+		comp, ok := byID[d.ComponentID]
+		if !ok {
+			fmt.Printf("  ⏭️  %s/%s:%s no longer exists, skipping\n", d.Repository, d.ImageName, d.Tag)
+			continue
+		}
+		toApply = append(toApply, d)
+		components = append(components, comp)
+	}
+
+	// Deletions are logged as they complete, so a log_failure: abort stops
+	// the remaining ones
+	apply := func(d PlannedDeletion, err error) {
+		if !p.dryRun {
+			if errors.Is(err, errAborted) {
+				return
+			} else if isAlreadyDeleted(err) {
+				p.alreadyDeleted++
+				fmt.Printf("  ℹ️  %s/%s:%s was already deleted\n", d.Repository, d.ImageName, d.Tag)
+			} else if err != nil {
+				category := p.errors.add(fmt.Sprintf("%s/%s:%s", d.Repository, d.ImageName, d.Tag), err)
+				fmt.Printf("  ⚠️  Failed to delete %s/%s:%s (%s)\n", d.Repository, d.ImageName, d.Tag, category)
+				return
+			} else {
+				fmt.Printf("  🗑️  Deleted %s/%s:%s\n", d.Repository, d.ImageName, d.Tag)
+			}
+		} else {
+			fmt.Printf("  🗑️  Would delete %s/%s:%s\n", d.Repository, d.ImageName, d.Tag)
+		}
+
+		p.logDeletion(logger.DeletionRecord{
+			ExecutionID: p.executionID,
+			Timestamp:   time.Now(),
+			Repository:  d.Repository,
+			ImageName:   d.ImageName,
+			Tag:         d.Tag,
+			ComponentID: d.ComponentID,
+			Rule:        d.Rule,
+			DryRun:      p.dryRun,
+		})
+		deleted++
+	}
+
+	if p.dryRun {
+		for _, d := range toApply {
+			apply(d, nil)
+		}
+	} else {
+		if p.config.BackupManifest != "" {
+			if err := p.backupPlan(toApply, components); err != nil {
+				return err
+			}
+		}
+		p.deleteAll(components, func(i int, err error) { apply(toApply[i], err) })
+	}
+
+	fmt.Printf("\n✅ Plan applied (%s)\n", p.executionID)
+	fmt.Printf("   Deleted: %d components\n", deleted)
+	fmt.Printf("   Skipped: %d components\n", len(plan.Deletions)-len(toApply))
+	if p.alreadyDeleted > 0 {
+		fmt.Printf("   Already deleted: %d components\n", p.alreadyDeleted)
+	}
+	if !p.dryRun && deleted > 0 {
+		fmt.Printf("   Rate: %s\n", p.progress.summary(time.Now()))
+	}
+	p.flushUnlogged()
+	p.errors.print()
+
+	if err := p.abortErr(); err != nil {
+		return err
+	}
+	return p.errors.err()
+}
@@ -0,0 +1,81 @@
+package retention
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+func TestSaveLoadPlan(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), numbered("app", 3)...)
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`)
+	engine, _ := newTestEngine(fake, cfg, Options{DryRun: true})
+	plan, _, err := engine.Plan()
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := plan.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := LoadPlan(path)
+	if err != nil {
+		t.Fatalf("LoadPlan: %v", err)
+	}
+	if loaded.ExecutionID != plan.ExecutionID || !reflect.DeepEqual(loaded.Deletions, plan.Deletions) {
+		t.Errorf("loaded %+v, want %+v", loaded, plan)
+	}
+}
+
+func TestApplyPlan(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), numbered("app", 4)...)
+
+	plan := &Plan{}
+	for _, comp := range numbered("app", 4)[1:] {
+		plan.add("docker-hosted", "app", "all", comp)
+	}
+	// Deleted since the plan was made, so it's skipped
+	if err := fake.DeleteComponent("app:v1"); err != nil {
+		t.Fatal(err)
+	}
+	fake.Deleted = nil
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`)
+	engine, log := newTestEngine(fake, cfg, Options{Force: true})
+	if err := engine.Apply(plan); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	if got, want := deletedTags(fake), []string{"app:v2", "app:v3"}; !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+	if got := len(log.Records()); got != 2 {
+		t.Errorf("logged %d deletions, want 2", got)
+	}
+}
+
+func TestApplyPlanDryRun(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), numbered("app", 2)...)
+
+	plan := &Plan{}
+	plan.add("docker-hosted", "app", "all", numbered("app", 2)[1])
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`)
+	engine, log := newTestEngine(fake, cfg, Options{DryRun: true})
+	if err := engine.Apply(plan); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if len(fake.Deleted) != 0 {
+		t.Errorf("dry run deleted %v", fake.Deleted)
+	}
+	if records := log.Records(); len(records) != 1 || !records[0].DryRun {
+		t.Errorf("logged %+v, want one dry-run record", records)
+	}
+}
@@ -1,21 +1,130 @@
 package retention
 
 import (
+	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"regexp"
+	"slices"
 	"sort"
+	"strings"
 	"time"
 
 	"nexus-retention-policy/internal/config"
 	"nexus-retention-policy/internal/logger"
 	"nexus-retention-policy/internal/nexus"
+	"nexus-retention-policy/internal/tracing"
 )
 
+// Registry is the backend cleaned by the policy engine. Nexus is the
+// default; other registries map their repositories and images to the Nexus
+// types.
+type Registry interface {
+	GetRepositories() ([]nexus.Repository, error)
+	GetComponents(repository string) ([]nexus.Component, error)
+	DeleteComponent(componentID string) error
+}
+
+// AssetDeleter is implemented by registries that can delete single assets
+// of a component. Only Nexus supports asset rules.
+type AssetDeleter interface {
+	DeleteAsset(assetID string) error
+}
+
+// TruncationReporter is implemented by registries whose listings can be cut
+// off by max_components_per_repo with fewer components than the limit, e.g.
+// because they leave out items after applying it. Only Artifactory needs it.
+type TruncationReporter interface {
+	Truncated(repository string) bool
+}
+
+// listingTruncated reports whether the last listing of the repository was
+// cut off, if the registry reports it.
+func listingTruncated(registry Registry, repository string) bool {
+	reporter, ok := registry.(TruncationReporter)
+	return ok && reporter.Truncated(repository)
+}
+
+// DeletionLogger records deletions performed or planned by the policy engine.
+type DeletionLogger interface {
+	LogDeletion(record logger.DeletionRecord) error
+}
+
+// RunLogger is implemented by deletion loggers that also record the start
+// and end of runs.
+type RunLogger interface {
+	LogRun(record logger.RunRecord) error
+}
+
 type PolicyEngine struct {
-	client  *nexus.Client
+	client  Registry
 	config  *config.Config
-	logger  *logger.Logger
+	logger  DeletionLogger
+	options Options
 	dryRun  bool
-	verbose bool
+
+	// cache holds the component listings of the current run
+	cache *componentCache
+	// deletionQuota holds the IDs of components that may be deleted from the
+	// current repository under max_deletions_per_repo, nil without a cap
+	deletionQuota map[string]bool
+	// executionID identifies the current Execute run in logs
+	executionID string
+	// protectionOverrides counts protected tags a rule would have deleted
+	protectionOverrides int
+	// overrides records protection overrides when a report path is set
+	overrides *overrideReport
+	// backup records the metadata of components before they are deleted
+	// when backup_manifest is set
+	backup *backupManifest
+	// alreadyDeleted counts deletions that found the component already gone
+	alreadyDeleted int
+	// ruleDryRuns counts deletions skipped by rules in dry-run mode
+	ruleDryRuns int
+	// assetsDeleted counts assets deleted by asset rules
+	assetsDeleted int
+	// result collects the outcome of the current Execute run
+	result *RunResult
+	// emptyImages lists images left without tags in the current run
+	emptyImages []string
+	// quarantined counts components that entered quarantine in this run
+	quarantined int
+	// quarantine holds components before deletion, nil without
+	// quarantine_days
+	quarantine *quarantine
+	// inUse lists running images loaded from the in-use file
+	inUse *inUseSet
+	// golden lists versions per image loaded from the golden versions file
+	golden goldenVersions
+	// locked lists image references loaded from the deploy lockfile
+	locked lockedRefs
+	// errors aggregates failures of the current run by category
+	errors *errorSummary
+	// breaker aborts the current run when requests fail broadly, nil
+	// without thresholds
+	breaker *circuitBreaker
+	// unlogged holds deletions whose log write failed, to be retried with
+	// log_failure: buffer-retry
+	unlogged []unloggedDeletion
+	// logAbort stops the current run after a failed log write with
+	// log_failure: abort
+	logAbort error
+	// limiter adapts deletion concurrency to Nexus rate limiting
+	limiter *aimdLimiter
+	// planned collects deletions while building a plan, nil otherwise
+	planned *Plan
+	// progress tracks deletion throughput of the current run
+	progress *progress
+	// since is the start of the last run with --since-last-run, zero for a
+	// full scan
+	since time.Time
+	// unchangedImages counts images skipped by an incremental run
+	unchangedImages int
+	// ruleMatches counts images matched per rule name in the current run
+	ruleMatches map[string]int
+	// ctx carries the current trace span for Nexus API calls
+	ctx context.Context
 }
 
 type ImageGroup struct {
@@ -23,67 +132,600 @@ type ImageGroup struct {
 	Components []nexus.Component
 }
 
-func NewPolicyEngine(client *nexus.Client, cfg *config.Config, log *logger.Logger, dryRun bool, verbose bool) *PolicyEngine {
-	return &PolicyEngine{
-		client:  client,
+// Verbosity controls how much per-component detail is printed.
+type Verbosity int
+
+const (
+	// VerbositySummary prints repositories and the run summary only
+	VerbositySummary Verbosity = iota
+	// VerbosityImage also prints each matched image with its rule
+	VerbosityImage
+	// VerbosityTag also prints every tag's decision, unmatched images and
+	// full deletion errors
+	VerbosityTag
+)
+
+// Options controls how the policy engine runs.
+type Options struct {
+	// DryRun reports deletions without performing them
+	DryRun bool
+	// Verbosity is the level of per-image and per-tag output
+	Verbosity Verbosity
+	// Force deletes outside of the configured allowed hours
+	Force bool
+	// MaxRepos limits processing to the first N repositories by name (0 = all)
+	MaxRepos int
+	// ProtectedOverrideReport appends protected components a rule would
+	// have deleted to this CSV file (empty = no report)
+	ProtectedOverrideReport string
+	// Rule restricts the run to images whose matching rule has this name,
+	// skipping asset rules (empty = all rules)
+	Rule string
+	// CheckPermissions checks the delete permission of each repository in
+	// dry-run mode
+	CheckPermissions bool
+	// SinceLastRun skips images without components modified since the last
+	// successful run recorded in the state file
+	SinceLastRun bool
+	// ListLimit truncates the per-image tag listing to N entries (0 = all)
+	ListLimit int
+	// Status receives the progress of each run (nil = not tracked)
+	Status *StatusTracker
+	// Tracer records spans for runs, repositories and Nexus API calls
+	Tracer *tracing.Tracer
+}
+
+func NewPolicyEngine(client Registry, cfg *config.Config, log DeletionLogger, opts Options) *PolicyEngine {
+	p := &PolicyEngine{
 		config:  cfg,
 		logger:  log,
-		dryRun:  dryRun,
-		verbose: verbose,
+		options: opts,
+		ctx:     context.Background(),
 	}
+	p.cache = newComponentCache(&tracedAPI{next: client, tracer: opts.Tracer, engine: p})
+	p.client = p.cache
+	return p
 }
 
-func (p *PolicyEngine) Execute() error {
+// Execute applies the retention rules to all repositories and returns the
+// outcome. The result is also returned with a PartialFailureError or
+// CircuitOpenError; it is nil if the run failed before processing
+// repositories.
+func (p *PolicyEngine) Execute() (result *RunResult, err error) {
+	p.executionID = newExecutionID()
+	p.result = &RunResult{ExecutionID: p.executionID, StartedAt: time.Now()}
+
+	ctx, span := p.options.Tracer.Start(context.Background(), "retention.execute")
+	span.SetAttribute("retention.execution_id", p.executionID)
+	p.ctx = ctx
+	defer func() {
+		span.RecordError(err)
+		span.End()
+		p.flushTraces()
+	}()
+
+	p.protectionOverrides = 0
+	p.alreadyDeleted = 0
+	p.ruleDryRuns = 0
+	p.assetsDeleted = 0
+	p.quarantined = 0
+	p.emptyImages = nil
+	p.cache.reset()
+	p.errors = newErrorSummary()
+	p.breaker = newCircuitBreaker(p.config.CircuitBreaker)
+	p.unlogged, p.logAbort = nil, nil
+	p.limiter = newAIMDLimiter(p.config.DeleteConcurrency)
+	p.progress = newProgress(time.Now())
+	p.ruleMatches = make(map[string]int)
+
 	fmt.Println("Starting retention policy execution...")
+	fmt.Printf("Execution ID: %s\n", p.executionID)
+
+	p.dryRun = p.options.DryRun || p.planned != nil
+	if !p.dryRun && !p.options.Force && !p.config.InAllowedHours(time.Now()) {
+		fmt.Printf("⏸️  Outside allowed hours (%s), skipping deletions (use -force to override)\n", p.config.AllowedHours)
+		p.dryRun = true
+	}
+
+	p.result.DryRun = p.dryRun
+	p.options.Status.start(p.executionID, p.dryRun, time.Now())
+	defer func() { p.options.Status.finish(time.Now()) }()
+
+	totalDeleted := 0
+	totalKept := 0
+	p.logRun(logger.RunStarted, 0, 0)
+	defer func() { p.logRun(logger.RunFinished, totalDeleted, totalKept) }()
+
 	if p.dryRun {
 		fmt.Println("🔍 DRY RUN MODE - No actual deletions will be performed")
 	} else {
 		fmt.Println("⚠️  EXECUTION MODE - Deletions will be performed")
 	}
 
-	repos, err := p.client.GetDockerRepositories()
-	if err != nil {
-		return fmt.Errorf("failed to get repositories: %w", err)
+	if p.options.Rule != "" {
+		fmt.Printf("Only applying rule: %s\n", p.options.Rule)
+	}
+
+	if err := p.loadProtections(); err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("Found %d Docker hosted repositories\n", len(repos))
+	// Streaming doesn't rank protected components or hold whole images
+	if p.config.StreamComponents && p.options.ProtectedOverrideReport != "" {
+		fmt.Println("⚠️  Protected override reports are not supported with stream_components, skipping report")
+	}
+	if p.config.StreamComponents && p.options.SinceLastRun {
+		fmt.Println("⚠️  --since-last-run is not supported with stream_components, scanning all images")
+	}
 
-	totalDeleted := 0
-	totalKept := 0
+	p.overrides = nil
+	if p.options.ProtectedOverrideReport != "" && !p.config.StreamComponents {
+		overrides, err := openOverrideReport(p.options.ProtectedOverrideReport)
+		if err != nil {
+			return nil, err
+		}
+		p.overrides = overrides
+		defer overrides.close()
+	}
 
-	for _, repo := range repos {
-		fmt.Printf("\n📦 Processing repository: %s\n", repo.Name)
+	p.backup = nil
+	if p.config.BackupManifest != "" && !p.dryRun {
+		backup, err := openBackupManifest(p.config.BackupManifest)
+		if err != nil {
+			return nil, err
+		}
+		p.backup = backup
+		defer backup.close()
+	}
+
+	p.since, p.unchangedImages = time.Time{}, 0
+	if p.options.SinceLastRun && !p.config.StreamComponents {
+		since, err := p.loadSince()
+		if err != nil {
+			return nil, err
+		}
+		p.since = since
+	}
 
-		components, err := p.client.GetComponents(repo.Name)
+	p.quarantine = nil
+	if p.config.QuarantineDays > 0 {
+		q, err := loadQuarantine(p.config.QuarantineFile, time.Duration(p.config.QuarantineDays)*24*time.Hour)
 		if err != nil {
-			fmt.Printf("  ⚠️  Error getting components: %v\n", err)
+			return nil, err
+		}
+		p.quarantine = q
+	}
+
+	// Collect the planned deletions to compare them with the last dry run
+	trackDelta := p.dryRun && p.config.LastPlanFile != ""
+	if trackDelta && p.planned == nil {
+		p.planned = &Plan{CreatedAt: time.Now()}
+		defer func() { p.planned = nil }()
+	}
+
+	allRepos, err := p.client.GetRepositories()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repositories: %w", err)
+	}
+
+	p.checkRepositoryTypes(allRepos)
+
+	var repos []nexus.Repository
+	excluded := 0
+	for _, repo := range allRepos {
+		if !p.isCleanable(repo) {
 			continue
 		}
+		if !p.config.IncludesRepository(repo.Name) {
+			excluded++
+			continue
+		}
+		if p.config.SkipOffline && !repo.IsOnline() {
+			fmt.Printf("⏭️  Skipping offline repository: %s\n", repo.Name)
+			continue
+		}
+		repos = append(repos, repo)
+	}
 
-		fmt.Printf("  Found %d components\n", len(components))
+	fmt.Printf("Found %d hosted repositories\n", len(repos))
+	if excluded > 0 {
+		fmt.Printf("Skipped %d repositories excluded by config\n", excluded)
+	}
+
+	// Process in a stable order regardless of server ordering
+	sort.Slice(repos, func(i, j int) bool {
+		return repos[i].Name < repos[j].Name
+	})
+
+	if p.options.MaxRepos > 0 && len(repos) > p.options.MaxRepos {
+		repos = repos[:p.options.MaxRepos]
+
+		names := make([]string, len(repos))
+		for i, repo := range repos {
+			names[i] = repo.Name
+		}
+		fmt.Printf("Limiting run to %d repositories: %s\n", len(repos), strings.Join(names, ", "))
+	}
 
-		// Group components by image name
-		imageGroups := p.groupByImageName(components)
+	p.options.Status.setRepositories(len(repos))
+	for _, repo := range repos {
+		p.options.Status.startRepository(repo.Name)
+		deleted, kept := p.processRepository(ctx, repo)
+		totalDeleted += deleted
+		totalKept += kept
+		p.options.Status.finishRepository()
 
-		for imageName, group := range imageGroups {
-			deleted, kept := p.processImageGroup(repo.Name, imageName, group)
-			totalDeleted += deleted
-			totalKept += kept
+		if err := p.abortErr(); err != nil {
+			fmt.Printf("\n🛑 Aborting run: %v\n", err)
+			break
 		}
 	}
 
-	fmt.Printf("\n✅ Execution completed\n")
+	span.SetAttribute("retention.deleted", totalDeleted)
+	span.SetAttribute("retention.kept", totalKept)
+
+	fmt.Printf("\n✅ Execution completed (%s)\n", p.executionID)
 	fmt.Printf("   Deleted: %d components\n", totalDeleted)
 	fmt.Printf("   Kept: %d components\n", totalKept)
+	if p.config.WarnProtectedOverrides || p.overrides != nil {
+		fmt.Printf("   Protection overrides: %d\n", p.protectionOverrides)
+	}
+	if p.alreadyDeleted > 0 {
+		fmt.Printf("   Already deleted: %d components\n", p.alreadyDeleted)
+	}
+	if p.assetsDeleted > 0 {
+		fmt.Printf("   Assets deleted: %d\n", p.assetsDeleted)
+	}
+	if p.ruleDryRuns > 0 {
+		fmt.Printf("   Dry-run rules: %d components would be deleted\n", p.ruleDryRuns)
+	}
+	if p.quarantined > 0 {
+		fmt.Printf("   Quarantined: %d components\n", p.quarantined)
+	}
+	if p.unchangedImages > 0 {
+		fmt.Printf("   Unchanged images skipped: %d\n", p.unchangedImages)
+	}
+	if !p.dryRun && totalDeleted > 0 {
+		fmt.Printf("   Rate: %s\n", p.progress.summary(time.Now()))
+	}
+	p.reportEmptyImages()
+	p.printRuleStats(p.ruleStats(p.result.images()))
+	p.flushUnlogged()
+	p.errors.print()
+	// Rules of repositories skipped by an aborted run aren't dead
+	if p.abortErr() == nil {
+		p.reportDeadRules()
+	}
+	p.finishResult(totalDeleted, totalKept)
+	p.emailReport()
+
+	// Aborted runs keep the marks of the last run that completed
+	if p.quarantine != nil && !p.dryRun && p.abortErr() == nil {
+		if err := p.quarantine.save(); err != nil {
+			return p.result, err
+		}
+	}
+
+	// An aborted run planned only part of the deletions
+	if err := p.abortErr(); err != nil {
+		return p.result, err
+	}
+
+	if trackDelta {
+		p.planned.ExecutionID = p.executionID
+		if err := p.reportDelta(p.planned, p.config.LastPlanFile); err != nil {
+			return p.result, err
+		}
+	}
+
+	// Only executions that covered all images without errors may be the
+	// base of incremental runs
+	if p.options.SinceLastRun && !p.dryRun && p.options.MaxRepos == 0 && p.options.Rule == "" && p.errors.total() == 0 {
+		if err := p.saveSince(p.result.StartedAt); err != nil {
+			return p.result, err
+		}
+	}
+
+	return p.result, p.errors.err()
+}
+
+// processRepository applies the retention rules to all images of a
+// repository and returns the number of deleted and kept components.
+func (p *PolicyEngine) processRepository(ctx context.Context, repo nexus.Repository) (deleted, kept int) {
+	repoCtx, span := p.options.Tracer.Start(ctx, "retention.repository")
+	span.SetAttribute("nexus.repository", repo.Name)
+	p.ctx = repoCtx
+	p.result.Repositories = append(p.result.Repositories, RepositoryResult{Name: repo.Name})
+	defer func() {
+		span.SetAttribute("retention.deleted", deleted)
+		span.SetAttribute("retention.kept", kept)
+		span.End()
+		p.ctx = ctx
+		p.result.repository().Deleted, p.result.repository().Kept = deleted, kept
+		p.result.repository().Rules = p.ruleStats(p.result.repository().Images)
+		if dir := p.config.PerRepoReportsDir; dir != "" {
+			if err := p.writeRepositoryReport(dir, p.result.repository()); err != nil {
+				fmt.Printf("  ⚠️  %v\n", err)
+			}
+		}
+	}()
+
+	fmt.Printf("\n📦 Processing repository: %s\n", repo.Name)
+
+	if p.dryRun && p.options.CheckPermissions {
+		p.checkDeletePermission(repo)
+	}
+
+	if p.config.StreamComponents {
+		deleted, kept, err := p.streamRepository(repo.Name)
+		if !errors.Is(err, errStreamingUnsupported) {
+			if err != nil {
+				span.RecordError(err)
+				category := p.errors.add(repo.Name, err)
+				fmt.Printf("  ⚠️  Error streaming components (%s): %v\n", category, err)
+				p.result.repository().Error = err.Error()
+			}
+			return deleted, kept
+		}
+		fmt.Println("  ⚠️  Registry can't list components page by page, listing the repository at once")
+	}
+
+	components, err := p.client.GetComponents(repo.Name)
+	if err != nil {
+		span.RecordError(err)
+		category := p.errors.add(repo.Name, err)
+		fmt.Printf("  ⚠️  Error getting components (%s): %v\n", category, err)
+		p.result.repository().Error = err.Error()
+		return 0, 0
+	}
+
+	if max := p.config.MaxComponentsPerRepo; max > 0 && (len(components) >= max || listingTruncated(p.client, repo.Name)) {
+		fmt.Printf("  ⚠️  Listing reached max_components_per_repo (%d), skipping repository to avoid deleting based on a partial listing\n", max)
+		p.result.repository().Error = fmt.Sprintf("listing reached max_components_per_repo (%d)", max)
+		return 0, 0
+	}
+
+	if err := p.enrichDetails(components); err != nil {
+		span.RecordError(err)
+		category := p.errors.add(repo.Name, err)
+		fmt.Printf("  ⚠️  Error enriching components (%s), skipping repository: %v\n", category, err)
+		p.result.repository().Error = err.Error()
+		return 0, 0
+	}
+
+	stats := p.Scan(repo.Name, components)
+	p.result.repository().Stats = stats
+	stats.Print()
+
+	if len(p.config.ExcludeBlobStores) > 0 {
+		components = p.filterBlobStores(components)
+	}
+
+	if p.config.FloatingTagMode == config.FloatingTagIgnore {
+		components = p.filterFloatingTags(components)
+	}
+
+	if len(p.config.AssetRules) > 0 && p.options.Rule == "" {
+		p.processAssetRules(repo.Name, components)
+	}
+
+	// Group components by image name
+	imageGroups := p.groupByImageName(components)
+
+	imageNames := make([]string, 0, len(imageGroups))
+	for imageName := range imageGroups {
+		imageNames = append(imageNames, imageName)
+	}
+	sort.Strings(imageNames)
+
+	p.deletionQuota = nil
+	if p.config.MaxDeletionsPerRepo > 0 {
+		p.deletionQuota = p.selectDeletions(repo.Name, imageGroups, p.config.MaxDeletionsPerRepo)
+	}
+
+	for _, imageName := range imageNames {
+		if p.abortErr() != nil {
+			break
+		}
+		d, k := p.processImageGroup(repo.Name, imageName, imageGroups[imageName])
+		deleted += d
+		kept += k
+		p.options.Status.addImage(d, k)
+	}
+
+	return deleted, kept
+}
+
+// selectDeletions plans all images of a repository and returns the IDs of
+// the oldest max components planned for deletion. Later runs delete the
+// rest.
+func (p *PolicyEngine) selectDeletions(repoName string, imageGroups map[string][]nexus.Component, max int) map[string]bool {
+	type candidate struct {
+		id   string
+		time time.Time
+	}
+
+	var candidates []candidate
+	for imageName, components := range imageGroups {
+		rule := p.matchRule(repoName, imageName, components)
+		if rule == nil {
+			continue
+		}
+
+		p.sortComponents(rule, components)
+		if rule.DryRun {
+			continue
+		}
+		for _, d := range p.planImage(repoName, rule, components) {
+			if d.Action == ActionDelete {
+				candidates = append(candidates, candidate{d.Component.ID, p.componentTime(d.Component, rule.TimeBasis)})
+			}
+		}
+	}
+
+	// Oldest first, ties broken by ID for a deterministic selection
+	sort.Slice(candidates, func(i, j int) bool {
+		if !candidates[i].time.Equal(candidates[j].time) {
+			return candidates[i].time.Before(candidates[j].time)
+		}
+		return candidates[i].id < candidates[j].id
+	})
+
+	if len(candidates) > max {
+		fmt.Printf("  Deferring %d of %d deletions to later runs (max_deletions_per_repo: %d)\n", len(candidates)-max, len(candidates), max)
+		candidates = candidates[:max]
+	}
+
+	quota := make(map[string]bool, len(candidates))
+	for _, c := range candidates {
+		quota[c.id] = true
+	}
+	return quota
+}
+
+// loadProtections reads the in-use, golden versions and lock files, which
+// may change between runs.
+func (p *PolicyEngine) loadProtections() error {
+	p.inUse = nil
+	if p.config.InUseFile != "" {
+		inUse, err := loadInUse(p.config.InUseFile)
+		if err != nil {
+			return err
+		}
+		p.inUse = inUse
+		fmt.Printf("Loaded %d in-use digests and %d in-use images\n", len(inUse.digests), len(inUse.refs))
+	}
+
+	p.golden = nil
+	if p.config.GoldenVersionsFile != "" {
+		golden, err := loadGolden(p.config.GoldenVersionsFile)
+		if err != nil {
+			return err
+		}
+		p.golden = golden
+		fmt.Printf("Loaded %d golden versions for %d images\n", golden.count(), len(golden))
+	}
+
+	p.locked = nil
+	if p.config.LockFile != "" {
+		locked, err := loadLockfile(p.config.LockFile)
+		if err != nil {
+			return err
+		}
+		p.locked = locked
+		fmt.Printf("Loaded %d locked images\n", len(locked))
+	}
 
 	return nil
 }
 
+// checkRepositoryTypes warns about rules referencing repositories that are
+// missing or can't be cleaned (proxy and group repositories).
+func (p *PolicyEngine) checkRepositoryTypes(repos []nexus.Repository) {
+	byName := make(map[string]nexus.Repository)
+	for _, repo := range repos {
+		byName[repo.Name] = repo
+	}
+
+	for _, name := range p.config.ReferencedRepositories() {
+		repo, ok := byName[name]
+		if !ok {
+			fmt.Printf("⚠️  Repository '%s' referenced in rules was not found\n", name)
+			continue
+		}
+		if !p.isCleanable(repo) {
+			fmt.Printf("⚠️  Repository '%s' referenced in rules is a %s %s repository and will not be cleaned\n", name, repo.Format, repo.Type)
+		}
+	}
+}
+
+// matchRule returns the rule for an image with its keep count scaled by
+// downloads if the rule has keep_by_downloads, or nil.
+func (p *PolicyEngine) matchRule(repoName, imageName string, components []nexus.Component) *config.Rule {
+	rule := p.config.MatchRule(repoName, imageName)
+	if rule != nil && p.options.Rule != "" && rule.Name != p.options.Rule {
+		return nil
+	}
+	if rule == nil || rule.KeepByDownloads == nil {
+		return rule
+	}
+
+	scaled := *rule
+	scaled.Keep = rule.KeepByDownloads.Keep(downloadCount(components))
+	return &scaled
+}
+
+// downloadCount sums the downloads of all assets of the components.
+func downloadCount(components []nexus.Component) int64 {
+	var total int64
+	for _, comp := range components {
+		for _, asset := range comp.Assets {
+			total += asset.DownloadCount
+		}
+	}
+	return total
+}
+
+// isCleanable reports whether the repository is processed: Docker hosted
+// repositories, and Maven hosted repositories when a rule has Maven keep
+// counts.
+func (p *PolicyEngine) isCleanable(repo nexus.Repository) bool {
+	return repo.IsDockerHosted() || (repo.IsMavenHosted() && p.config.HasMavenRules())
+}
+
+// filterBlobStores drops components with any asset in an excluded blob store.
+func (p *PolicyEngine) filterBlobStores(components []nexus.Component) []nexus.Component {
+	var filtered []nexus.Component
+	skipped := 0
+	for _, comp := range components {
+		if p.excludedByBlobStore(comp) {
+			skipped++
+			continue
+		}
+		filtered = append(filtered, comp)
+	}
+
+	if skipped > 0 {
+		fmt.Printf("  Skipped %d components in excluded blob stores\n", skipped)
+	}
+	return filtered
+}
+
+// filterFloatingTags removes the floating tags ignored with
+// floating_tag_mode: ignore.
+func (p *PolicyEngine) filterFloatingTags(components []nexus.Component) []nexus.Component {
+	var filtered []nexus.Component
+	ignored := 0
+	for _, comp := range components {
+		if p.config.FloatingTag(comp.Version, config.FloatingTagIgnore) {
+			ignored++
+			continue
+		}
+		filtered = append(filtered, comp)
+	}
+
+	if ignored > 0 && p.options.Verbosity >= VerbosityImage {
+		fmt.Printf("  Ignored %d floating tags\n", ignored)
+	}
+	return filtered
+}
+
+// excludedByBlobStore reports whether any asset of the component is in an
+// excluded blob store.
+func (p *PolicyEngine) excludedByBlobStore(comp nexus.Component) bool {
+	for _, asset := range comp.Assets {
+		if p.config.IsBlobStoreExcluded(asset.BlobStore) {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *PolicyEngine) groupByImageName(components []nexus.Component) map[string][]nexus.Component {
 	groups := make(map[string][]nexus.Component)
 
 	for _, comp := range components {
-		imageName := comp.Name
+		imageName := p.config.CanonicalImage(groupName(comp))
 		groups[imageName] = append(groups[imageName], comp)
 	}
 
@@ -95,84 +737,384 @@ func (p *PolicyEngine) processImageGroup(repoName, imageName string, components
 		return 0, 0
 	}
 
-	keepCount, ruleName, matched := p.config.GetKeepCount(imageName)
+	rule := p.matchRule(repoName, imageName, components)
 
-	if !matched {
-		if p.verbose {
+	if rule == nil {
+		if p.options.Verbosity >= VerbosityTag {
 			fmt.Printf("  ⏭️  Image: %s (no matching rule, skipping)\n", imageName)
 		}
 		return 0, 0
 	}
 
-	fmt.Printf("  🏷️  Image: %s (rule: %s, keep: %d)\n", imageName, ruleName, keepCount)
+	keepCount, ruleName := rule.Keep, rule.Name
+	p.ruleMatches[rule.Name]++
 
-	// Sort by last modified date (most recent first)
-	sort.Slice(components, func(i, j int) bool {
-		return p.getLastModified(components[i]).After(p.getLastModified(components[j]))
-	})
+	if p.unchanged(components) {
+		p.unchangedImages++
+		if p.options.Verbosity >= VerbosityTag {
+			fmt.Printf("  ⏭️  Image: %s (unchanged since last run, skipping)\n", imageName)
+		}
+		return 0, 0
+	}
+	p.printImage(imageName, rule, components[0].Format)
 
-	// Separate protected and non-protected components
-	var protectedComps []nexus.Component
-	var regularComps []nexus.Component
+	p.sortComponents(rule, components)
+	decisions := p.planImage(repoName, rule, components)
 
-	for _, comp := range components {
-		if p.config.IsProtected(comp.Version) {
-			protectedComps = append(protectedComps, comp)
-		} else {
-			regularComps = append(regularComps, comp)
-		}
+	if p.config.WarnProtectedOverrides || p.overrides != nil {
+		p.warnProtectedOverrides(repoName, ruleName, components, keepCount)
 	}
 
-	// Keep the most recent N components (excluding protected ones)
-	toKeep := regularComps
-	toDelete := []nexus.Component{}
+	image := ImageResult{Name: imageName, Rule: ruleName, Decisions: decisions, Components: len(components)}
+	return p.applyDecisions(repoName, rule, image, 0)
+}
+
+// printImage prints the rule applied to an image.
+func (p *PolicyEngine) printImage(imageName string, rule *config.Rule, format string) {
+	if p.options.Verbosity < VerbosityImage {
+		return
+	}
 
-	if len(regularComps) > keepCount {
-		toKeep = regularComps[:keepCount]
-		toDelete = regularComps[keepCount:]
+	if rule.IsMaven() && format == "maven2" {
+		fmt.Printf("  🏷️  Image: %s (rule: %s, keep: %d snapshots, %d releases)\n", imageName, rule.Name, rule.KeepFor(true), rule.KeepFor(false))
+	} else {
+		fmt.Printf("  🏷️  Image: %s (rule: %s, keep: %d)\n", imageName, rule.Name, rule.Keep)
+	}
+	// Rules in dry-run mode only log their deletions
+	if rule.DryRun && !p.dryRun {
+		fmt.Printf("     🔍 Rule %s is in dry-run mode, deletions are only logged\n", rule.Name)
 	}
+}
+
+// applyDecisions carries out the decisions of an image: deletions beyond
+// max_deletions_per_repo are deferred and quarantined ones held, the rest
+// are deleted and logged, and the image result is recorded. fixedKept counts
+// kept components missing from the decisions.
+func (p *PolicyEngine) applyDecisions(repoName string, rule *config.Rule, image ImageResult, fixedKept int) (deleted, kept int) {
+	imageName, ruleName, decisions := image.Name, rule.Name, image.Decisions
+	dryRun := p.dryRun || rule.DryRun
+	kept = fixedKept
 
-	// Log kept components (in both modes)
-	for _, comp := range protectedComps {
-		fmt.Printf("     ✓ Keeping %s (protected)\n", comp.Version)
-		kept++
+	if p.quarantine != nil && !rule.DryRun {
+		p.quarantine.evaluate(decisions)
 	}
 
-	for _, comp := range toKeep {
-		fmt.Printf("     ✓ Keeping %s\n", comp.Version)
-		kept++
+	if p.deletionQuota != nil && !rule.DryRun {
+		for i, d := range decisions {
+			if d.Action == ActionDelete && !p.deletionQuota[d.Component.ID] {
+				decisions[i].Action, decisions[i].Reason = ActionKeep, "deferred, max deletions per repository"
+			}
+		}
 	}
 
-	// Delete old components
-	for _, comp := range toDelete {
-		if p.dryRun {
-			fmt.Printf("     🗑️  Would delete %s\n", comp.Version)
+	if p.quarantine != nil && !rule.DryRun {
+		p.applyQuarantine(decisions)
+	}
+
+	// Components are only deleted once their metadata is in the backup
+	// manifest
+	if !dryRun && p.backup != nil {
+		var pending []nexus.Component
+		for _, d := range decisions {
+			if d.Action == ActionDelete {
+				pending = append(pending, d.Component)
+			}
+		}
+		if err := p.backupDeletions(repoName, imageName, pending); err != nil {
+			category := p.errors.add(repoName+"/"+imageName, err)
+			fmt.Printf("     ⚠️  Not deleting from %s (%s): %v\n", imageName, category, err)
+			for i, d := range decisions {
+				if d.Action == ActionDelete {
+					decisions[i].Action, decisions[i].Reason = ActionKeep, "backup manifest not written"
+				}
+			}
+		}
+	}
+
+	if p.options.Verbosity >= VerbosityTag {
+		p.printDecisions(imageName, decisions)
+	}
+
+	defer func() {
+		image.Deleted, image.Kept = deleted, kept
+		repo := p.result.repository()
+		repo.Images = append(repo.Images, image)
+		repo.ReclaimedBytes += image.ReclaimedBytes
+	}()
+
+	empty := emptiesImage(decisions)
+
+	var toDelete []nexus.Component
+	for _, d := range decisions {
+		if d.Action == ActionDelete {
+			toDelete = append(toDelete, d.Component)
 		} else {
-			fmt.Printf("     🗑️  Deleting %s\n", comp.Version)
-			if err := p.client.DeleteComponent(comp.ID); err != nil {
-				fmt.Printf("     ⚠️  Failed to delete: %v\n", err)
-				continue
+			kept++
+		}
+	}
+	// Decisions are most recent first; deleting the oldest first leaves
+	// the newest deletable components if a run is interrupted
+	if p.config.DeleteOrder == config.DeleteOrderOldestFirst {
+		slices.Reverse(toDelete)
+	}
+
+	// Each deletion is logged as soon as it's done, so guardrails such as
+	// log_failure: abort stop the remaining deletions
+	handle := func(comp nexus.Component, err error) {
+		if !dryRun {
+			if errors.Is(err, errAborted) {
+				kept++
+				if !isUntagged(comp) {
+					empty = false
+				}
+				return
+			}
+			if p.options.Verbosity >= VerbosityTag {
+				fmt.Printf("     🗑️  Deleting %s\n", displayRef(imageName, comp))
 			}
+			if isAlreadyDeleted(err) {
+				p.alreadyDeleted++
+				if p.options.Verbosity >= VerbosityTag {
+					fmt.Printf("     ℹ️  %s was already deleted\n", displayRef(imageName, comp))
+				}
+			} else if err != nil {
+				category := p.errors.add(fmt.Sprintf("%s/%s:%s", repoName, groupName(comp), comp.Version), err)
+				if p.options.Verbosity >= VerbosityTag {
+					fmt.Printf("     ⚠️  Failed to delete %s (%s): %v\n", displayRef(imageName, comp), category, err)
+				} else {
+					fmt.Printf("  ⚠️  Failed to delete %s/%s:%s (%s)\n", repoName, groupName(comp), displayTag(comp), category)
+				}
+				if !isUntagged(comp) {
+					empty = false
+				}
+				image.Failed = append(image.Failed, comp.ID)
+				return
+			}
+			p.quarantine.release(comp.ID)
+		}
+
+		// Plans are applied later, so they must not include dry-run rules
+		if p.planned != nil && !rule.DryRun {
+			p.planned.add(repoName, groupName(comp), ruleName, comp)
 		}
 
 		// Log deletion
-		p.logger.LogDeletion(logger.DeletionRecord{
+		p.logDeletion(logger.DeletionRecord{
+			ExecutionID: p.executionID,
 			Timestamp:   time.Now(),
 			Repository:  repoName,
-			ImageName:   imageName,
+			ImageName:   groupName(comp),
 			Tag:         comp.Version,
 			ComponentID: comp.ID,
 			Rule:        ruleName,
-			DryRun:      p.dryRun,
+			DryRun:      dryRun,
 		})
 
+		if rule.DryRun && !p.dryRun {
+			p.ruleDryRuns++
+			return
+		}
 		deleted++
+		image.ReclaimedBytes += componentSize(comp)
+	}
+
+	// Delete old components
+	if dryRun {
+		for _, comp := range toDelete {
+			handle(comp, nil)
+		}
+	} else {
+		p.deleteAll(toDelete, func(i int, err error) { handle(toDelete[i], err) })
+	}
+
+	if empty && p.config.ReportEmptyImages && (p.dryRun || !rule.DryRun) {
+		p.emptyImages = append(p.emptyImages, repoName+"/"+imageName)
 	}
 
 	return deleted, kept
 }
 
+// logRun records the start or end of the current run, if the deletion
+// logger records runs.
+func (p *PolicyEngine) logRun(event string, deleted, kept int) {
+	runLogger, ok := p.logger.(RunLogger)
+	if !ok {
+		return
+	}
+
+	record := logger.RunRecord{
+		ExecutionID: p.executionID,
+		Timestamp:   time.Now(),
+		Event:       event,
+		DryRun:      p.dryRun,
+		Deleted:     deleted,
+		Kept:        kept,
+	}
+	if event == logger.RunFinished {
+		record.Failed = p.errors.total()
+	}
+	runLogger.LogRun(record)
+}
+
+// reportEmptyImages lists the images left without tags by the run.
+func (p *PolicyEngine) reportEmptyImages() {
+	if len(p.emptyImages) == 0 {
+		return
+	}
+
+	if p.dryRun {
+		fmt.Printf("   Images that would be left without tags: %d\n", len(p.emptyImages))
+	} else {
+		fmt.Printf("   Images left without tags: %d\n", len(p.emptyImages))
+	}
+	for _, image := range p.emptyImages {
+		fmt.Printf("     - %s\n", image)
+	}
+}
+
+// reportDeadRules lists configured rules that matched no image in the run,
+// which usually points to a typo in the regex or an obsolete rule.
+func (p *PolicyEngine) reportDeadRules() {
+	var dead []string
+	for _, rule := range p.config.Rules {
+		if p.options.Rule != "" && rule.Name != p.options.Rule {
+			continue
+		}
+		if p.ruleMatches[rule.Name] == 0 {
+			dead = append(dead, rule.Name)
+		}
+	}
+	if len(dead) == 0 {
+		return
+	}
+
+	p.result.DeadRules = dead
+	fmt.Printf("   ⚠️  Rules that matched no images: %s\n", strings.Join(dead, ", "))
+}
+
+// sortComponents orders components by the rule's time basis, most recent
+// first, with ties broken by tag. By download recency, ties such as never
+// downloaded components are first ordered by last modification.
+func (p *PolicyEngine) sortComponents(rule *config.Rule, components []nexus.Component) {
+	sort.Slice(components, func(i, j int) bool {
+		return p.newer(rule, components[i], components[j])
+	})
+}
+
+// newer reports whether a is ordered before b by sortComponents.
+func (p *PolicyEngine) newer(rule *config.Rule, a, b nexus.Component) bool {
+	ta, tb := p.componentTime(a, rule.TimeBasis), p.componentTime(b, rule.TimeBasis)
+	if !ta.Equal(tb) {
+		return ta.After(tb)
+	}
+	if rule.TimeBasis == config.TimeBasisLastDownloaded {
+		if ma, mb := p.getLastModified(a), p.getLastModified(b); !ma.Equal(mb) {
+			return ma.After(mb)
+		}
+	}
+	return a.Version > b.Version
+}
+
+// warnProtectedOverrides reports protected components that fall outside the
+// rule's keep window and would have been deleted without protection, and
+// records them in the override report. Components must already be sorted
+// most recent first.
+func (p *PolicyEngine) warnProtectedOverrides(repoName, ruleName string, components []nexus.Component, keepCount int) {
+	rank := 0
+	for _, comp := range components {
+		if p.config.DeleteUntagged && isUntagged(comp) {
+			continue
+		}
+		if reason := p.protectionReason(comp); rank >= keepCount && reason != "" {
+			if p.config.WarnProtectedOverrides {
+				fmt.Printf("     ⚠️  Protection overrides rule for %s (would be deleted)\n", comp.Version)
+			}
+			if err := p.overrides.add(p.executionID, repoName, p.reportedName(groupName(comp)), ruleName, reason, comp); err != nil {
+				fmt.Printf("     ⚠️  Failed to write protected override report: %v\n", err)
+			}
+			p.protectionOverrides++
+		}
+		rank++
+	}
+}
+
+// isProtected reports whether a component must never be deleted, either by
+// tag, as a golden version or because it is listed as in use or locked.
+func (p *PolicyEngine) isProtected(comp nexus.Component) bool {
+	return p.protectionReason(comp) != ""
+}
+
+// protectionReason describes why a component is protected, or returns an
+// empty string if it isn't.
+func (p *PolicyEngine) protectionReason(comp nexus.Component) string {
+	switch {
+	case p.config.IsProtected(comp.Version):
+		return "protected tag"
+	case p.config.FloatingTag(comp.Version, config.FloatingTagProtect):
+		return "floating tag"
+	case p.golden.contains(comp):
+		return "golden version"
+	case p.inUse.contains(comp):
+		return "in use"
+	case p.locked.contains(comp):
+		return "locked"
+	}
+	return ""
+}
+
+// groupName returns the name components are grouped by: the image name, or
+// "group:artifact" for Maven components.
+func groupName(comp nexus.Component) string {
+	if comp.Format == "maven2" && comp.Group != "" {
+		return comp.Group + ":" + comp.Name
+	}
+	return comp.Name
+}
+
+// isSnapshot reports whether a Maven version is a snapshot, either as
+// "1.0-SNAPSHOT" or as a timestamped "1.0-20240115.120000-1".
+func isSnapshot(version string) bool {
+	return strings.HasSuffix(version, "-SNAPSHOT") || timestampedSnapshot.MatchString(version)
+}
+
+var timestampedSnapshot = regexp.MustCompile(`-\d{8}\.\d{6}-\d+$`)
+
+// isUntagged reports whether a component is a dangling manifest without a tag.
+func isUntagged(comp nexus.Component) bool {
+	return comp.Version == "" || comp.Version == "<none>"
+}
+
+// componentTime returns the timestamp selected by a rule's time basis. For
+// blob timestamps the most recent asset value is used.
+func (p *PolicyEngine) componentTime(comp nexus.Component, basis string) time.Time {
+	var timeOf func(nexus.Asset) time.Time
+	switch basis {
+	case config.TimeBasisBlobCreated:
+		timeOf = func(a nexus.Asset) time.Time { return a.BlobCreated }
+	case config.TimeBasisBlobUpdated:
+		timeOf = func(a nexus.Asset) time.Time { return a.BlobUpdated }
+	case config.TimeBasisLastDownloaded:
+		timeOf = func(a nexus.Asset) time.Time { return a.LastDownloaded }
+	default:
+		return p.getLastModified(comp)
+	}
+
+	var latest time.Time
+	for _, asset := range comp.Assets {
+		if t := timeOf(asset); t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
+
+// getLastModified prefers the component-level timestamp and falls back to
+// the most recent asset timestamp.
 func (p *PolicyEngine) getLastModified(comp nexus.Component) time.Time {
+	if !comp.LastModified.IsZero() {
+		return comp.LastModified
+	}
+
 	if len(comp.Assets) == 0 {
 		return time.Time{}
 	}
@@ -186,3 +1128,27 @@ func (p *PolicyEngine) getLastModified(comp nexus.Component) time.Time {
 
 	return latest
 }
+
+// newExecutionID returns a random RFC 4122 version 4 UUID.
+func newExecutionID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("run-%d", time.Now().UnixNano())
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+var (
+	_ Registry = (*nexus.Client)(nil)
+	_ Registry = (*nexus.FakeClient)(nil)
+	_ Registry = (*nexus.MockClient)(nil)
+
+	_ AssetDeleter = (*nexus.Client)(nil)
+	_ AssetDeleter = (*nexus.FakeClient)(nil)
+	_ AssetDeleter = (*nexus.MockClient)(nil)
+
+	_ DeletionLogger = (*logger.Logger)(nil)
+	_ DeletionLogger = (*logger.MemoryLogger)(nil)
+)
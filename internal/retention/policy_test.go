@@ -0,0 +1,464 @@
+package retention
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// repositoryNames returns the names of a run's processed repositories in
+// processing order.
+func repositoryNames(result *RunResult) []string {
+	var names []string
+	for _, repo := range result.Repositories {
+		names = append(names, repo.Name)
+	}
+	return names
+}
+
+func TestExecuteMaxRepos(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-c"), numbered("c", 2)...)
+	fake.AddRepository(dockerRepo("docker-a"), numbered("a", 2)...)
+	fake.AddRepository(dockerRepo("docker-b"), numbered("b", 2)...)
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`)
+	engine, _ := newTestEngine(fake, cfg, Options{MaxRepos: 2})
+	result := execute(t, engine)
+
+	if got, want := repositoryNames(result), []string{"docker-a", "docker-b"}; !equalStrings(got, want) {
+		t.Errorf("processed %v, want %v", got, want)
+	}
+	if got, want := deletedTags(fake), []string{"a:v1", "b:v1"}; !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+}
+
+func TestExecuteSortsRepositories(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	for _, name := range []string{"docker-b", "docker-c", "docker-a"} {
+		fake.AddRepository(dockerRepo(name), numbered(name, 1)...)
+	}
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	result := execute(t, engine)
+
+	if got, want := repositoryNames(result), []string{"docker-a", "docker-b", "docker-c"}; !equalStrings(got, want) {
+		t.Errorf("processed %v, want %v", got, want)
+	}
+}
+
+func TestGetLastModified(t *testing.T) {
+	older, newer := testTime.Add(-time.Hour), testTime
+	tests := []struct {
+		name string
+		comp nexus.Component
+		want time.Time
+	}{
+		{
+			name: "component timestamp wins",
+			comp: nexus.Component{LastModified: older, Assets: []nexus.Asset{{LastModified: newer}}},
+			want: older,
+		},
+		{
+			name: "newest asset",
+			comp: nexus.Component{Assets: []nexus.Asset{{LastModified: older}, {LastModified: newer}, {}}},
+			want: newer,
+		},
+		{
+			name: "no assets",
+			comp: nexus.Component{},
+		},
+	}
+
+	engine, _ := newTestEngine(nexus.NewFakeClient(), parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`), Options{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := engine.getLastModified(tt.comp); !got.Equal(tt.want) {
+				t.Errorf("getLastModified = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteSkipsOfflineRepositories(t *testing.T) {
+	offline := false
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), numbered("app", 2)...)
+	fake.AddRepository(nexus.Repository{Name: "docker-offline", Format: "docker", Type: "hosted", Online: &offline}, numbered("old", 2)...)
+
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 1}]
+skip_offline: true
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	result := execute(t, engine)
+
+	if got, want := repositoryNames(result), []string{"docker-hosted"}; !equalStrings(got, want) {
+		t.Errorf("processed %v, want %v", got, want)
+	}
+}
+
+func TestExecuteExcludesBlobStores(t *testing.T) {
+	components := numbered("app", 4)
+	// v2 has an asset in the excluded blob store, so it's left alone
+	for i := range components {
+		components[i].Assets[0].BlobStore = "default"
+	}
+	components[2].Assets = append(components[2].Assets, nexus.Asset{ID: "app:v2#layer", BlobStore: "archive"})
+
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), components...)
+
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 1}]
+exclude_blob_stores: ["archive"]
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	execute(t, engine)
+
+	if got, want := deletedTags(fake), []string{"app:v1", "app:v3"}; !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+}
+
+func TestExecuteReportsDeadRules(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), append(numbered("prod-api", 2), numbered("app", 2)...)...)
+
+	cfg := parseConfig(t, `
+rules:
+  - {name: prod, regex: "^prod-", keep: 1}
+  - {name: typo, regex: "^pord-", keep: 1}
+  - {name: all, regex: ".*", keep: 1}
+`)
+	tests := []struct {
+		name string
+		opts Options
+		dead []string
+	}{
+		{name: "all rules", dead: []string{"typo"}},
+		// Rules left out by -rule aren't reported
+		{name: "single rule", opts: Options{DryRun: true, Rule: "prod"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, _ := newTestEngine(fake, cfg, tt.opts)
+			var result *RunResult
+			out := captureStdout(t, func() { result = execute(t, engine) })
+
+			if !equalStrings(result.DeadRules, tt.dead) {
+				t.Errorf("DeadRules = %v, want %v", result.DeadRules, tt.dead)
+			}
+			if got := strings.Contains(out, "Rules that matched no images: typo"); got != (len(tt.dead) > 0) {
+				t.Errorf("dead rules reported = %t, want %t:\n%s", got, len(tt.dead) > 0, out)
+			}
+		})
+	}
+}
+
+func TestExecuteVerbosity(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), append(numbered("app", 2), numbered("other", 1)...)...)
+	cfg := parseConfig(t, `rules: [{name: apps, regex: "^app", keep: 1}]`)
+
+	tests := []struct {
+		verbosity Verbosity
+		image     bool
+		tags      bool
+	}{
+		{VerbositySummary, false, false},
+		{VerbosityImage, true, false},
+		{VerbosityTag, true, true},
+	}
+
+	for _, tt := range tests {
+		engine, _ := newTestEngine(fake, cfg, Options{DryRun: true, Verbosity: tt.verbosity})
+		out := captureStdout(t, func() { execute(t, engine) })
+
+		if got := strings.Contains(out, "Image: app (rule: apps, keep: 1)"); got != tt.image {
+			t.Errorf("verbosity %d: image printed = %t, want %t", tt.verbosity, got, tt.image)
+		}
+		if got := strings.Contains(out, "DELETE     v1") && strings.Contains(out, "Image: other (no matching rule"); got != tt.tags {
+			t.Errorf("verbosity %d: tags and unmatched images printed = %t, want %t", tt.verbosity, got, tt.tags)
+		}
+		if !strings.Contains(out, "Processing repository: docker-hosted") {
+			t.Errorf("verbosity %d: repository not printed", tt.verbosity)
+		}
+	}
+}
+
+func TestExecuteImageAliases(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"),
+		component("service", "v4", testTime),
+		component("svc", "v3", testTime.Add(-time.Hour)),
+		component("my-service", "v2", testTime.Add(-2*time.Hour)),
+		component("svc", "v1", testTime.Add(-3*time.Hour)),
+		component("other", "v1", testTime.Add(-3*time.Hour)),
+	)
+
+	cfg := parseConfig(t, `
+rules: [{name: service, regex: "^service$", keep: 2}]
+image_aliases:
+  svc: service
+  my-service: service
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	execute(t, engine)
+
+	// The aliased names are retained as one image matched by the rule
+	if got, want := deletedTags(fake), []string{"my-service:v2", "svc:v1"}; !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+}
+
+func TestExecuteMaxDeletionsPerRepo(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), append(numbered("app", 4), numbered("api", 3)...)...)
+
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 1}]
+max_deletions_per_repo: 2
+`)
+
+	// Each run deletes the oldest two, later runs continue with the rest
+	for i, want := range [][]string{
+		{"api:v1", "app:v1"},
+		{"api:v1", "api:v2", "app:v1", "app:v2"},
+		{"api:v1", "api:v2", "app:v1", "app:v2", "app:v3"},
+	} {
+		engine, _ := newTestEngine(fake, cfg, Options{})
+		execute(t, engine)
+		if got := deletedTags(fake); !equalStrings(got, want) {
+			t.Errorf("run %d: deleted %v, want %v", i+1, got, want)
+		}
+	}
+}
+
+func TestExecuteKeepByDownloads(t *testing.T) {
+	// downloaded gives every component of an image the downloads
+	downloaded := func(components []nexus.Component, downloads int64) []nexus.Component {
+		for i := range components {
+			components[i].Assets[0].DownloadCount = downloads
+		}
+		return components
+	}
+
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), append(
+		downloaded(numbered("popular", 6), 1000),
+		downloaded(numbered("rare", 6), 0)...)...)
+
+	cfg := parseConfig(t, `
+rules:
+  - name: all
+    regex: ".*"
+    keep_by_downloads: {min_keep: 1, max_keep: 4, max_downloads: 5000}
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	execute(t, engine)
+
+	// popular has 6000 downloads and keeps 4, rare keeps 1
+	want := []string{"popular:v1", "popular:v2", "rare:v1", "rare:v2", "rare:v3", "rare:v4", "rare:v5"}
+	if got := deletedTags(fake); !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+}
+
+func TestExecuteRuleDryRun(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), append(numbered("app", 3), numbered("new", 3)...)...)
+
+	cfg := parseConfig(t, `
+rules:
+  - {name: aggressive, regex: "^new$", keep: 1, dry_run: true}
+  - {name: all, regex: ".*", keep: 1}
+`)
+	engine, log := newTestEngine(fake, cfg, Options{})
+	out := captureStdout(t, func() { execute(t, engine) })
+
+	if got, want := deletedTags(fake), []string{"app:v1", "app:v2"}; !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+
+	// The dry-run rule's deletions are logged as dry-run rows only
+	dryRun := map[string]bool{}
+	for _, record := range log.Records() {
+		dryRun[record.ComponentID] = record.DryRun
+	}
+	want := map[string]bool{"app:v1": false, "app:v2": false, "new:v1": true, "new:v2": true}
+	if !reflect.DeepEqual(dryRun, want) {
+		t.Errorf("logged %v, want %v", dryRun, want)
+	}
+	if !strings.Contains(out, "Dry-run rules: 2 components would be deleted") {
+		t.Errorf("dry-run rule deletions not reported:\n%s", out)
+	}
+}
+
+func TestExecuteSingleRule(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), append(numbered("app", 3), numbered("api", 3)...)...)
+
+	cfg := parseConfig(t, `
+rules:
+  - {name: app, regex: "^app$", keep: 1}
+  - {name: rest, regex: ".*", keep: 1}
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{Rule: "rest"})
+	result := execute(t, engine)
+
+	if got, want := deletedTags(fake), []string{"api:v1", "api:v2"}; !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+	// Images of other rules aren't decided at all
+	if _, ok := decisionsOf(result)["app:v1"]; ok {
+		t.Errorf("app decided by a skipped rule")
+	}
+}
+
+func TestExecuteEmptyImages(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		deleted  []string
+	}{
+		{
+			name:     "report",
+			settings: "report_empty_images: true",
+			deleted:  []string{"app:v1", "old:v1", "old:v2"},
+		},
+		{
+			name:     "delete",
+			settings: "report_empty_images: true\ndelete_empty_images: true",
+			deleted:  []string{"app:v1", "old:", "old:v1", "old:v2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The untagged manifest of old fills its keep slot, leaving no tags
+			fake := nexus.NewFakeClient()
+			fake.AddRepository(dockerRepo("docker-hosted"), append([]nexus.Component{
+				component("old", "", testTime),
+				component("old", "v2", testTime.Add(-time.Hour)),
+				component("old", "v1", testTime.Add(-2*time.Hour)),
+			}, numbered("app", 2)...)...)
+
+			cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`+"\n"+tt.settings)
+			engine, _ := newTestEngine(fake, cfg, Options{})
+			var result *RunResult
+			out := captureStdout(t, func() { result = execute(t, engine) })
+
+			if got := deletedTags(fake); !equalStrings(got, tt.deleted) {
+				t.Errorf("deleted %v, want %v", got, tt.deleted)
+			}
+			if !equalStrings(result.EmptyImages, []string{"docker-hosted/old"}) {
+				t.Errorf("empty images %v, want docker-hosted/old", result.EmptyImages)
+			}
+			if !strings.Contains(out, "Images left without tags: 1") {
+				t.Errorf("empty images not reported:\n%s", out)
+			}
+		})
+	}
+}
+
+// mavenVersions returns Maven components of an artifact, the first version
+// pushed most recently and each following one an hour earlier.
+func mavenVersions(group, artifact string, versions ...string) []nexus.Component {
+	components := make([]nexus.Component, len(versions))
+	for i, version := range versions {
+		comp := component(artifact, version, testTime.Add(-time.Duration(i)*time.Hour))
+		comp.ID, comp.Group, comp.Format = group+":"+artifact+":"+version, group, "maven2"
+		components[i] = comp
+	}
+	return components
+}
+
+func TestExecuteGroupRegex(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(nexus.Repository{Name: "maven-releases", Format: "maven2", Type: "hosted"}, append(
+		mavenVersions("com.example", "lib", "1.2", "1.1", "1.0"),
+		mavenVersions("org.other", "lib", "1.2", "1.1", "1.0")...)...)
+
+	cfg := parseConfig(t, `rules: [{name: ours, regex: ".*", group_regex: "^com\\.example$", keep: 1, keep_releases: 1}]`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	result := execute(t, engine)
+
+	// Artifacts of the same name in different groups are separate images
+	if got, want := deletedTags(fake), []string{"com.example:lib:1.0", "com.example:lib:1.1"}; !equalStrings(got, want) {
+		t.Errorf("deleted %v, want %v", got, want)
+	}
+	if images := result.Repositories[0].Images; len(images) != 1 || images[0].Name != "com.example:lib" {
+		t.Errorf("images %+v, want com.example:lib", images)
+	}
+}
+
+func TestExecuteDeleteOrder(t *testing.T) {
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{"", []string{"app:v3", "app:v2", "app:v1"}},
+		{"newest-first", []string{"app:v3", "app:v2", "app:v1"}},
+		{"oldest-first", []string{"app:v1", "app:v2", "app:v3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			fake := nexus.NewFakeClient()
+			fake.AddRepository(dockerRepo("docker-hosted"), numbered("app", 4)...)
+
+			settings := "delete_concurrency: 1"
+			if tt.order != "" {
+				settings += "\ndelete_order: " + tt.order
+			}
+			cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`+"\n"+settings)
+			engine, log := newTestEngine(fake, cfg, Options{})
+			execute(t, engine)
+
+			if !equalStrings(fake.Deleted, tt.want) {
+				t.Errorf("deleted %v, want %v", fake.Deleted, tt.want)
+			}
+			var logged []string
+			for _, record := range log.Records() {
+				logged = append(logged, record.ImageName+":"+record.Tag)
+			}
+			if !equalStrings(logged, tt.want) {
+				t.Errorf("logged %v, want %v", logged, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteOutsideAllowedHours(t *testing.T) {
+	// A window starting two hours from now never contains the run
+	now := time.Now().UTC()
+	window := now.Add(2*time.Hour).Format("15:04") + "-" + now.Add(3*time.Hour).Format("15:04")
+
+	for _, force := range []bool{false, true} {
+		fake := nexus.NewFakeClient()
+		fake.AddRepository(dockerRepo("docker-hosted"), numbered("app", 3)...)
+
+		cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 1}]
+timezone: UTC
+allowed_hours: "`+window+`"
+`)
+		engine, _ := newTestEngine(fake, cfg, Options{Force: force})
+		var result *RunResult
+		out := captureStdout(t, func() { result = execute(t, engine) })
+
+		switch {
+		case !force && (len(fake.Deleted) != 0 || !result.DryRun || !strings.Contains(out, "Outside allowed hours")):
+			t.Errorf("deleted %v outside allowed hours, dry run %t:\n%s", fake.Deleted, result.DryRun, out)
+		case force && len(fake.Deleted) != 2:
+			t.Errorf("deleted %v with -force, want app:v1 and app:v2", fake.Deleted)
+		}
+	}
+}
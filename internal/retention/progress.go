@@ -0,0 +1,87 @@
+package retention
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Progress is reported after this many deletions or this much time,
+// whichever comes first.
+const (
+	progressEvery    = 100
+	progressInterval = 5 * time.Second
+)
+
+// progress tracks deletion throughput and estimates the remaining time for
+// the deletions planned so far.
+type progress struct {
+	mu         sync.Mutex
+	start      time.Time
+	lastReport time.Time
+	planned    int
+	done       int
+	sinceLast  int
+}
+
+func newProgress(now time.Time) *progress {
+	return &progress{start: now, lastReport: now}
+}
+
+// addPlanned adds deletions to the known total.
+func (p *progress) addPlanned(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.planned += n
+}
+
+// completed records a finished deletion and reports whether a progress line
+// is due.
+func (p *progress) completed(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done++
+	p.sinceLast++
+	if p.sinceLast < progressEvery && now.Sub(p.lastReport) < progressInterval {
+		return false
+	}
+	p.sinceLast = 0
+	p.lastReport = now
+	return true
+}
+
+// rate returns deletions per second since the start.
+func (p *progress) rate(now time.Time) float64 {
+	elapsed := now.Sub(p.start).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(p.done) / elapsed
+}
+
+// eta estimates the time to finish the remaining planned deletions.
+func (p *progress) eta(now time.Time) time.Duration {
+	rate := p.rate(now)
+	remaining := p.planned - p.done
+	if rate <= 0 || remaining <= 0 {
+		return 0
+	}
+	return time.Duration(float64(remaining) / rate * float64(time.Second)).Round(time.Second)
+}
+
+func (p *progress) report(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fmt.Printf("     ⏱️  Progress: %d/%d deleted (%.1f/s, ETA %s)\n", p.done, p.planned, p.rate(now), p.eta(now))
+}
+
+// summary returns the overall deletion rate line for the run summary.
+func (p *progress) summary(now time.Time) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return fmt.Sprintf("%.1f deletions/s over %s", p.rate(now), now.Sub(p.start).Round(time.Second))
+}
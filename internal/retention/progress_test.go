@@ -0,0 +1,48 @@
+package retention
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	start := testTime
+	p := newProgress(start)
+	p.addPlanned(300)
+
+	// Reported every progressEvery deletions
+	now := start.Add(time.Second)
+	for i := 1; i < progressEvery; i++ {
+		if p.completed(now) {
+			t.Fatalf("progress reported after %d deletions", i)
+		}
+	}
+	if !p.completed(now) {
+		t.Fatalf("progress not reported after %d deletions", progressEvery)
+	}
+
+	// 100 deletions in 10s leave 200 for another 20s
+	now = start.Add(10 * time.Second)
+	if got := p.rate(now); got != 10 {
+		t.Errorf("rate = %v, want 10", got)
+	}
+	if got := p.eta(now); got != 20*time.Second {
+		t.Errorf("eta = %s, want 20s", got)
+	}
+	if got, want := p.summary(now), "10.0 deletions/s over 10s"; got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+
+	// Or after progressInterval
+	if !p.completed(now.Add(progressInterval)) {
+		t.Error("progress not reported after the interval")
+	}
+}
+
+func TestProgressWithoutDeletions(t *testing.T) {
+	p := newProgress(testTime)
+	p.addPlanned(10)
+	if rate, eta := p.rate(testTime), p.eta(testTime); rate != 0 || eta != 0 {
+		t.Errorf("rate = %v, eta = %s at the start, want 0", rate, eta)
+	}
+}
@@ -0,0 +1,120 @@
+package retention
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// quarantine marks components selected for deletion and holds them for a
+// grace window before they are deleted. Marks are stored in a JSON file
+// mapping component IDs to the time they were first selected.
+type quarantine struct {
+	path   string
+	window time.Duration
+	marked map[string]time.Time
+	// evaluated holds the components decided in this run, and whether the
+	// policy still selects them for deletion. Marks of components that
+	// weren't evaluated, e.g. in repositories a partial run skipped, are kept.
+	evaluated map[string]bool
+}
+
+func loadQuarantine(path string, window time.Duration) (*quarantine, error) {
+	q := &quarantine{
+		path:      path,
+		window:    window,
+		marked:    make(map[string]time.Time),
+		evaluated: make(map[string]bool),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quarantine file: %w", err)
+	}
+	if err := json.Unmarshal(data, &q.marked); err != nil {
+		return nil, fmt.Errorf("failed to parse quarantine file: %w", err)
+	}
+	return q, nil
+}
+
+// hold reports whether a component selected for deletion must be kept until
+// its grace window ends. Unmarked components are marked at now, if mark is
+// set; newly reports whether that happened.
+func (q *quarantine) hold(id string, now time.Time, mark bool) (until time.Time, held, newly bool) {
+	markedAt, ok := q.marked[id]
+	if !ok {
+		if !mark {
+			return now.Add(q.window), true, false
+		}
+		markedAt = now
+		q.marked[id] = now
+		newly = true
+	}
+
+	until = markedAt.Add(q.window)
+	return until, now.Before(until), newly
+}
+
+// release removes the mark of a deleted component.
+func (q *quarantine) release(id string) {
+	if q == nil {
+		return
+	}
+	delete(q.marked, id)
+}
+
+// evaluate records the components of an image's decisions before deletions
+// are deferred or held, so marks of components the policy still selects
+// survive deferrals.
+func (q *quarantine) evaluate(decisions []Decision) {
+	for _, d := range decisions {
+		q.evaluated[d.Component.ID] = d.Action == ActionDelete
+	}
+}
+
+// save writes the marks, dropping those of components that were evaluated
+// in this run and are no longer selected, so they leave quarantine.
+func (q *quarantine) save() error {
+	marks := make(map[string]time.Time, len(q.marked))
+	for id, markedAt := range q.marked {
+		if selected, ok := q.evaluated[id]; ok && !selected {
+			continue
+		}
+		marks[id] = markedAt
+	}
+
+	data, err := json.MarshalIndent(marks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode quarantine: %w", err)
+	}
+	if err := os.WriteFile(q.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write quarantine file: %w", err)
+	}
+	return nil
+}
+
+// applyQuarantine keeps components selected for deletion until their grace
+// window ends. Dry runs report the holds without marking components.
+func (p *PolicyEngine) applyQuarantine(decisions []Decision) {
+	now := time.Now()
+	for i, d := range decisions {
+		if d.Action != ActionDelete {
+			continue
+		}
+
+		until, held, newly := p.quarantine.hold(d.Component.ID, now, !p.dryRun)
+		if !held {
+			continue
+		}
+		if newly {
+			p.quarantined++
+		}
+		decisions[i].Action = ActionKeep
+		decisions[i].Reason = fmt.Sprintf("quarantined until %s", until.Format("2006-01-02 15:04"))
+	}
+}
@@ -0,0 +1,110 @@
+package retention
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nexus-retention-policy/internal/logger"
+	"nexus-retention-policy/internal/nexus"
+)
+
+// failingLog fails every write to the deletion log.
+type failingLog struct{}
+
+func (failingLog) LogDeletion(logger.DeletionRecord) error {
+	return errors.New("disk full")
+}
+
+// quarantineConfig returns a quarantine test configuration keeping keep
+// tags of each image.
+func quarantineConfig(path string, keep int) string {
+	return fmt.Sprintf("quarantine_days: 1\nquarantine_file: %s\nlog_failure: abort\nrules: [{name: all, regex: \".*\", keep: %d}]\n", path, keep)
+}
+
+func readMarks(t *testing.T, path string) map[string]time.Time {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read quarantine file: %v", err)
+	}
+	marks := make(map[string]time.Time)
+	if err := json.Unmarshal(data, &marks); err != nil {
+		t.Fatalf("parse quarantine file: %v", err)
+	}
+	return marks
+}
+
+func TestQuarantineKeepsMarksOfPartialRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quarantine.json")
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("a"), numbered("api", 2)...)
+	fake.AddRepository(dockerRepo("b"), numbered("web", 2)...)
+
+	engine, _ := newTestEngine(fake, parseConfig(t, quarantineConfig(path, 1)), Options{})
+	execute(t, engine)
+	marks := readMarks(t, path)
+	if len(marks) != 2 {
+		t.Fatalf("marks after full run %v, want api:v1 and web:v1", marks)
+	}
+
+	// Only repository a is evaluated, where api:v1 is no longer selected
+	engine, _ = newTestEngine(fake, parseConfig(t, quarantineConfig(path, 2)), Options{MaxRepos: 1})
+	execute(t, engine)
+	marks = readMarks(t, path)
+	if _, ok := marks["api:v1"]; ok {
+		t.Errorf("api:v1 still marked after it was no longer selected")
+	}
+	if _, ok := marks["web:v1"]; !ok {
+		t.Errorf("mark of web:v1 lost in a run that skipped its repository")
+	}
+	if len(fake.Deleted) != 0 {
+		t.Errorf("deleted %v before the grace window ended", fake.Deleted)
+	}
+}
+
+func TestQuarantineKeepsMarksOfDeferredDeletions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quarantine.json")
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("a"), numbered("api", 3)...)
+
+	engine, _ := newTestEngine(fake, parseConfig(t, quarantineConfig(path, 1)), Options{})
+	execute(t, engine)
+	before := readMarks(t, path)
+
+	cfg := parseConfig(t, quarantineConfig(path, 1)+"max_deletions_per_repo: 1\n")
+	engine, _ = newTestEngine(fake, cfg, Options{})
+	execute(t, engine)
+	after := readMarks(t, path)
+
+	for id, markedAt := range before {
+		if !after[id].Equal(markedAt) {
+			t.Errorf("mark of %s changed from %v to %v", id, markedAt, after[id])
+		}
+	}
+}
+
+func TestQuarantineNotSavedByAbortedRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quarantine.json")
+	expired := map[string]time.Time{"api:v1": time.Now().Add(-48 * time.Hour).Truncate(time.Second)}
+	data, _ := json.Marshal(expired)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("a"), numbered("api", 2)...)
+	engine := NewPolicyEngine(fake, parseConfig(t, quarantineConfig(path, 1)), failingLog{}, Options{})
+	var guardrail *GuardrailError
+	if _, err := engine.Execute(); !errors.As(err, &guardrail) {
+		t.Fatalf("Execute returned %v, want a guardrail error", err)
+	}
+
+	if marks := readMarks(t, path); !marks["api:v1"].Equal(expired["api:v1"]) {
+		t.Errorf("aborted run rewrote the quarantine file: %v", marks)
+	}
+}
@@ -0,0 +1,63 @@
+package retention
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// repositoryReport is the per-repository summary written to
+// per_repo_reports_dir.
+type repositoryReport struct {
+	Repository     string    `json:"repository"`
+	ExecutionID    string    `json:"execution_id"`
+	Timestamp      time.Time `json:"timestamp"`
+	DryRun         bool      `json:"dry_run"`
+	Components     int       `json:"components"`
+	Images         int       `json:"images"`
+	Deleted        int       `json:"deleted"`
+	Kept           int       `json:"kept"`
+	ReclaimedBytes int64     `json:"reclaimed_bytes"`
+	Failed         int       `json:"failed"`
+	Error          string    `json:"error,omitempty"`
+	// Rules holds the numbers of every rule applied in the repository
+	Rules []RuleStats `json:"rules,omitempty"`
+}
+
+// writeRepositoryReport writes the summary of a processed repository to
+// <dir>/<repository>.json, replacing the report of the previous run.
+func (p *PolicyEngine) writeRepositoryReport(dir string, repo *RepositoryResult) error {
+	report := repositoryReport{
+		Repository:     repo.Name,
+		ExecutionID:    p.executionID,
+		Timestamp:      time.Now(),
+		DryRun:         p.dryRun,
+		Components:     repo.Stats.Components,
+		Images:         repo.Stats.Images,
+		Deleted:        repo.Deleted,
+		Kept:           repo.Kept,
+		ReclaimedBytes: repo.ReclaimedBytes,
+		Error:          repo.Error,
+		Rules:          repo.Rules,
+	}
+	for _, image := range repo.Images {
+		report.Failed += len(image.Failed)
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode repository report: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create repository report directory: %w", err)
+	}
+
+	name := strings.ReplaceAll(repo.Name, string(filepath.Separator), "_") + ".json"
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+		return fmt.Errorf("failed to write repository report: %w", err)
+	}
+	return nil
+}
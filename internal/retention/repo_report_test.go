@@ -0,0 +1,95 @@
+package retention
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+func TestRepositoryReportGolden(t *testing.T) {
+	dir := t.TempDir()
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), append(numbered("prod-api", 5), tags("app", "v2", "v1", "latest")...)...)
+
+	cfg := parseConfig(t, `
+rules: [{name: prod, regex: "^prod-", keep: 3}, {name: all, regex: ".*", keep: 1}]
+protected_tags: ["latest"]
+per_repo_reports_dir: `+dir+`
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	captureStdout(t, func() { execute(t, engine) })
+
+	data, err := os.ReadFile(filepath.Join(dir, "docker-hosted.json"))
+	if err != nil {
+		t.Fatalf("read report: %v", err)
+	}
+	// The execution ID and timestamp change with every run
+	var report repositoryReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("parse report: %v", err)
+	}
+	report.ExecutionID, report.Timestamp = "<execution>", time.Time{}
+	normalized, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		t.Fatalf("encode report: %v", err)
+	}
+	assertGolden(t, "repository_report", normalized)
+}
+
+func TestRepositoryReports(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "reports")
+	mock := &nexus.MockClient{
+		GetRepositoriesFunc: func() ([]nexus.Repository, error) {
+			return []nexus.Repository{dockerRepo("broken"), dockerRepo("docker-hosted"), {Name: "docker-proxy", Format: "docker", Type: "proxy"}}, nil
+		},
+		GetComponentsFunc: func(repository string) ([]nexus.Component, error) {
+			if repository == "broken" {
+				return nil, &nexus.APIError{StatusCode: 500, Body: "boom"}
+			}
+			return numbered("app", 3), nil
+		},
+	}
+
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 1}]
+per_repo_reports_dir: `+dir+`
+`)
+	engine, _ := newTestEngine(mock, cfg, Options{})
+	captureStdout(t, func() { engine.Execute() })
+
+	// One report per processed repository, the proxy isn't processed
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read report directory: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if want := []string{"broken.json", "docker-hosted.json"}; !equalStrings(names, want) {
+		t.Fatalf("reports %v, want %v", names, want)
+	}
+
+	reports := make(map[string]repositoryReport)
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var report repositoryReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			t.Fatalf("parse %s: %v", name, err)
+		}
+		reports[report.Repository] = report
+	}
+	if r := reports["docker-hosted"]; r.Deleted != 2 || r.Kept != 1 || r.ReclaimedBytes != 2048 || r.Error != "" {
+		t.Errorf("docker-hosted report %+v", r)
+	}
+	if r := reports["broken"]; r.Error == "" || r.Deleted != 0 {
+		t.Errorf("broken report %+v, want the listing error", r)
+	}
+}
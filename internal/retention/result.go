@@ -0,0 +1,112 @@
+package retention
+
+import "time"
+
+// RunResult is the outcome of an Execute run, for programs embedding the
+// engine. It holds the same information as the printed output.
+type RunResult struct {
+	ExecutionID string
+	DryRun      bool
+	StartedAt   time.Time
+	FinishedAt  time.Time
+	// Repositories lists the processed repositories in processing order
+	Repositories []RepositoryResult
+	// Deleted and Kept count components over all repositories; deletions
+	// of dry runs and dry-run rules are counted as deleted
+	Deleted int
+	Kept    int
+	// AlreadyDeleted counts deletions that found the component already gone
+	AlreadyDeleted int
+	// AssetsDeleted counts assets deleted by asset rules
+	AssetsDeleted int
+	// RuleDryRuns counts deletions skipped by dry-run rules
+	RuleDryRuns int
+	// Quarantined counts components that entered quarantine
+	Quarantined int
+	// ProtectionOverrides counts protected components a rule would have
+	// deleted, if warn_protected_overrides or an override report is enabled
+	ProtectionOverrides int
+	// Rules holds the numbers of every applied rule, in configuration order
+	Rules []RuleStats
+	// UnchangedImages counts images skipped by --since-last-run
+	UnchangedImages int
+	// EmptyImages lists "<repository>/<image>" left without tags, if
+	// report_empty_images is enabled
+	EmptyImages []string
+	// DeadRules lists rules that matched no image
+	DeadRules []string
+	// Errors counts failed requests by category, e.g. "auth" or "timeout"
+	Errors map[string]int
+	// Aborted is set when the circuit breaker or a failed deletion log write
+	// with log_failure: abort stopped the run early
+	Aborted bool
+}
+
+// RepositoryResult is the outcome for a single repository.
+type RepositoryResult struct {
+	Name  string
+	Stats RepositoryStats
+	// Error is set when the repository couldn't be listed or was skipped
+	Error   string
+	Deleted int
+	Kept    int
+	// ReclaimedBytes sums the asset sizes of deleted components
+	ReclaimedBytes int64
+	// Images lists the images matched by a rule, sorted by name
+	Images []ImageResult
+	// Rules holds the numbers of every rule applied in the repository
+	Rules []RuleStats
+}
+
+// ImageResult holds the decisions for one image. Decisions deferred by
+// max_deletions_per_repo or held in quarantine are keeps. With
+// stream_components, Decisions only lists the deletions and the newest keep
+// components.
+type ImageResult struct {
+	Name      string
+	Rule      string
+	Decisions []Decision
+	// Components counts the components of the image that were evaluated
+	Components int
+	Deleted    int
+	Kept       int
+	// ReclaimedBytes sums the asset sizes of deleted components
+	ReclaimedBytes int64
+	// Failed lists the IDs of components whose deletion failed
+	Failed []string
+}
+
+// repository returns the result of the repository being processed.
+func (r *RunResult) repository() *RepositoryResult {
+	return &r.Repositories[len(r.Repositories)-1]
+}
+
+// images returns the image results of all repositories.
+func (r *RunResult) images() []ImageResult {
+	var images []ImageResult
+	for _, repo := range r.Repositories {
+		images = append(images, repo.Images...)
+	}
+	return images
+}
+
+// finishResult records the totals and counters of the run in the result.
+func (p *PolicyEngine) finishResult(deleted, kept int) {
+	r := p.result
+	r.FinishedAt = time.Now()
+	r.Deleted, r.Kept = deleted, kept
+	r.AlreadyDeleted = p.alreadyDeleted
+	r.AssetsDeleted = p.assetsDeleted
+	r.RuleDryRuns = p.ruleDryRuns
+	r.Quarantined = p.quarantined
+	r.ProtectionOverrides = p.protectionOverrides
+	r.UnchangedImages = p.unchangedImages
+	r.Rules = p.ruleStats(r.images())
+	r.EmptyImages = p.emptyImages
+	r.Aborted = p.abortErr() != nil
+
+	r.Errors = make(map[string]int, len(p.errors.counts))
+	for category, count := range p.errors.counts {
+		r.Errors[category] = count
+	}
+}
@@ -0,0 +1,71 @@
+package retention
+
+import (
+	"testing"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+func TestExecuteResult(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), append(numbered("app", 3), numbered("other", 1)...)...)
+
+	cfg := parseConfig(t, `
+rules:
+  - {name: apps, regex: "^app$", keep: 1}
+  - {name: unused, regex: "^unused$", keep: 1}
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	result := execute(t, engine)
+
+	if result.ExecutionID == "" || result.DryRun || result.FinishedAt.Before(result.StartedAt) {
+		t.Errorf("run fields %+v", result)
+	}
+	if result.Deleted != 2 || result.Kept != 1 || len(result.Errors) != 0 {
+		t.Errorf("deleted %d, kept %d, errors %v, want 2, 1 and none", result.Deleted, result.Kept, result.Errors)
+	}
+	if !equalStrings(result.DeadRules, []string{"unused"}) {
+		t.Errorf("dead rules %v, want unused", result.DeadRules)
+	}
+
+	if len(result.Repositories) != 1 {
+		t.Fatalf("repositories %+v, want docker-hosted", result.Repositories)
+	}
+	repo := result.Repositories[0]
+	if repo.Name != "docker-hosted" || repo.Deleted != 2 || repo.Kept != 1 || repo.ReclaimedBytes != 2048 || repo.Stats.Components != 4 {
+		t.Errorf("repository result %+v", repo)
+	}
+
+	// Only images matched by a rule are listed
+	if len(repo.Images) != 1 {
+		t.Fatalf("images %+v, want app", repo.Images)
+	}
+	image := repo.Images[0]
+	if image.Name != "app" || image.Rule != "apps" || image.Components != 3 || image.Deleted != 2 || image.Kept != 1 || len(image.Failed) != 0 {
+		t.Errorf("image result %+v", image)
+	}
+	var decisions []string
+	for _, d := range image.Decisions {
+		decisions = append(decisions, string(d.Action)+" "+d.Component.Version+" ("+d.Reason+")")
+	}
+	want := []string{"KEEP v3 (newest 1)", "DELETE v2 (beyond keep 1)", "DELETE v1 (beyond keep 1)"}
+	if !equalStrings(decisions, want) {
+		t.Errorf("decisions %v, want %v", decisions, want)
+	}
+}
+
+func TestPlanResult(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), numbered("app", 3)...)
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	plan, result, err := engine.Plan()
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+
+	if !result.DryRun || result.Deleted != 2 || len(plan.Deletions) != 2 || len(fake.Deleted) != 0 {
+		t.Errorf("plan of %d deletions, result %+v", len(plan.Deletions), result)
+	}
+}
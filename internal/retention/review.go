@@ -0,0 +1,126 @@
+package retention
+
+// ImageDeletions are the planned deletions of one image, in plan order.
+type ImageDeletions struct {
+	Repository string
+	ImageName  string
+	// Indexes are the positions of the deletions in the selection
+	Indexes []int
+}
+
+// Selection tracks which deletions of a plan were approved during review.
+// All deletions start selected.
+type Selection struct {
+	plan      *Plan
+	deletions []PlannedDeletion
+	selected  []bool
+	images    []ImageDeletions
+}
+
+// NewSelection selects all deletions of a plan and groups them by
+// repository and image in order of first appearance. Deletions are indexed
+// in group order, so the deletions of an image are consecutive.
+func NewSelection(plan *Plan) *Selection {
+	s := &Selection{plan: plan}
+
+	positions := make(map[[2]string]int)
+	var groups [][]PlannedDeletion
+	for _, d := range plan.Deletions {
+		key := [2]string{d.Repository, d.ImageName}
+		pos, ok := positions[key]
+		if !ok {
+			pos = len(s.images)
+			positions[key] = pos
+			s.images = append(s.images, ImageDeletions{Repository: d.Repository, ImageName: d.ImageName})
+			groups = append(groups, nil)
+		}
+		groups[pos] = append(groups[pos], d)
+	}
+
+	for pos, group := range groups {
+		for _, d := range group {
+			s.images[pos].Indexes = append(s.images[pos].Indexes, len(s.deletions))
+			s.deletions = append(s.deletions, d)
+			s.selected = append(s.selected, true)
+		}
+	}
+	return s
+}
+
+// Images returns the deletions grouped by repository and image.
+func (s *Selection) Images() []ImageDeletions {
+	return s.images
+}
+
+// Deletion returns the planned deletion at index i.
+func (s *Selection) Deletion(i int) PlannedDeletion {
+	return s.deletions[i]
+}
+
+// Len returns the number of planned deletions.
+func (s *Selection) Len() int {
+	return len(s.selected)
+}
+
+// Selected reports whether the deletion at index i is selected.
+func (s *Selection) Selected(i int) bool {
+	return s.selected[i]
+}
+
+// Count returns the number of selected deletions.
+func (s *Selection) Count() int {
+	count := 0
+	for _, selected := range s.selected {
+		if selected {
+			count++
+		}
+	}
+	return count
+}
+
+// Toggle flips the deletion at index i. Out of range indexes are ignored.
+func (s *Selection) Toggle(i int) bool {
+	if i < 0 || i >= len(s.selected) {
+		return false
+	}
+	s.selected[i] = !s.selected[i]
+	return true
+}
+
+// ToggleImage flips all deletions of the image group at index i: they are
+// deselected if all are selected and selected otherwise.
+func (s *Selection) ToggleImage(i int) bool {
+	if i < 0 || i >= len(s.images) {
+		return false
+	}
+
+	all := true
+	for _, idx := range s.images[i].Indexes {
+		all = all && s.selected[idx]
+	}
+	for _, idx := range s.images[i].Indexes {
+		s.selected[idx] = !all
+	}
+	return true
+}
+
+// SetAll selects or deselects every deletion.
+func (s *Selection) SetAll(selected bool) {
+	for i := range s.selected {
+		s.selected[i] = selected
+	}
+}
+
+// Plan returns a plan with only the selected deletions, in group order.
+func (s *Selection) Plan() *Plan {
+	filtered := &Plan{
+		ExecutionID: s.plan.ExecutionID,
+		CreatedAt:   s.plan.CreatedAt,
+	}
+	for i, d := range s.deletions {
+		if s.selected[i] {
+			filtered.Deletions = append(filtered.Deletions, d)
+		}
+	}
+	return filtered
+}
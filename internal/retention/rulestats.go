@@ -0,0 +1,66 @@
+package retention
+
+import (
+	"fmt"
+	"sort"
+)
+
+// RuleStats are the numbers of a rule over the images it was applied to,
+// for tuning rules. Deletions of dry runs count as deleted; deletions of
+// dry-run rules don't.
+type RuleStats struct {
+	Rule string `json:"rule"`
+	// Images counts the images the rule was applied to
+	Images int `json:"images"`
+	// Components counts the components of those images that were evaluated
+	Components int `json:"components"`
+	Kept       int `json:"kept"`
+	Deleted    int `json:"deleted"`
+}
+
+// ruleStats aggregates the image results by rule, in configuration order.
+// Rules that weren't applied to any image are left out.
+func (p *PolicyEngine) ruleStats(images []ImageResult) []RuleStats {
+	byRule := make(map[string]*RuleStats)
+	for _, image := range images {
+		stats := byRule[image.Rule]
+		if stats == nil {
+			stats = &RuleStats{Rule: image.Rule}
+			byRule[image.Rule] = stats
+		}
+		stats.Images++
+		stats.Components += image.Components
+		stats.Kept += image.Kept
+		stats.Deleted += image.Deleted
+	}
+
+	var result []RuleStats
+	for _, rule := range p.config.Rules {
+		if stats := byRule[rule.Name]; stats != nil {
+			result = append(result, *stats)
+			delete(byRule, rule.Name)
+		}
+	}
+	// Keep overrides aren't configured rules
+	var other []string
+	for name := range byRule {
+		other = append(other, name)
+	}
+	sort.Strings(other)
+	for _, name := range other {
+		result = append(result, *byRule[name])
+	}
+	return result
+}
+
+// printRuleStats lists the numbers of every applied rule in the summary.
+func (p *PolicyEngine) printRuleStats(stats []RuleStats) {
+	if len(stats) == 0 {
+		return
+	}
+
+	fmt.Println("   Rules:")
+	for _, s := range stats {
+		fmt.Printf("     %s: %d images, %d components, %d kept, %d deleted\n", s.Rule, s.Images, s.Components, s.Kept, s.Deleted)
+	}
+}
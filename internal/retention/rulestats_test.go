@@ -0,0 +1,42 @@
+package retention
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+func TestExecuteRuleStats(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-a"), append(numbered("app-web", 3), numbered("base", 2)...)...)
+	fake.AddRepository(dockerRepo("docker-b"), append(numbered("app-api", 4), numbered("pinned", 3)...)...)
+
+	cfg := parseConfig(t, `
+rules:
+  - {name: apps, regex: "^app-", keep: 2}
+  - {name: unused, regex: "^unused$", keep: 1}
+  - {name: rest, regex: ".*", keep: 1}
+keep_overrides: {pinned: 3}
+`)
+	engine, _ := newTestEngine(fake, cfg, Options{})
+	var result *RunResult
+	out := captureStdout(t, func() { result = execute(t, engine) })
+
+	// Rules in configuration order, then keep overrides
+	want := []RuleStats{
+		{Rule: "apps", Images: 2, Components: 7, Kept: 4, Deleted: 3},
+		{Rule: "rest", Images: 1, Components: 2, Kept: 1, Deleted: 1},
+		{Rule: "keep override", Images: 1, Components: 3, Kept: 3},
+	}
+	if !reflect.DeepEqual(result.Rules, want) {
+		t.Errorf("rule stats %+v, want %+v", result.Rules, want)
+	}
+	if got := result.Repositories[1].Rules; len(got) != 2 || got[0] != (RuleStats{Rule: "apps", Images: 1, Components: 4, Kept: 2, Deleted: 2}) {
+		t.Errorf("docker-b rule stats %+v", got)
+	}
+	if !strings.Contains(out, "apps: 2 images, 7 components, 4 kept, 3 deleted") {
+		t.Errorf("rule stats not printed:\n%s", out)
+	}
+}
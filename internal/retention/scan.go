@@ -0,0 +1,78 @@
+package retention
+
+import (
+	"fmt"
+	"time"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// RepositoryStats summarizes the contents of a repository before any deletion.
+type RepositoryStats struct {
+	Repository string
+	Components int
+	Images     int
+	TotalSize  int64
+	Oldest     time.Time
+	Newest     time.Time
+}
+
+// Scan computes pre-scan statistics for the components of a repository.
+func (p *PolicyEngine) Scan(repoName string, components []nexus.Component) RepositoryStats {
+	stats := RepositoryStats{
+		Repository: repoName,
+		Components: len(components),
+	}
+
+	images := make(map[string]bool)
+	for _, comp := range components {
+		images[comp.Name] = true
+
+		stats.TotalSize += componentSize(comp)
+
+		modified := p.getLastModified(comp)
+		if modified.IsZero() {
+			continue
+		}
+		if stats.Oldest.IsZero() || modified.Before(stats.Oldest) {
+			stats.Oldest = modified
+		}
+		if modified.After(stats.Newest) {
+			stats.Newest = modified
+		}
+	}
+	stats.Images = len(images)
+
+	return stats
+}
+
+// Print writes the pre-scan summary for the repository.
+func (s RepositoryStats) Print() {
+	fmt.Printf("  Found %d components in %d images (%s)\n", s.Components, s.Images, FormatBytes(s.TotalSize))
+	if !s.Oldest.IsZero() {
+		fmt.Printf("  Oldest: %s, newest: %s\n", s.Oldest.Format("2006-01-02"), s.Newest.Format("2006-01-02"))
+	}
+}
+
+// componentSize sums the file sizes of a component's assets.
+func componentSize(comp nexus.Component) int64 {
+	var size int64
+	for _, asset := range comp.Assets {
+		size += asset.FileSize
+	}
+	return size
+}
+
+// FormatBytes formats a size in binary units, e.g. "1.5 GiB".
+func FormatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,58 @@
+package retention
+
+import (
+	"testing"
+	"time"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+func TestScan(t *testing.T) {
+	components := append(tags("app", "v3", "v2", "v1"), tags("api", "v1")...)
+	// A second asset adds to the size of its component
+	components[0].Assets = append(components[0].Assets, nexus.Asset{FileSize: 4096, LastModified: testTime})
+	// Components without timestamps don't affect the range
+	components = append(components, nexus.Component{ID: "web:v1", Name: "web", Version: "v1", Assets: []nexus.Asset{{FileSize: 512}}})
+
+	engine, _ := newTestEngine(nil, parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`), Options{})
+	stats := engine.Scan("docker-hosted", components)
+
+	want := RepositoryStats{
+		Repository: "docker-hosted",
+		Components: 5,
+		Images:     3,
+		TotalSize:  4*1024 + 4096 + 512,
+		Oldest:     testTime.Add(-2 * time.Hour),
+		Newest:     testTime,
+	}
+	if stats != want {
+		t.Errorf("Scan = %+v, want %+v", stats, want)
+	}
+}
+
+func TestScanEmptyRepository(t *testing.T) {
+	engine, _ := newTestEngine(nil, parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`), Options{})
+	if stats := engine.Scan("docker-hosted", nil); stats != (RepositoryStats{Repository: "docker-hosted"}) {
+		t.Errorf("Scan = %+v, want no components", stats)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 << 20, "5.0 MiB"},
+		{3 << 30, "3.0 GiB"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatBytes(tt.size); got != tt.want {
+			t.Errorf("FormatBytes(%d) = %s, want %s", tt.size, got, tt.want)
+		}
+	}
+}
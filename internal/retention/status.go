@@ -0,0 +1,89 @@
+package retention
+
+import (
+	"sync"
+	"time"
+)
+
+// RunStatus is a snapshot of the progress of the current or last run.
+type RunStatus struct {
+	Running     bool       `json:"running"`
+	ExecutionID string     `json:"execution_id,omitempty"`
+	DryRun      bool       `json:"dry_run"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	// ReposDone and ReposTotal count the repositories processed in this run
+	ReposDone   int    `json:"repositories_done"`
+	ReposTotal  int    `json:"repositories_total"`
+	CurrentRepo string `json:"current_repository,omitempty"`
+	// Deleted and Kept count components of the images processed so far
+	Deleted int `json:"deleted"`
+	Kept    int `json:"kept"`
+}
+
+// StatusTracker holds the status of runs for concurrent readers, e.g. an
+// HTTP endpoint polled while a run is in progress. A nil tracker ignores
+// all updates.
+type StatusTracker struct {
+	mu     sync.Mutex
+	status RunStatus
+}
+
+// NewStatusTracker creates a tracker with no run recorded.
+func NewStatusTracker() *StatusTracker {
+	return &StatusTracker{}
+}
+
+// Status returns a snapshot of the current status.
+func (t *StatusTracker) Status() RunStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.status
+}
+
+func (t *StatusTracker) update(fn func(s *RunStatus)) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fn(&t.status)
+}
+
+func (t *StatusTracker) start(executionID string, dryRun bool, now time.Time) {
+	t.update(func(s *RunStatus) {
+		*s = RunStatus{Running: true, ExecutionID: executionID, DryRun: dryRun, StartedAt: now}
+	})
+}
+
+func (t *StatusTracker) setRepositories(total int) {
+	t.update(func(s *RunStatus) { s.ReposTotal = total })
+}
+
+func (t *StatusTracker) startRepository(name string) {
+	t.update(func(s *RunStatus) { s.CurrentRepo = name })
+}
+
+func (t *StatusTracker) addImage(deleted, kept int) {
+	t.update(func(s *RunStatus) {
+		s.Deleted += deleted
+		s.Kept += kept
+	})
+}
+
+func (t *StatusTracker) finishRepository() {
+	t.update(func(s *RunStatus) {
+		s.ReposDone++
+		s.CurrentRepo = ""
+	})
+}
+
+func (t *StatusTracker) finish(now time.Time) {
+	t.update(func(s *RunStatus) {
+		s.Running = false
+		s.CurrentRepo = ""
+		s.FinishedAt = &now
+	})
+}
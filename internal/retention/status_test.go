@@ -0,0 +1,49 @@
+package retention
+
+import (
+	"testing"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+func TestStatusDuringRun(t *testing.T) {
+	listing, release := make(chan struct{}), make(chan struct{})
+	mock := &nexus.MockClient{
+		GetRepositoriesFunc: func() ([]nexus.Repository, error) {
+			return []nexus.Repository{dockerRepo("first"), dockerRepo("second")}, nil
+		},
+		GetComponentsFunc: func(repository string) ([]nexus.Component, error) {
+			if repository == "second" {
+				// Hold the run until the status is checked
+				close(listing)
+				<-release
+				return numbered("api", 2), nil
+			}
+			return numbered("app", 3), nil
+		},
+	}
+
+	tracker := NewStatusTracker()
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`)
+	engine, _ := newTestEngine(mock, cfg, Options{Status: tracker})
+	done := make(chan error)
+	go func() {
+		_, err := engine.Execute()
+		done <- err
+	}()
+
+	<-listing
+	status := tracker.Status()
+	if !status.Running || status.ReposDone != 1 || status.ReposTotal != 2 || status.CurrentRepo != "second" || status.Deleted != 2 || status.Kept != 1 {
+		t.Errorf("status during the run %+v", status)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	status = tracker.Status()
+	if status.Running || status.FinishedAt == nil || status.ReposDone != 2 || status.Deleted != 3 || status.Kept != 2 {
+		t.Errorf("status after the run %+v", status)
+	}
+}
@@ -0,0 +1,224 @@
+package retention
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"nexus-retention-policy/internal/config"
+	"nexus-retention-policy/internal/nexus"
+)
+
+// ComponentStreamer is implemented by registries that can list components
+// page by page. Only Nexus supports stream_components.
+type ComponentStreamer interface {
+	EachComponentPage(repository string, fn func(page []nexus.Component) error) error
+}
+
+// errStreamingUnsupported is returned for streamed listings on registries
+// that don't implement ComponentStreamer.
+var errStreamingUnsupported = errors.New("registry does not support listing components page by page")
+
+// eachComponentPage lists components page by page if the registry supports
+// it.
+func eachComponentPage(registry Registry, repository string, fn func(page []nexus.Component) error) error {
+	streamer, ok := registry.(ComponentStreamer)
+	if !ok {
+		return errStreamingUnsupported
+	}
+	return streamer.EachComponentPage(repository, fn)
+}
+
+// componentHeap holds the newest ranked components seen so far, with the
+// oldest at the root, so it is the one dropped when the heap exceeds the
+// keep count.
+type componentHeap struct {
+	components []nexus.Component
+	older      func(a, b nexus.Component) bool
+}
+
+func (h *componentHeap) Len() int           { return len(h.components) }
+func (h *componentHeap) Less(i, j int) bool { return h.older(h.components[i], h.components[j]) }
+func (h *componentHeap) Swap(i, j int) {
+	h.components[i], h.components[j] = h.components[j], h.components[i]
+}
+func (h *componentHeap) Push(x any) { h.components = append(h.components, x.(nexus.Component)) }
+func (h *componentHeap) Pop() any {
+	last := h.components[len(h.components)-1]
+	h.components = h.components[:len(h.components)-1]
+	return last
+}
+
+// imageStream collects the decisions of an image while its components are
+// listed. Only deletions and the newest keep components are held; other
+// kept components are just counted.
+type imageStream struct {
+	rule *config.Rule
+	// format is the format of the first listed component
+	format     string
+	components int
+	fixedKept  int
+	deletions  []Decision
+	ranked     map[rankKey]*componentHeap
+	// mostRecent is the most recently pushed tagged component so far, with
+	// protect_most_recent_from_age
+	mostRecent *nexus.Component
+}
+
+// streamRepository applies the retention rules to a repository page by page
+// as its components are listed. Deletions are made once the listing is
+// complete, so a failed listing deletes nothing and deleting doesn't shift
+// the pages still to be listed. Returns errStreamingUnsupported if the
+// registry can't list page by page.
+func (p *PolicyEngine) streamRepository(repoName string) (deleted, kept int, err error) {
+	images := make(map[string]*imageStream)
+	unmatched := make(map[string]bool)
+	skipped := 0
+	now := time.Now()
+
+	err = eachComponentPage(p.client, repoName, func(page []nexus.Component) error {
+		if err := p.abortErr(); err != nil {
+			return err
+		}
+		if err := p.enrichDetails(page); err != nil {
+			return err
+		}
+
+		components := make([]nexus.Component, 0, len(page))
+		for _, comp := range page {
+			if p.excludedByBlobStore(comp) {
+				skipped++
+				continue
+			}
+			if p.config.FloatingTag(comp.Version, config.FloatingTagIgnore) {
+				continue
+			}
+			components = append(components, comp)
+		}
+
+		if len(p.config.AssetRules) > 0 && p.options.Rule == "" {
+			p.processAssetRules(repoName, components)
+		}
+
+		for _, comp := range components {
+			imageName := p.config.CanonicalImage(groupName(comp))
+			image := images[imageName]
+			if image == nil {
+				if unmatched[imageName] {
+					continue
+				}
+				// Rules that depend on all components are rejected with
+				// stream_components, so the image name selects the rule
+				rule := p.matchRule(repoName, imageName, nil)
+				if rule == nil {
+					unmatched[imageName] = true
+					continue
+				}
+				image = &imageStream{rule: rule, format: comp.Format, ranked: make(map[rankKey]*componentHeap)}
+				images[imageName] = image
+			}
+			p.streamComponent(image, comp, now)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if skipped > 0 {
+		fmt.Printf("  Skipped %d components in excluded blob stores\n", skipped)
+	}
+
+	imageNames := make([]string, 0, len(images)+len(unmatched))
+	for imageName := range images {
+		imageNames = append(imageNames, imageName)
+	}
+	for imageName := range unmatched {
+		imageNames = append(imageNames, imageName)
+	}
+	sort.Strings(imageNames)
+
+	for _, imageName := range imageNames {
+		if p.abortErr() != nil {
+			break
+		}
+		image := images[imageName]
+		if image == nil {
+			if p.options.Verbosity >= VerbosityTag {
+				fmt.Printf("  ⏭️  Image: %s (no matching rule, skipping)\n", imageName)
+			}
+			continue
+		}
+
+		p.ruleMatches[image.rule.Name]++
+		p.printImage(imageName, image.rule, image.format)
+		result := ImageResult{Name: imageName, Rule: image.rule.Name, Decisions: p.streamDecisions(image), Components: image.components}
+		d, k := p.applyDecisions(repoName, image.rule, result, image.fixedKept)
+		deleted += d
+		kept += k
+		p.options.Status.addImage(d, k)
+	}
+
+	return deleted, kept, nil
+}
+
+// streamComponent decides a listed component of an image. Ranked components
+// enter the keep heap of their rank; once it holds more than the keep count,
+// its oldest component is beyond keep. Listing order doesn't matter, and
+// with keep counts of at least 1 the newest tag is never beyond keep.
+func (p *PolicyEngine) streamComponent(image *imageStream, comp nexus.Component, now time.Time) {
+	rule := image.rule
+	image.components++
+	if p.config.ProtectMostRecentFromAge && !isUntagged(comp) && (image.mostRecent == nil || p.pushedAfter(comp, *image.mostRecent)) {
+		image.mostRecent = &comp
+	}
+
+	d, fixed := p.fixedDecision(rule, comp)
+	if !fixed {
+		key := rankOf(rule, comp)
+		keep, class := keepFor(rule, comp, key)
+		h := image.ranked[key]
+		if h == nil {
+			h = &componentHeap{older: func(a, b nexus.Component) bool { return p.newer(rule, b, a) }}
+			image.ranked[key] = h
+		}
+
+		heap.Push(h, comp)
+		if h.Len() <= keep {
+			return
+		}
+		d = Decision{Component: heap.Pop(h).(nexus.Component), Action: ActionDelete, Reason: fmt.Sprintf("beyond keep %d%s", keep, class)}
+	}
+
+	p.holdYoung(&d, now)
+	if d.Action == ActionDelete {
+		image.deletions = append(image.deletions, d)
+	} else {
+		image.fixedKept++
+	}
+}
+
+// streamDecisions returns the held decisions of a fully listed image, most
+// recent first: the deletions and the newest keep components. The most
+// recently pushed tag is only known now, so its deletion is protected here.
+func (p *PolicyEngine) streamDecisions(image *imageStream) []Decision {
+	decisions := image.deletions
+	for i, d := range decisions {
+		if image.mostRecent != nil && d.Component.ID == image.mostRecent.ID {
+			decisions[i].Action, decisions[i].Reason = ActionProtected, "most recently pushed tag"
+		}
+	}
+	for key, h := range image.ranked {
+		for _, comp := range h.components {
+			keep, class := keepFor(image.rule, comp, key)
+			decisions = append(decisions, Decision{Component: comp, Action: ActionKeep, Reason: fmt.Sprintf("newest %d%s", keep, class)})
+		}
+	}
+
+	sort.Slice(decisions, func(i, j int) bool {
+		return p.newer(image.rule, decisions[i].Component, decisions[j].Component)
+	})
+	return decisions
+}
@@ -0,0 +1,72 @@
+package retention
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+func TestStreamingMatchesBatch(t *testing.T) {
+	// Several pages of interleaved images in no particular order
+	components := append(numbered("app", 17), numbered("api", 12)...)
+	components = append(components, tags("web", "latest", "v3", "stable", "v2", "v1")...)
+	components = append(components, component("api", "", testTime.Add(-30*time.Hour)))
+	rand.New(rand.NewSource(1)).Shuffle(len(components), func(i, j int) {
+		components[i], components[j] = components[j], components[i]
+	})
+
+	const rules = `
+rules:
+  - {name: apps, regex: "^app", keep: 5, always_keep_regex: "^v1$"}
+  - {name: rest, regex: ".*", keep: 2}
+protected_tags: [latest, stable]
+delete_untagged: true
+`
+	run := func(settings string) ([]string, map[string]Action) {
+		fake := nexus.NewFakeClient()
+		fake.AddRepository(dockerRepo("docker-hosted"), append([]nexus.Component(nil), components...)...)
+		engine, _ := newTestEngine(fake, parseConfig(t, rules+settings), Options{})
+		result := execute(t, engine)
+
+		// Streaming only lists the deletions and newest kept tags
+		decisions := decisionsOf(result)
+		for id, action := range decisions {
+			if action != ActionDelete {
+				delete(decisions, id)
+			}
+		}
+		return deletedTags(fake), decisions
+	}
+
+	batchDeleted, batchDecisions := run("")
+	streamDeleted, streamDecisions := run("stream_components: true")
+
+	if len(batchDeleted) == 0 || !equalStrings(streamDeleted, batchDeleted) {
+		t.Errorf("streaming deleted %v, batch deleted %v", streamDeleted, batchDeleted)
+	}
+	if !reflect.DeepEqual(streamDecisions, batchDecisions) {
+		t.Errorf("streaming decided %v, batch decided %v", streamDecisions, batchDecisions)
+	}
+}
+
+func TestStreamingFallsBackToListing(t *testing.T) {
+	mock := mockRegistry(numbered("app", 2))
+	cfg := parseConfig(t, `
+rules: [{name: all, regex: ".*", keep: 1}]
+stream_components: true
+`)
+	// Hide the optional interfaces of the mock
+	engine, _ := newTestEngine(struct{ Registry }{mock}, cfg, Options{})
+	out := captureStdout(t, func() { execute(t, engine) })
+
+	if !strings.Contains(out, "can't list components page by page") {
+		t.Errorf("fallback not reported:\n%s", out)
+	}
+	if got := mock.CallsTo("DeleteComponent"); !equalStrings(got, []string{"app:v1"}) {
+		t.Errorf("deleted %v, want app:v1", got)
+	}
+}
@@ -0,0 +1,139 @@
+package retention
+
+import (
+	"errors"
+	"fmt"
+
+	"nexus-retention-policy/internal/nexus"
+	"nexus-retention-policy/internal/tracing"
+)
+
+// tracedAPI wraps a Registry with a span per call, parented to the span of
+// the repository being processed. Calls fail without a request once the
+// engine's circuit breaker is open.
+type tracedAPI struct {
+	next   Registry
+	tracer *tracing.Tracer
+	engine *PolicyEngine
+}
+
+func (t *tracedAPI) start(name string) *tracing.Span {
+	_, span := t.tracer.Start(t.engine.ctx, name)
+	return span
+}
+
+func (t *tracedAPI) end(span *tracing.Span, err error) {
+	var apiErr *nexus.APIError
+	if errors.As(err, &apiErr) {
+		span.SetAttribute("http.status_code", apiErr.StatusCode)
+	}
+	span.RecordError(err)
+	span.End()
+}
+
+func (t *tracedAPI) GetRepositories() ([]nexus.Repository, error) {
+	if err := t.engine.breaker.err(); err != nil {
+		return nil, err
+	}
+	span := t.start("nexus.GetRepositories")
+	repos, err := t.next.GetRepositories()
+	t.engine.breaker.record(err)
+	span.SetAttribute("nexus.repositories", len(repos))
+	t.end(span, err)
+	return repos, err
+}
+
+func (t *tracedAPI) GetComponents(repository string) ([]nexus.Component, error) {
+	if err := t.engine.breaker.err(); err != nil {
+		return nil, err
+	}
+	span := t.start("nexus.GetComponents")
+	span.SetAttribute("nexus.repository", repository)
+	components, err := t.next.GetComponents(repository)
+	t.engine.breaker.record(err)
+	span.SetAttribute("nexus.components", len(components))
+	t.end(span, err)
+	return components, err
+}
+
+func (t *tracedAPI) EachComponentPage(repository string, fn func(page []nexus.Component) error) error {
+	if err := t.engine.breaker.err(); err != nil {
+		return err
+	}
+	span := t.start("nexus.EachComponentPage")
+	span.SetAttribute("nexus.repository", repository)
+	listed := 0
+	var fnErr error
+	err := eachComponentPage(t.next, repository, func(page []nexus.Component) error {
+		listed += len(page)
+		fnErr = fn(page)
+		return fnErr
+	})
+	// Errors of fn are not request failures
+	if fnErr == nil {
+		t.engine.breaker.record(err)
+	}
+	span.SetAttribute("nexus.components", listed)
+	t.end(span, err)
+	return err
+}
+
+func (t *tracedAPI) DeleteComponent(componentID string) error {
+	if err := t.engine.breaker.err(); err != nil {
+		return err
+	}
+	span := t.start("nexus.DeleteComponent")
+	span.SetAttribute("nexus.component_id", componentID)
+	err := t.next.DeleteComponent(componentID)
+	t.engine.breaker.record(err)
+	t.end(span, err)
+	return err
+}
+
+func (t *tracedAPI) DeleteAsset(assetID string) error {
+	if err := t.engine.breaker.err(); err != nil {
+		return err
+	}
+	span := t.start("nexus.DeleteAsset")
+	span.SetAttribute("nexus.asset_id", assetID)
+	err := deleteAsset(t.next, assetID)
+	t.engine.breaker.record(err)
+	t.end(span, err)
+	return err
+}
+
+func (t *tracedAPI) GetComponent(componentID string) (nexus.Component, error) {
+	if err := t.engine.breaker.err(); err != nil {
+		return nexus.Component{}, err
+	}
+	span := t.start("nexus.GetComponent")
+	span.SetAttribute("nexus.component_id", componentID)
+	comp, err := getComponent(t.next, componentID)
+	t.engine.breaker.record(err)
+	t.end(span, err)
+	return comp, err
+}
+
+func (t *tracedAPI) Truncated(repository string) bool {
+	return listingTruncated(t.next, repository)
+}
+
+func (t *tracedAPI) CanDelete(repo nexus.Repository) (bool, error) {
+	if err := t.engine.breaker.err(); err != nil {
+		return false, err
+	}
+	span := t.start("nexus.CanDelete")
+	span.SetAttribute("nexus.repository", repo.Name)
+	allowed, err := canDelete(t.next, repo)
+	t.engine.breaker.record(err)
+	span.SetAttribute("nexus.delete_permitted", allowed)
+	t.end(span, err)
+	return allowed, err
+}
+
+// flushTraces exports the spans of the finished run.
+func (p *PolicyEngine) flushTraces() {
+	if err := p.options.Tracer.Flush(); err != nil {
+		fmt.Printf("⚠️  Failed to export traces: %v\n", err)
+	}
+}
@@ -0,0 +1,141 @@
+package retention
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"nexus-retention-policy/internal/nexus"
+	"nexus-retention-policy/internal/tracing"
+)
+
+// exportedSpan is a span as exported over OTLP/HTTP JSON.
+type exportedSpan struct {
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Attributes   []struct {
+		Key   string            `json:"key"`
+		Value map[string]string `json:"value"`
+	} `json:"attributes"`
+	Status *struct {
+		Code int `json:"code"`
+	} `json:"status"`
+}
+
+// attribute returns the value of a span attribute.
+func (s exportedSpan) attribute(key string) string {
+	for _, attr := range s.Attributes {
+		if attr.Key == key {
+			for _, value := range attr.Value {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// collector is an OTLP/HTTP endpoint recording exported spans.
+type collector struct {
+	*httptest.Server
+	mu    sync.Mutex
+	spans []exportedSpan
+}
+
+func newCollector(t *testing.T) *collector {
+	c := &collector{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []exportedSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		if r.URL.Path != "/v1/traces" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				c.spans = append(c.spans, ss.Spans...)
+			}
+		}
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+// named returns the exported spans with the name.
+func (c *collector) named(name string) []exportedSpan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var spans []exportedSpan
+	for _, s := range c.spans {
+		if s.Name == name {
+			spans = append(spans, s)
+		}
+	}
+	return spans
+}
+
+func TestExecuteExportsSpans(t *testing.T) {
+	collector := newCollector(t)
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), numbered("app", 3)...)
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`)
+	engine, _ := newTestEngine(fake, cfg, Options{Tracer: tracing.New(collector.URL, "test")})
+	execute(t, engine)
+
+	runs := collector.named("retention.execute")
+	if len(runs) != 1 || runs[0].ParentSpanID != "" {
+		t.Fatalf("exported %d root execute spans, want 1", len(runs))
+	}
+	repos := collector.named("retention.repository")
+	if len(repos) != 1 || repos[0].ParentSpanID != runs[0].SpanID {
+		t.Fatalf("repository spans %+v, want one child of the execute span", repos)
+	}
+	repo := repos[0]
+	if repo.attribute("nexus.repository") != "docker-hosted" || repo.attribute("retention.deleted") != "2" || repo.attribute("retention.kept") != "1" {
+		t.Errorf("unexpected repository span attributes %+v", repo.Attributes)
+	}
+
+	// Registry calls of the repository are children of its span
+	for name, want := range map[string]int{"nexus.GetComponents": 1, "nexus.DeleteComponent": 2} {
+		spans := collector.named(name)
+		if len(spans) != want {
+			t.Errorf("exported %d %s spans, want %d", len(spans), name, want)
+		}
+		for _, s := range spans {
+			if s.ParentSpanID != repo.SpanID {
+				t.Errorf("%s span isn't a child of the repository span", name)
+			}
+		}
+	}
+	if repos := collector.named("nexus.GetRepositories"); len(repos) != 1 || repos[0].ParentSpanID != runs[0].SpanID {
+		t.Errorf("GetRepositories spans %+v, want one child of the execute span", repos)
+	}
+}
+
+func TestFailedRequestSpans(t *testing.T) {
+	collector := newCollector(t)
+	mock := mockRegistry(numbered("app", 2))
+	mock.DeleteComponentFunc = func(string) error { return &nexus.APIError{StatusCode: 500} }
+
+	cfg := parseConfig(t, `rules: [{name: all, regex: ".*", keep: 1}]`)
+	engine, _ := newTestEngine(mock, cfg, Options{Tracer: tracing.New(collector.URL, "test")})
+	if _, err := engine.Execute(); err == nil {
+		t.Fatal("Execute succeeded despite a failed deletion")
+	}
+
+	deletes := collector.named("nexus.DeleteComponent")
+	if len(deletes) != 1 || deletes[0].Status == nil || deletes[0].Status.Code != 2 || deletes[0].attribute("http.status_code") != "500" {
+		t.Errorf("delete spans %+v, want one failed with status 500", deletes)
+	}
+}
@@ -0,0 +1,35 @@
+package retention
+
+import (
+	"testing"
+
+	"nexus-retention-policy/internal/nexus"
+)
+
+// truncatingRegistry reports every listing as cut off.
+type truncatingRegistry struct {
+	*nexus.FakeClient
+}
+
+func (truncatingRegistry) Truncated(repository string) bool {
+	return true
+}
+
+func TestExecuteSkipsTruncatedListings(t *testing.T) {
+	fake := nexus.NewFakeClient()
+	fake.AddRepository(dockerRepo("docker-hosted"), numbered("app", 3)...)
+
+	cfg := parseConfig(t, `
+max_components_per_repo: 10
+rules: [{name: all, regex: ".*", keep: 1}]
+`)
+	engine, _ := newTestEngine(truncatingRegistry{fake}, cfg, Options{})
+	result := execute(t, engine)
+
+	if len(fake.Deleted) != 0 {
+		t.Errorf("deleted %v from a truncated listing", fake.Deleted)
+	}
+	if result.Repositories[0].Error == "" {
+		t.Errorf("truncated listing not reported")
+	}
+}
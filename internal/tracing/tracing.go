@@ -0,0 +1,220 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// scopeName identifies this tool as the instrumentation scope.
+const scopeName = "nexus-retention-policy"
+
+// Tracer records spans and exports them to an OpenTelemetry collector using
+// OTLP over HTTP with JSON encoding. A nil Tracer is valid and records
+// nothing, so callers don't need to check whether tracing is enabled.
+type Tracer struct {
+	endpoint    string
+	serviceName string
+	httpClient  *http.Client
+
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// Span is a single timed operation within a trace.
+type Span struct {
+	tracer   *Tracer
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	start    time.Time
+	end      time.Time
+
+	mu     sync.Mutex
+	attrs  map[string]interface{}
+	errMsg string
+}
+
+type spanKey struct{}
+
+// New creates a tracer exporting to an OTLP/HTTP endpoint such as
+// http://otel-collector:4318. It returns nil if endpoint is empty.
+func New(endpoint, serviceName string) *Tracer {
+	if endpoint == "" {
+		return nil
+	}
+	if serviceName == "" {
+		serviceName = scopeName
+	}
+	return &Tracer{
+		endpoint:    strings.TrimSuffix(endpoint, "/"),
+		serviceName: serviceName,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Start begins a span as a child of the span in ctx, if any.
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		tracer: t,
+		name:   name,
+		start:  time.Now(),
+		attrs:  make(map[string]interface{}),
+	}
+	rand.Read(span.spanID[:])
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok && parent != nil {
+		span.traceID = parent.traceID
+		span.parentID = parent.spanID
+	} else {
+		rand.Read(span.traceID[:])
+	}
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// SetAttribute sets a string, int or bool attribute on the span.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attrs[key] = value
+}
+
+// RecordError marks the span as failed.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.errMsg = err.Error()
+}
+
+// End finishes the span and queues it for export.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.tracer.spans = append(s.tracer.spans, s)
+}
+
+// Flush exports all ended spans and clears the queue.
+func (t *Tracer) Flush() error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	spans := t.spans
+	t.spans = nil
+	t.mu.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(t.encode(spans))
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", t.endpoint+"/v1/traces", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create trace export request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to export spans: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// encode builds an OTLP ExportTraceServiceRequest in its JSON mapping.
+func (t *Tracer) encode(spans []*Span) map[string]interface{} {
+	encoded := make([]map[string]interface{}, 0, len(spans))
+	for _, s := range spans {
+		s.mu.Lock()
+		span := map[string]interface{}{
+			"traceId":           hex.EncodeToString(s.traceID[:]),
+			"spanId":            hex.EncodeToString(s.spanID[:]),
+			"name":              s.name,
+			"kind":              1, // SPAN_KIND_INTERNAL
+			"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+			"attributes":        encodeAttributes(s.attrs),
+		}
+		if s.parentID != ([8]byte{}) {
+			span["parentSpanId"] = hex.EncodeToString(s.parentID[:])
+		}
+		if s.errMsg != "" {
+			span["status"] = map[string]interface{}{"code": 2, "message": s.errMsg} // STATUS_CODE_ERROR
+		}
+		s.mu.Unlock()
+		encoded = append(encoded, span)
+	}
+
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": encodeAttributes(map[string]interface{}{"service.name": t.serviceName}),
+				},
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": map[string]interface{}{"name": scopeName},
+						"spans": encoded,
+					},
+				},
+			},
+		},
+	}
+}
+
+func encodeAttributes(attrs map[string]interface{}) []interface{} {
+	encoded := make([]interface{}, 0, len(attrs))
+	for key, value := range attrs {
+		var v map[string]interface{}
+		switch value := value.(type) {
+		case bool:
+			v = map[string]interface{}{"boolValue": value}
+		case int:
+			v = map[string]interface{}{"intValue": strconv.Itoa(value)}
+		case int64:
+			v = map[string]interface{}{"intValue": strconv.FormatInt(value, 10)}
+		default:
+			v = map[string]interface{}{"stringValue": fmt.Sprint(value)}
+		}
+		encoded = append(encoded, map[string]interface{}{"key": key, "value": v})
+	}
+	return encoded
+}
@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNilTracer(t *testing.T) {
+	tracer := New("", "test")
+	if tracer != nil {
+		t.Fatal("New without endpoint returned a tracer")
+	}
+
+	ctx, span := tracer.Start(context.Background(), "noop")
+	if ctx == nil || span != nil {
+		t.Fatalf("Start on a nil tracer returned span %v", span)
+	}
+	span.SetAttribute("key", 1)
+	span.RecordError(errors.New("failed"))
+	span.End()
+	if err := tracer.Flush(); err != nil {
+		t.Errorf("Flush: %v", err)
+	}
+}
+
+func TestSpanHierarchy(t *testing.T) {
+	tracer := New("http://collector.test:4318/", "")
+	if tracer.endpoint != "http://collector.test:4318" || tracer.serviceName != scopeName {
+		t.Errorf("tracer endpoint %q, service %q", tracer.endpoint, tracer.serviceName)
+	}
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	_, child := tracer.Start(ctx, "child")
+	_, other := tracer.Start(context.Background(), "other")
+
+	if child.traceID != root.traceID || child.parentID != root.spanID {
+		t.Error("child span isn't part of its parent's trace")
+	}
+	if other.traceID == root.traceID || other.parentID != ([8]byte{}) {
+		t.Error("span without parent joined another trace")
+	}
+
+	child.End()
+	root.End()
+	if len(tracer.spans) != 2 {
+		t.Errorf("queued %d ended spans, want 2", len(tracer.spans))
+	}
+}
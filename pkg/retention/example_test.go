@@ -0,0 +1,203 @@
+package retention_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"nexus-retention-policy/pkg/retention"
+)
+
+// testNexus serves the repositories and components API of a Nexus with one
+// hosted Docker repository and records the requests made.
+type testNexus struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	components []retention.Component
+	deleted    []string
+	requests   []*http.Request
+}
+
+// newTestNexus starts a Nexus holding the tags of app in docker-hosted, the
+// first one pushed most recently.
+func newTestNexus(tags ...string) *testNexus {
+	n := &testNexus{}
+	pushed := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	for i, tag := range tags {
+		id := "app-" + tag
+		n.components = append(n.components, retention.Component{
+			ID: id, Repository: "docker-hosted", Format: "docker", Name: "app", Version: tag,
+			Assets: []retention.Asset{{ID: id, Path: "v2/app/manifests/" + tag, FileSize: 100, LastModified: pushed.Add(-time.Duration(i) * time.Hour)}},
+		})
+	}
+	n.Server = httptest.NewServer(http.HandlerFunc(n.serve))
+	return n
+}
+
+func (n *testNexus) serve(w http.ResponseWriter, r *http.Request) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.requests = append(n.requests, r)
+
+	switch {
+	case r.URL.Path == "/service/rest/v1/repositories":
+		json.NewEncoder(w).Encode([]retention.Repository{{Name: "docker-hosted", Format: "docker", Type: "hosted"}})
+	case r.URL.Path == "/service/rest/v1/components" && r.Method == http.MethodGet:
+		json.NewEncoder(w).Encode(map[string]any{"items": n.components})
+	case strings.HasPrefix(r.URL.Path, "/service/rest/v1/components/") && r.Method == http.MethodDelete:
+		n.deleted = append(n.deleted, strings.TrimPrefix(r.URL.Path, "/service/rest/v1/components/"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// config returns a configuration for the server keeping the newest tag,
+// with extra top-level settings.
+func (n *testNexus) config(extra string) string {
+	return fmt.Sprintf(`
+nexus:
+  url: %q
+  username: admin
+  password: secret
+  headers:
+    X-Gateway-Route: nexus-internal
+rules:
+  - name: all
+    regex: ".*"
+    keep: 1
+%s`, n.URL, extra)
+}
+
+// quiet runs fn with stdout discarded, hiding the progress Execute prints.
+func quiet(fn func()) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		fn()
+		return
+	}
+	stdout := os.Stdout
+	os.Stdout = devNull
+	defer func() {
+		os.Stdout = stdout
+		devNull.Close()
+	}()
+	fn()
+}
+
+func Example_dryRun() {
+	nexus := newTestNexus("v3", "v2", "v1")
+	defer nexus.Close()
+
+	cfg, err := retention.ParseConfig([]byte(nexus.config("")))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	engine := retention.NewEngine(retention.NewNexusClient(cfg), cfg, retention.Discard, retention.Options{DryRun: true})
+
+	var result *retention.RunResult
+	quiet(func() { result, err = engine.Execute() })
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, repo := range result.Repositories {
+		for _, image := range repo.Images {
+			for _, d := range image.Decisions {
+				fmt.Println(repo.Name, image.Name, d.Component.Version, d.Action)
+			}
+		}
+	}
+	// Output:
+	// docker-hosted app v3 KEEP
+	// docker-hosted app v2 DELETE
+	// docker-hosted app v1 DELETE
+}
+
+// recordingLog is a DeletionLogger of a consumer of the package.
+type recordingLog struct {
+	records []retention.DeletionRecord
+}
+
+func (l *recordingLog) LogDeletion(record retention.DeletionRecord) error {
+	l.records = append(l.records, record)
+	return nil
+}
+
+func TestEngineDeletes(t *testing.T) {
+	nexus := newTestNexus("v3", "v2", "v1")
+	defer nexus.Close()
+
+	cfg, err := retention.ParseConfig([]byte(nexus.config("")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := &recordingLog{}
+	engine := retention.NewEngine(retention.NewNexusClient(cfg), cfg, log, retention.Options{})
+	var result *retention.RunResult
+	quiet(func() { result, err = engine.Execute() })
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	sort.Strings(nexus.deleted)
+	if got := strings.Join(nexus.deleted, ","); got != "app-v1,app-v2" {
+		t.Errorf("deleted %s, want app-v1,app-v2", got)
+	}
+	if result.Deleted != 2 || len(log.records) != 2 {
+		t.Errorf("result deleted %d and logged %d, want 2", result.Deleted, len(log.records))
+	}
+}
+
+func TestNewNexusClientUsesConfig(t *testing.T) {
+	nexus := newTestNexus("v3", "v2", "v1")
+	defer nexus.Close()
+
+	cfg, err := retention.ParseConfig([]byte(nexus.config(`
+repository_settings:
+  docker-hosted:
+    username: deployer
+    password: deploy-secret
+max_components_per_repo: 3
+`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := retention.NewNexusClient(cfg)
+	if _, err := client.GetComponents("docker-hosted"); err != nil {
+		t.Fatalf("GetComponents: %v", err)
+	}
+	nexus.mu.Lock()
+	req := nexus.requests[len(nexus.requests)-1]
+	nexus.mu.Unlock()
+	if user, pass, _ := req.BasicAuth(); user != "deployer" || pass != "deploy-secret" {
+		t.Errorf("listed with %s:%s, want the repository_settings credentials", user, pass)
+	}
+	if got := req.Header.Get("User-Agent"); got != "nexus-retention-policy/embedded" {
+		t.Errorf("User-Agent %q", got)
+	}
+	if got := req.Header.Get("X-Gateway-Route"); got != "nexus-internal" {
+		t.Errorf("X-Gateway-Route %q, want the configured header", got)
+	}
+
+	// Three tags reach max_components_per_repo, so nothing is deleted
+	engine := retention.NewEngine(client, cfg, retention.Discard, retention.Options{})
+	var result *retention.RunResult
+	quiet(func() { result, err = engine.Execute() })
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if len(nexus.deleted) != 0 || result.Repositories[0].Error == "" {
+		t.Errorf("deleted %v from a listing that reached max_components_per_repo", nexus.deleted)
+	}
+}
@@ -0,0 +1,149 @@
+// Package retention is the public API for embedding the retention policy
+// engine in other Go programs. It exposes the configuration, the registry
+// clients and the engine that the command line tool is built on; the
+// internal packages may change without notice, this package does not.
+//
+// A dry run against Nexus, collecting the planned deletions:
+//
+//	cfg, err := retention.LoadConfig("config.yaml")
+//	if err != nil {
+//		return err
+//	}
+//	client := retention.NewNexusClient(cfg)
+//	engine := retention.NewEngine(client, cfg, retention.Discard, retention.Options{DryRun: true})
+//
+//	result, err := engine.Execute()
+//	if err != nil {
+//		return err
+//	}
+//	for _, repo := range result.Repositories {
+//		for _, image := range repo.Images {
+//			for _, d := range image.Decisions {
+//				if d.Action == retention.ActionDelete {
+//					fmt.Println(repo.Name, image.Name, d.Component.Version)
+//				}
+//			}
+//		}
+//	}
+//
+// Execute also prints its progress to stdout, like the command line tool.
+package retention
+
+import (
+	"nexus-retention-policy/internal/config"
+	"nexus-retention-policy/internal/logger"
+	"nexus-retention-policy/internal/nexus"
+	"nexus-retention-policy/internal/registry"
+	"nexus-retention-policy/internal/retention"
+)
+
+// Configuration.
+type (
+	// Config is a validated configuration with compiled rules
+	Config = config.Config
+	// Rule is a retention rule of the configuration
+	Rule = config.Rule
+)
+
+// Registry access.
+type (
+	// Registry is the backend cleaned by the engine
+	Registry = retention.Registry
+	// NexusClient is the Nexus REST API client
+	NexusClient = nexus.Client
+	// Repository is a registry repository
+	Repository = nexus.Repository
+	// Component is a single image tag or Maven version
+	Component = nexus.Component
+	// Asset is a file of a component
+	Asset = nexus.Asset
+	// APIError is returned for unsuccessful registry responses
+	APIError = nexus.APIError
+)
+
+// Engine and results.
+type (
+	// Engine applies the retention rules of a configuration to a registry
+	Engine = retention.PolicyEngine
+	// Options controls how the engine runs
+	Options = retention.Options
+	// RunResult is the outcome of Engine.Execute
+	RunResult = retention.RunResult
+	// RepositoryResult is the outcome for a single repository
+	RepositoryResult = retention.RepositoryResult
+	// ImageResult holds the decisions for one image
+	ImageResult = retention.ImageResult
+	// Decision is the planned action for a component and why
+	Decision = retention.Decision
+	// Action is the outcome planned for a component
+	Action = retention.Action
+	// Plan is a set of deletions that can be reviewed and applied later
+	Plan = retention.Plan
+	// PlannedDeletion identifies a single component of a plan
+	PlannedDeletion = retention.PlannedDeletion
+	// DeletionLogger records deletions performed or planned by the engine
+	DeletionLogger = retention.DeletionLogger
+	// DeletionRecord is a single deletion passed to a DeletionLogger
+	DeletionRecord = logger.DeletionRecord
+)
+
+// Errors returned by runs.
+type (
+	// PartialFailureError is returned by a run in which some requests failed
+	PartialFailureError = retention.PartialFailureError
+	// GuardrailError is returned when a safety check stopped a run
+	GuardrailError = retention.GuardrailError
+	// CircuitOpenError is returned when failing requests aborted a run
+	CircuitOpenError = retention.CircuitOpenError
+)
+
+// ErrStopIteration stops NexusClient.IterComponents early without an error.
+var ErrStopIteration = nexus.ErrStopIteration
+
+// Actions of decisions.
+const (
+	ActionKeep      = retention.ActionKeep
+	ActionDelete    = retention.ActionDelete
+	ActionProtected = retention.ActionProtected
+)
+
+// LoadConfig reads and validates a configuration from a file path or an
+// http(s) URL.
+func LoadConfig(path string) (*Config, error) {
+	return config.Load(path)
+}
+
+// ParseConfig validates a configuration from YAML.
+func ParseConfig(data []byte) (*Config, error) {
+	return config.Parse(data)
+}
+
+// userAgent identifies requests of embedded engines in registry access logs.
+// nexus.headers may replace it.
+const userAgent = "nexus-retention-policy/embedded"
+
+// NewNexusClient creates a Nexus client from a configuration like the
+// command line tool does: with the nexus section's credentials, API key and
+// headers, the credentials of repository_settings, page_size and
+// max_components_per_repo.
+func NewNexusClient(cfg *Config) *NexusClient {
+	return registry.NewNexus(cfg, userAgent)
+}
+
+// NewEngine creates an engine applying the configuration to the registry.
+// Deletions are recorded with log, which may be Discard.
+func NewEngine(registry Registry, cfg *Config, log DeletionLogger, opts Options) *Engine {
+	return retention.NewPolicyEngine(registry, cfg, log, opts)
+}
+
+// LoadPlan reads a plan written by Plan.Save.
+func LoadPlan(path string) (*Plan, error) {
+	return retention.LoadPlan(path)
+}
+
+// Discard is a DeletionLogger that drops all records.
+var Discard DeletionLogger = discard{}
+
+type discard struct{}
+
+func (discard) LogDeletion(DeletionRecord) error { return nil }